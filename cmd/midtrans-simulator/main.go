@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/hiuncy/spp-payment-api/internal/midtranssim"
+)
+
+func main() {
+	port := flag.String("port", getEnv("SIMULATOR_PORT", "8090"), "port simulator")
+	serverKey := flag.String("server-key", os.Getenv("MIDTRANS_SERVER_KEY"), "server key untuk autentikasi dan signature")
+	notificationURL := flag.String("notification-url", getEnv("SIMULATOR_NOTIFICATION_URL", "http://localhost:8080/api/v1/payments/midtrans-notification"), "URL webhook notifikasi")
+	flag.Parse()
+
+	simulator := midtranssim.New(midtranssim.Options{
+		ServerKey:       *serverKey,
+		NotificationURL: *notificationURL,
+	})
+	addr := ":" + *port
+	simulator.SetBaseURL(fmt.Sprintf("http://localhost%s", addr))
+
+	log.Printf("Midtrans simulator berjalan di %s, notifikasi dikirim ke %s", addr, *notificationURL)
+	if err := http.ListenAndServe(addr, simulator); err != nil {
+		log.Fatalf("Gagal menjalankan simulator: %v", err)
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -1,7 +1,11 @@
 package config
 
 import (
+	"errors"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -18,6 +22,16 @@ type Config struct {
 	MidtransServerKey   string
 	MidtransClientKey   string
 	MidtransEnvironment string
+	MidtransBaseURL     string
+	FakeGatewayEnabled  bool
+	FakeGatewaySecret   string
+	ReconcileInterval   time.Duration
+	ReconcilePendingAge time.Duration
+	ReconcileExpireAge  time.Duration
+	LateFeeInterval     time.Duration
+	PeriodInterval      time.Duration
+	UploadDir           string
+	UploadMaxSize       int64
 }
 
 func LoadConfig() (*Config, error) {
@@ -26,7 +40,7 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
-	return &Config{
+	cfg := &Config{
 		ServerPort:          os.Getenv("SERVER_PORT"),
 		DBHost:              os.Getenv("DB_HOST"),
 		DBPort:              os.Getenv("DB_PORT"),
@@ -38,5 +52,39 @@ func LoadConfig() (*Config, error) {
 		MidtransServerKey:   os.Getenv("MIDTRANS_SERVER_KEY"),
 		MidtransClientKey:   os.Getenv("MIDTRANS_CLIENT_KEY"),
 		MidtransEnvironment: os.Getenv("MIDTRANS_ENVIRONMENT"),
-	}, nil
+		MidtransBaseURL:     strings.TrimRight(os.Getenv("MIDTRANS_BASE_URL"), "/"),
+		FakeGatewayEnabled:  os.Getenv("FAKE_GATEWAY_ENABLED") == "true",
+		FakeGatewaySecret:   os.Getenv("FAKE_GATEWAY_SECRET"),
+		ReconcileInterval:   getEnvMinutes("RECONCILE_INTERVAL_MINUTES", 15),
+		ReconcilePendingAge: getEnvMinutes("RECONCILE_PENDING_AGE_MINUTES", 30),
+		ReconcileExpireAge:  getEnvMinutes("RECONCILE_EXPIRE_AGE_MINUTES", 1440),
+		LateFeeInterval:     getEnvMinutes("LATE_FEE_INTERVAL_MINUTES", 1440),
+		PeriodInterval:      getEnvMinutes("PERIOD_LIFECYCLE_INTERVAL_MINUTES", 60),
+		UploadDir:           getEnv("UPLOAD_DIR", "uploads"),
+		UploadMaxSize:       int64(getEnvInt("UPLOAD_MAX_SIZE_KB", 2048)) * 1024,
+	}
+	if cfg.FakeGatewayEnabled && cfg.FakeGatewaySecret == "" {
+		return nil, errors.New("FAKE_GATEWAY_SECRET wajib diisi jika FAKE_GATEWAY_ENABLED=true")
+	}
+
+	return cfg, nil
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+func getEnvMinutes(key string, fallback int) time.Duration {
+	return time.Duration(getEnvInt(key, fallback)) * time.Minute
 }
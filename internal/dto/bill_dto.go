@@ -6,9 +6,43 @@ type FindAllBillsInput struct {
 	PeriodeID        uint
 	SiswaID          uint
 	StatusPembayaran string
+	JenisBiayaID     uint
+}
+
+type GenerateBillsInput struct {
+	JenisBiayaID uint
+	KelasIDs     []uint
+	TingkatIDs   []uint
+	SiswaIDs     []uint
+	DryRun       bool
 }
 
 type UpdateBillInput struct {
-	JumlahTagihan    float64
-	StatusPembayaran string
+	JumlahTagihan float64
+}
+
+type InstallmentInput struct {
+	Jumlah            float64
+	TanggalJatuhTempo string
+}
+
+type WaiveBillsInput struct {
+	TagihanIDs     []uint
+	PeriodeID      uint
+	JenisBiayaID   uint
+	KelasIDs       []uint
+	TingkatIDs     []uint
+	SiswaIDs       []uint
+	Alasan         string
+	DibebaskanOleh uint
+}
+
+type ExemptionInput struct {
+	PeriodeID    uint
+	JenisBiayaID *uint
+	TingkatID    *uint
+	KelasID      *uint
+	SiswaID      *uint
+	Alasan       string
+	DibuatOleh   uint
 }
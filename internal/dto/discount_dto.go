@@ -0,0 +1,18 @@
+package dto
+
+type DiscountTypeInput struct {
+	NamaPotongan     string
+	JenisBiayaID     uint
+	TipePotongan     string
+	Nilai            float64
+	MaksimalPotongan *float64
+	Keterangan       string
+	Status           string
+}
+
+type StudentDiscountInput struct {
+	JenisPotonganID  uint
+	PeriodeMulaiID   uint
+	PeriodeSelesaiID *uint
+	Keterangan       string
+}
@@ -0,0 +1,17 @@
+package dto
+
+import "github.com/hiuncy/spp-payment-api/internal/model"
+
+type FamilyInput struct {
+	NamaKeluarga    string
+	NamaOrangtua    string
+	TeleponOrangtua string
+	Keterangan      string
+	SiswaIDs        []uint
+}
+
+type FamilySuggestion struct {
+	NamaOrangtua    string
+	TeleponOrangtua string
+	Siswa           []model.Siswa
+}
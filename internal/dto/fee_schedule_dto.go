@@ -0,0 +1,23 @@
+package dto
+
+type FeeScheduleInput struct {
+	JenisBiayaID  uint
+	TingkatID     uint
+	TahunAjaranID uint
+	Jumlah        float64
+	BerlakuMulai  string
+	Keterangan    string
+}
+
+type FeeIncreasePreviewInput struct {
+	TahunAjaranID  uint
+	JenisBiayaID   uint
+	KenaikanPersen float64
+	Tarif          []FeeRateInput
+}
+
+type FindAllFeeSchedulesInput struct {
+	TahunAjaranID uint
+	JenisBiayaID  uint
+	TingkatID     uint
+}
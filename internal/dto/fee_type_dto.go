@@ -0,0 +1,16 @@
+package dto
+
+type FeeTypeInput struct {
+	KodeBiaya    string
+	NamaBiaya    string
+	Periodisitas string
+	Wajib        bool
+	Keterangan   string
+	Status       string
+	Tarif        []FeeRateInput
+}
+
+type FeeRateInput struct {
+	TingkatID uint
+	Jumlah    float64
+}
@@ -0,0 +1,108 @@
+package dto
+
+import (
+	"io"
+	"time"
+)
+
+type TransactionItem struct {
+	ID       string
+	Name     string
+	Price    int64
+	Quantity int32
+}
+
+type TransactionCustomer struct {
+	Name  string
+	Email string
+	Phone string
+}
+
+type TransactionRequest struct {
+	OrderID         string
+	GrossAmount     int64
+	Items           []TransactionItem
+	Customer        *TransactionCustomer
+	EnabledPayments []string
+	Expiry          time.Duration
+	VANumber        string
+}
+
+type VirtualAccount struct {
+	Bank          string
+	Number        string
+	TransactionID string
+	ExpiresAt     *time.Time
+	RawResponse   string
+}
+
+type PaymentInstruction struct {
+	SnapToken          string
+	Bank               string
+	NomorVA            string
+	TanggalKedaluwarsa *time.Time
+}
+
+type PaymentStatusUpdate struct {
+	Gateway           string
+	OrderID           string
+	TransactionID     string
+	TransactionStatus string
+	FraudStatus       string
+	PaymentType       string
+	GrossAmount       string
+	TransactionTime   string
+	SettlementTime    string
+	RawResponse       string
+}
+
+type ManualPaymentInput struct {
+	TagihanID        uint
+	Jumlah           float64
+	MetodePembayaran string
+	TanggalDiterima  string
+	NomorReferensi   string
+	Keterangan       string
+	DiterimaOleh     uint
+}
+
+type FindAllNotificationsInput struct {
+	Page         int
+	Limit        int
+	OrderID      string
+	StatusProses string
+}
+
+type UploadTransferProofInput struct {
+	TagihanID       uint
+	UserID          uint
+	Jumlah          float64
+	TanggalTransfer string
+	NomorReferensi  string
+	Keterangan      string
+	NamaFile        string
+	Ukuran          int64
+	File            io.Reader
+}
+
+type FindAllTransferProofsInput struct {
+	Page             int
+	Limit            int
+	UserID           uint
+	SiswaID          uint
+	StatusVerifikasi string
+}
+
+type RefundInput struct {
+	OrderID      string
+	Jumlah       float64
+	Alasan       string
+	DiajukanOleh uint
+}
+
+type FindAllRefundsInput struct {
+	Page         int
+	Limit        int
+	OrderID      string
+	StatusRefund string
+}
@@ -1,18 +1,30 @@
 package dto
 
 type CreatePeriodInput struct {
-	TahunAjaran    string
-	Bulan          int
-	NamaBulan      string
-	TanggalMulai   string
-	TanggalSelesai string
+	TahunAjaranID     uint
+	Bulan             int
+	TanggalMulai      string
+	TanggalSelesai    string
+	TanggalJatuhTempo string
 }
 
 type UpdatePeriodInput struct {
-	TahunAjaran    string
-	Bulan          int
-	NamaBulan      string
-	TanggalMulai   string
-	TanggalSelesai string
-	Status         string
+	TahunAjaranID     uint
+	Bulan             int
+	TanggalMulai      string
+	TanggalSelesai    string
+	TanggalJatuhTempo string
+	Status            string
+}
+
+type AcademicYearInput struct {
+	NamaTahunAjaran string
+	TanggalMulai    string
+	TanggalSelesai  string
+	Aktif           bool
+}
+
+type GeneratePeriodsInput struct {
+	Bulan             []int
+	TanggalJatuhTempo int
 }
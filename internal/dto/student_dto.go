@@ -13,6 +13,7 @@ type CreateStudentInput struct {
 	NamaOrangTua    string
 	TeleponOrangTua string
 	TahunMasuk      int
+	TanggalMasuk    string
 }
 
 type UpdateStudentInput struct {
@@ -26,6 +27,7 @@ type UpdateStudentInput struct {
 	NamaOrangTua    string
 	TeleponOrangTua string
 	TahunMasuk      int
+	TanggalMasuk    *string
 	Status          string
 	EmailUser       string
 	StatusUser      string
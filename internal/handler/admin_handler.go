@@ -31,17 +31,39 @@ type AdminHandler interface {
 	DeleteClass(c *gin.Context)
 	FindAllSettings(c *gin.Context)
 	UpdateSettings(c *gin.Context)
+	ApproveRefund(c *gin.Context)
+	RejectRefund(c *gin.Context)
+	CreateDiscountType(c *gin.Context)
+	FindAllDiscountTypes(c *gin.Context)
+	FindDiscountTypeByID(c *gin.Context)
+	UpdateDiscountType(c *gin.Context)
+	DeleteDiscountType(c *gin.Context)
+	CreateFeeType(c *gin.Context)
+	FindAllFeeTypes(c *gin.Context)
+	FindFeeTypeByID(c *gin.Context)
+	UpdateFeeType(c *gin.Context)
+	DeleteFeeType(c *gin.Context)
+	CreateFeeSchedule(c *gin.Context)
+	FindAllFeeSchedules(c *gin.Context)
+	FindFeeScheduleByID(c *gin.Context)
+	UpdateFeeSchedule(c *gin.Context)
+	DeleteFeeSchedule(c *gin.Context)
+	PreviewFeeIncrease(c *gin.Context)
 }
 
 type adminHandler struct {
-	userService       service.UserService
-	classLevelService service.ClassLevelService
-	classService      service.ClassService
-	settingService    service.SettingService
+	userService        service.UserService
+	classLevelService  service.ClassLevelService
+	classService       service.ClassService
+	settingService     service.SettingService
+	refundService      service.RefundService
+	discountService    service.DiscountService
+	feeTypeService     service.FeeTypeService
+	feeScheduleService service.FeeScheduleService
 }
 
-func NewAdminHandler(userService service.UserService, classLevelService service.ClassLevelService, classService service.ClassService, settingService service.SettingService) AdminHandler {
-	return &adminHandler{userService, classLevelService, classService, settingService}
+func NewAdminHandler(userService service.UserService, classLevelService service.ClassLevelService, classService service.ClassService, settingService service.SettingService, refundService service.RefundService, discountService service.DiscountService, feeTypeService service.FeeTypeService, feeScheduleService service.FeeScheduleService) AdminHandler {
+	return &adminHandler{userService, classLevelService, classService, settingService, refundService, discountService, feeTypeService, feeScheduleService}
 }
 
 func (h *adminHandler) CreateUser(c *gin.Context) {
@@ -440,3 +462,385 @@ func (h *adminHandler) UpdateSettings(c *gin.Context) {
 
 	utils.SendSuccessResponse(c, http.StatusOK, "Pengaturan berhasil diperbarui", nil)
 }
+
+func (h *adminHandler) ApproveRefund(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID refund tidak valid")
+		return
+	}
+
+	refund, err := h.refundService.Approve(uint(id), userID)
+	if err != nil {
+		sendRefundError(c, err)
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Refund disetujui dan berhasil diproses", utils.FormatRefundResponse(refund))
+}
+
+func (h *adminHandler) RejectRefund(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID refund tidak valid")
+		return
+	}
+
+	var req utils.RejectRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	refund, err := h.refundService.Reject(uint(id), userID, req.Alasan)
+	if err != nil {
+		sendRefundError(c, err)
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Refund ditolak", utils.FormatRefundResponse(refund))
+}
+
+func (h *adminHandler) CreateDiscountType(c *gin.Context) {
+	var req utils.DiscountTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	discountType, err := h.discountService.CreateDiscountType(discountTypeInput(req))
+	if err != nil {
+		h.sendDiscountTypeError(c, err, "Gagal membuat jenis potongan")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusCreated, "Jenis potongan berhasil dibuat", utils.FormatDiscountTypeResponse(discountType))
+}
+
+func (h *adminHandler) FindAllDiscountTypes(c *gin.Context) {
+	discountTypes, err := h.discountService.FindAllDiscountTypes()
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengambil data jenis potongan")
+		return
+	}
+
+	responses := []utils.DiscountTypeResponse{}
+	for _, discountType := range discountTypes {
+		responses = append(responses, utils.FormatDiscountTypeResponse(&discountType))
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Data jenis potongan berhasil diambil", responses)
+}
+
+func (h *adminHandler) FindDiscountTypeByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID jenis potongan tidak valid")
+		return
+	}
+
+	discountType, err := h.discountService.FindDiscountTypeByID(uint(id))
+	if err != nil {
+		h.sendDiscountTypeError(c, err, "Gagal mengambil data jenis potongan")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Detail jenis potongan berhasil diambil", utils.FormatDiscountTypeResponse(discountType))
+}
+
+func (h *adminHandler) UpdateDiscountType(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID jenis potongan tidak valid")
+		return
+	}
+
+	var req utils.DiscountTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	discountType, err := h.discountService.UpdateDiscountType(uint(id), discountTypeInput(req))
+	if err != nil {
+		h.sendDiscountTypeError(c, err, "Gagal memperbarui jenis potongan")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Jenis potongan berhasil diperbarui", utils.FormatDiscountTypeResponse(discountType))
+}
+
+func (h *adminHandler) DeleteDiscountType(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID jenis potongan tidak valid")
+		return
+	}
+
+	if err := h.discountService.DeleteDiscountType(uint(id)); err != nil {
+		h.sendDiscountTypeError(c, err, "Gagal menghapus jenis potongan")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Jenis potongan berhasil dihapus", nil)
+}
+
+func (h *adminHandler) sendDiscountTypeError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.SendErrorResponse(c, http.StatusNotFound, "Jenis potongan tidak ditemukan")
+	case errors.Is(err, service.ErrInvalidDiscount), errors.Is(err, service.ErrInvalidFeeType):
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrDiscountTypeExists), errors.Is(err, service.ErrDiscountTypeInUse):
+		utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+	default:
+		utils.SendErrorResponse(c, http.StatusInternalServerError, message)
+	}
+}
+
+func discountTypeInput(req utils.DiscountTypeRequest) dto.DiscountTypeInput {
+	return dto.DiscountTypeInput{
+		NamaPotongan:     req.NamaPotongan,
+		JenisBiayaID:     req.JenisBiayaID,
+		TipePotongan:     req.TipePotongan,
+		Nilai:            req.Nilai,
+		MaksimalPotongan: req.MaksimalPotongan,
+		Keterangan:       req.Keterangan,
+		Status:           req.Status,
+	}
+}
+
+func (h *adminHandler) CreateFeeType(c *gin.Context) {
+	var req utils.FeeTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	feeType, err := h.feeTypeService.CreateFeeType(feeTypeInput(req))
+	if err != nil {
+		h.sendFeeTypeError(c, err, "Gagal membuat jenis biaya")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusCreated, "Jenis biaya berhasil dibuat", utils.FormatFeeTypeResponse(feeType))
+}
+
+func (h *adminHandler) FindAllFeeTypes(c *gin.Context) {
+	feeTypes, err := h.feeTypeService.FindAllFeeTypes()
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengambil data jenis biaya")
+		return
+	}
+
+	responses := []utils.FeeTypeResponse{}
+	for _, feeType := range feeTypes {
+		responses = append(responses, utils.FormatFeeTypeResponse(&feeType))
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Data jenis biaya berhasil diambil", responses)
+}
+
+func (h *adminHandler) FindFeeTypeByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID jenis biaya tidak valid")
+		return
+	}
+
+	feeType, err := h.feeTypeService.FindFeeTypeByID(uint(id))
+	if err != nil {
+		h.sendFeeTypeError(c, err, "Gagal mengambil data jenis biaya")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Detail jenis biaya berhasil diambil", utils.FormatFeeTypeResponse(feeType))
+}
+
+func (h *adminHandler) UpdateFeeType(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID jenis biaya tidak valid")
+		return
+	}
+
+	var req utils.FeeTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	feeType, err := h.feeTypeService.UpdateFeeType(uint(id), feeTypeInput(req))
+	if err != nil {
+		h.sendFeeTypeError(c, err, "Gagal memperbarui jenis biaya")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Jenis biaya berhasil diperbarui", utils.FormatFeeTypeResponse(feeType))
+}
+
+func (h *adminHandler) DeleteFeeType(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID jenis biaya tidak valid")
+		return
+	}
+
+	if err := h.feeTypeService.DeleteFeeType(uint(id)); err != nil {
+		h.sendFeeTypeError(c, err, "Gagal menghapus jenis biaya")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Jenis biaya berhasil dihapus", nil)
+}
+
+func (h *adminHandler) sendFeeTypeError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.SendErrorResponse(c, http.StatusNotFound, "Jenis biaya tidak ditemukan")
+	case errors.Is(err, service.ErrInvalidFeeType):
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrFeeTypeExists), errors.Is(err, service.ErrFeeTypeInUse):
+		utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+	default:
+		utils.SendErrorResponse(c, http.StatusInternalServerError, message)
+	}
+}
+
+func feeTypeInput(req utils.FeeTypeRequest) dto.FeeTypeInput {
+	input := dto.FeeTypeInput{
+		KodeBiaya:    req.KodeBiaya,
+		NamaBiaya:    req.NamaBiaya,
+		Periodisitas: req.Periodisitas,
+		Wajib:        *req.Wajib,
+		Keterangan:   req.Keterangan,
+		Status:       req.Status,
+	}
+	for _, rate := range req.Tarif {
+		input.Tarif = append(input.Tarif, dto.FeeRateInput{TingkatID: rate.TingkatID, Jumlah: rate.Jumlah})
+	}
+	return input
+}
+
+func (h *adminHandler) CreateFeeSchedule(c *gin.Context) {
+	var req utils.FeeScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	schedule, err := h.feeScheduleService.CreateFeeSchedule(feeScheduleInput(req))
+	if err != nil {
+		h.sendFeeScheduleError(c, err, "Gagal membuat jadwal tarif")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusCreated, "Jadwal tarif berhasil dibuat", utils.FormatFeeScheduleResponse(schedule))
+}
+
+func (h *adminHandler) FindAllFeeSchedules(c *gin.Context) {
+	tahunAjaranID, _ := strconv.Atoi(c.Query("tahun_ajaran_id"))
+	jenisBiayaID, _ := strconv.Atoi(c.Query("jenis_biaya_id"))
+	tingkatID, _ := strconv.Atoi(c.Query("tingkat_id"))
+	schedules, err := h.feeScheduleService.FindAllFeeSchedules(dto.FindAllFeeSchedulesInput{
+		TahunAjaranID: uint(tahunAjaranID),
+		JenisBiayaID:  uint(jenisBiayaID),
+		TingkatID:     uint(tingkatID),
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengambil data jadwal tarif")
+		return
+	}
+
+	responses := []utils.FeeScheduleResponse{}
+	for _, schedule := range schedules {
+		responses = append(responses, utils.FormatFeeScheduleResponse(&schedule))
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Data jadwal tarif berhasil diambil", responses)
+}
+
+func (h *adminHandler) FindFeeScheduleByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID jadwal tarif tidak valid")
+		return
+	}
+
+	schedule, err := h.feeScheduleService.FindFeeScheduleByID(uint(id))
+	if err != nil {
+		h.sendFeeScheduleError(c, err, "Gagal mengambil detail jadwal tarif")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Detail jadwal tarif berhasil diambil", utils.FormatFeeScheduleResponse(schedule))
+}
+
+func (h *adminHandler) UpdateFeeSchedule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID jadwal tarif tidak valid")
+		return
+	}
+
+	var req utils.FeeScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	schedule, err := h.feeScheduleService.UpdateFeeSchedule(uint(id), feeScheduleInput(req))
+	if err != nil {
+		h.sendFeeScheduleError(c, err, "Gagal memperbarui jadwal tarif")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Jadwal tarif berhasil diperbarui", utils.FormatFeeScheduleResponse(schedule))
+}
+
+func (h *adminHandler) DeleteFeeSchedule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID jadwal tarif tidak valid")
+		return
+	}
+
+	if err := h.feeScheduleService.DeleteFeeSchedule(uint(id)); err != nil {
+		h.sendFeeScheduleError(c, err, "Gagal menghapus jadwal tarif")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Jadwal tarif berhasil dihapus", nil)
+}
+
+func (h *adminHandler) PreviewFeeIncrease(c *gin.Context) {
+	var req utils.FeeIncreasePreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	input := dto.FeeIncreasePreviewInput{
+		TahunAjaranID:  req.TahunAjaranID,
+		JenisBiayaID:   req.JenisBiayaID,
+		KenaikanPersen: req.KenaikanPersen,
+	}
+	for _, rate := range req.Tarif {
+		input.Tarif = append(input.Tarif, dto.FeeRateInput{TingkatID: rate.TingkatID, Jumlah: rate.Jumlah})
+	}
+	preview, err := h.feeScheduleService.PreviewFeeIncrease(input)
+	if err != nil {
+		h.sendFeeScheduleError(c, err, "Gagal menghitung proyeksi pendapatan")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Proyeksi pendapatan berhasil dihitung", preview)
+}
+
+func (h *adminHandler) sendFeeScheduleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.SendErrorResponse(c, http.StatusNotFound, "Jadwal tarif tidak ditemukan")
+	case errors.Is(err, service.ErrInvalidFeeSchedule), errors.Is(err, service.ErrInvalidFeeType):
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrFeeScheduleExists), errors.Is(err, service.ErrFeeScheduleInUse):
+		utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+	default:
+		utils.SendErrorResponse(c, http.StatusInternalServerError, message)
+	}
+}
+
+func feeScheduleInput(req utils.FeeScheduleRequest) dto.FeeScheduleInput {
+	return dto.FeeScheduleInput{
+		JenisBiayaID:  req.JenisBiayaID,
+		TingkatID:     req.TingkatID,
+		TahunAjaranID: req.TahunAjaranID,
+		Jumlah:        req.Jumlah,
+		BerlakuMulai:  req.BerlakuMulai,
+		Keterangan:    req.Keterangan,
+	}
+}
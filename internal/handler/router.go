@@ -12,12 +12,12 @@ type Router struct {
 	adminHandler     AdminHandler
 	treasurerHandler TreasurerHandler
 	studentHandler   StudentHandler
-	midtransHandler  MidtransHandler
+	webhookHandler   WebhookHandler
 	jwtSecretKey     string
 }
 
-func NewRouter(engine *gin.Engine, authHandler AuthHandler, adminHandler AdminHandler, treasurerHandler TreasurerHandler, studentHandler StudentHandler, midtransHandler MidtransHandler, jwtSecretKey string) *Router {
-	return &Router{engine, authHandler, adminHandler, treasurerHandler, studentHandler, midtransHandler, jwtSecretKey}
+func NewRouter(engine *gin.Engine, authHandler AuthHandler, adminHandler AdminHandler, treasurerHandler TreasurerHandler, studentHandler StudentHandler, webhookHandler WebhookHandler, jwtSecretKey string) *Router {
+	return &Router{engine, authHandler, adminHandler, treasurerHandler, studentHandler, webhookHandler, jwtSecretKey}
 }
 
 func (r *Router) SetupRoutes() {
@@ -27,8 +27,9 @@ func (r *Router) SetupRoutes() {
 	api.POST("/login", r.authHandler.Login)
 	api.GET("/me", middleware.AuthMiddleware(r.jwtSecretKey, "admin", "bendahara", "siswa"), r.authHandler.GetMe)
 
-	// Midtrans routes
-	api.POST("/payments/midtrans-notification", r.midtransHandler.HandleNotification)
+	// Payment gateway webhook routes
+	api.POST("/payments/midtrans-notification", r.webhookHandler.HandleNotification)
+	api.POST("/payments/:gateway/notification", r.webhookHandler.HandleNotification)
 
 	// Admin routes
 	admin := api.Group("/admin")
@@ -50,6 +51,24 @@ func (r *Router) SetupRoutes() {
 		admin.DELETE("/classes/:id", r.adminHandler.DeleteClass)
 		admin.GET("/settings", r.adminHandler.FindAllSettings)
 		admin.PUT("/settings", r.adminHandler.UpdateSettings)
+		admin.POST("/refunds/:id/approve", r.adminHandler.ApproveRefund)
+		admin.POST("/refunds/:id/reject", r.adminHandler.RejectRefund)
+		admin.POST("/discount-types", r.adminHandler.CreateDiscountType)
+		admin.GET("/discount-types", r.adminHandler.FindAllDiscountTypes)
+		admin.GET("/discount-types/:id", r.adminHandler.FindDiscountTypeByID)
+		admin.PUT("/discount-types/:id", r.adminHandler.UpdateDiscountType)
+		admin.DELETE("/discount-types/:id", r.adminHandler.DeleteDiscountType)
+		admin.POST("/fee-types", r.adminHandler.CreateFeeType)
+		admin.GET("/fee-types", r.adminHandler.FindAllFeeTypes)
+		admin.GET("/fee-types/:id", r.adminHandler.FindFeeTypeByID)
+		admin.PUT("/fee-types/:id", r.adminHandler.UpdateFeeType)
+		admin.DELETE("/fee-types/:id", r.adminHandler.DeleteFeeType)
+		admin.POST("/fee-schedules", r.adminHandler.CreateFeeSchedule)
+		admin.GET("/fee-schedules", r.adminHandler.FindAllFeeSchedules)
+		admin.POST("/fee-schedules/preview", r.adminHandler.PreviewFeeIncrease)
+		admin.GET("/fee-schedules/:id", r.adminHandler.FindFeeScheduleByID)
+		admin.PUT("/fee-schedules/:id", r.adminHandler.UpdateFeeSchedule)
+		admin.DELETE("/fee-schedules/:id", r.adminHandler.DeleteFeeSchedule)
 	}
 
 	// Treasurer routes
@@ -62,8 +81,30 @@ func (r *Router) SetupRoutes() {
 		treasurer.GET("/students/:id", r.treasurerHandler.FindStudentByID)
 		treasurer.PUT("/students/:id", r.treasurerHandler.UpdateStudent)
 		treasurer.DELETE("/students/:id", r.treasurerHandler.DeleteStudent)
+		treasurer.GET("/families/suggestions", r.treasurerHandler.FindFamilySuggestions)
+		treasurer.POST("/families", r.treasurerHandler.CreateFamily)
+		treasurer.GET("/families", r.treasurerHandler.FindAllFamilies)
+		treasurer.GET("/families/:id", r.treasurerHandler.FindFamilyByID)
+		treasurer.PUT("/families/:id", r.treasurerHandler.UpdateFamily)
+		treasurer.DELETE("/families/:id", r.treasurerHandler.DeleteFamily)
+		treasurer.GET("/discount-types", r.adminHandler.FindAllDiscountTypes)
+		treasurer.GET("/fee-types", r.adminHandler.FindAllFeeTypes)
+		treasurer.GET("/fee-schedules", r.adminHandler.FindAllFeeSchedules)
+		treasurer.POST("/fee-schedules/preview", r.adminHandler.PreviewFeeIncrease)
+		treasurer.GET("/students/:id/discounts", r.treasurerHandler.FindStudentDiscounts)
+		treasurer.POST("/students/:id/discounts", r.treasurerHandler.AssignStudentDiscount)
+		treasurer.PUT("/students/:id/discounts/:discount_id", r.treasurerHandler.UpdateStudentDiscount)
+		treasurer.DELETE("/students/:id/discounts/:discount_id", r.treasurerHandler.DeleteStudentDiscount)
+		treasurer.POST("/academic-years", r.treasurerHandler.CreateAcademicYear)
+		treasurer.GET("/academic-years", r.treasurerHandler.FindAllAcademicYears)
+		treasurer.GET("/academic-years/active", r.treasurerHandler.FindActiveAcademicYear)
+		treasurer.GET("/academic-years/:id", r.treasurerHandler.FindAcademicYearByID)
+		treasurer.PUT("/academic-years/:id", r.treasurerHandler.UpdateAcademicYear)
+		treasurer.DELETE("/academic-years/:id", r.treasurerHandler.DeleteAcademicYear)
+		treasurer.POST("/academic-years/:id/generate-periods", r.treasurerHandler.GeneratePeriods)
 		treasurer.POST("/periods", r.treasurerHandler.CreatePeriod)
 		treasurer.GET("/periods", r.treasurerHandler.FindAllPeriods)
+		treasurer.POST("/periods/lifecycle/run", r.treasurerHandler.RunPeriodLifecycle)
 		treasurer.GET("/periods/:id", r.treasurerHandler.FindPeriodByID)
 		treasurer.PUT("/periods/:id", r.treasurerHandler.UpdatePeriod)
 		treasurer.DELETE("/periods/:id", r.treasurerHandler.DeletePeriod)
@@ -72,11 +113,38 @@ func (r *Router) SetupRoutes() {
 		treasurer.GET("/bills/:id", r.treasurerHandler.FindBillByID)
 		treasurer.PUT("/bills/:id", r.treasurerHandler.UpdateBill)
 		treasurer.DELETE("/bills/:id", r.treasurerHandler.DeleteBill)
+		treasurer.POST("/bills/waive", r.treasurerHandler.WaiveBills)
+		treasurer.POST("/exemptions", r.treasurerHandler.CreateExemption)
+		treasurer.GET("/exemptions", r.treasurerHandler.FindAllExemptions)
+		treasurer.GET("/exemptions/:id", r.treasurerHandler.FindExemptionByID)
+		treasurer.DELETE("/exemptions/:id", r.treasurerHandler.DeleteExemption)
+		treasurer.PUT("/bills/:id/installments", r.treasurerHandler.SetInstallments)
+		treasurer.DELETE("/bills/:id/installments", r.treasurerHandler.DeleteInstallments)
+		treasurer.POST("/bills/:id/payments", r.treasurerHandler.RecordManualPayment)
+		treasurer.GET("/bills/:id/late-fees", r.treasurerHandler.FindLateFees)
+		treasurer.POST("/bills/:id/late-fees/:fee_id/waive", r.treasurerHandler.WaiveLateFee)
+		treasurer.POST("/late-fees/run", r.treasurerHandler.RunLateFees)
+		treasurer.GET("/payments/:order_id/receipt", r.treasurerHandler.GetReceipt)
+		treasurer.POST("/payments/:order_id/refunds", r.treasurerHandler.RequestRefund)
+		treasurer.GET("/refunds", r.treasurerHandler.FindAllRefunds)
+		treasurer.GET("/refunds/:id", r.treasurerHandler.FindRefundByID)
+		treasurer.GET("/transfer-proofs", r.treasurerHandler.FindAllTransferProofs)
+		treasurer.GET("/transfer-proofs/:id", r.treasurerHandler.FindTransferProofByID)
+		treasurer.GET("/transfer-proofs/:id/file", r.treasurerHandler.GetTransferProofFile)
+		treasurer.POST("/transfer-proofs/:id/approve", r.treasurerHandler.ApproveTransferProof)
+		treasurer.POST("/transfer-proofs/:id/reject", r.treasurerHandler.RejectTransferProof)
+		treasurer.GET("/notifications", r.treasurerHandler.FindAllNotifications)
+		treasurer.GET("/notifications/:id", r.treasurerHandler.FindNotificationByID)
+		treasurer.POST("/notifications/:id/reprocess", r.treasurerHandler.ReprocessNotification)
+		treasurer.GET("/reconciliations", r.treasurerHandler.FindAllReconciliations)
+		treasurer.GET("/reconciliations/:id", r.treasurerHandler.FindReconciliationByID)
+		treasurer.POST("/reconciliations/run", r.treasurerHandler.RunReconciliation)
 		reports := treasurer.Group("/reports")
 		{
 			reports.GET("/per-student", r.treasurerHandler.GetLaporanSiswa)
 			reports.GET("/per-class", r.treasurerHandler.GetLaporanKelas)
 			reports.GET("/overall", r.treasurerHandler.GetLaporanKeseluruhan)
+			reports.GET("/discounts", r.treasurerHandler.GetLaporanPotongan)
 		}
 	}
 
@@ -86,7 +154,13 @@ func (r *Router) SetupRoutes() {
 	{
 		student.GET("/profile", r.studentHandler.GetProfile)
 		student.GET("/bills", r.studentHandler.FindMyBills)
+		student.GET("/bills/grouped", r.studentHandler.FindMyBillsGrouped)
 		student.POST("/bills/:id/pay", r.studentHandler.InitiatePayment)
+		student.POST("/bills/:id/cancel-payment", r.studentHandler.CancelPayment)
+		student.POST("/checkout", r.studentHandler.Checkout)
+		student.POST("/bills/:id/transfer-proofs", r.studentHandler.UploadTransferProof)
+		student.GET("/transfer-proofs", r.studentHandler.FindMyTransferProofs)
 		student.GET("/payment-history", r.studentHandler.GetPaymentHistory)
+		student.GET("/payments/:order_id/receipt", r.studentHandler.GetReceipt)
 	}
 }
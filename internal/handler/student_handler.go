@@ -2,6 +2,7 @@ package handler
 
 import (
 	"errors"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -16,18 +17,26 @@ import (
 type StudentHandler interface {
 	GetProfile(c *gin.Context)
 	FindMyBills(c *gin.Context)
+	FindMyBillsGrouped(c *gin.Context)
 	InitiatePayment(c *gin.Context)
+	Checkout(c *gin.Context)
+	CancelPayment(c *gin.Context)
 	GetPaymentHistory(c *gin.Context)
+	UploadTransferProof(c *gin.Context)
+	FindMyTransferProofs(c *gin.Context)
+	GetReceipt(c *gin.Context)
 }
 
 type studentHandler struct {
-	studentService service.StudentService
-	billService    service.BillService
-	paymentService service.PaymentService
+	studentService       service.StudentService
+	billService          service.BillService
+	paymentService       service.PaymentService
+	transferProofService service.TransferProofService
+	receiptService       service.ReceiptService
 }
 
-func NewStudentHandler(studentService service.StudentService, billService service.BillService, paymentService service.PaymentService) StudentHandler {
-	return &studentHandler{studentService, billService, paymentService}
+func NewStudentHandler(studentService service.StudentService, billService service.BillService, paymentService service.PaymentService, transferProofService service.TransferProofService, receiptService service.ReceiptService) StudentHandler {
+	return &studentHandler{studentService, billService, paymentService, transferProofService, receiptService}
 }
 
 func (h *studentHandler) GetProfile(c *gin.Context) {
@@ -57,10 +66,12 @@ func (h *studentHandler) FindMyBills(c *gin.Context) {
 	}
 
 	status := c.Query("status")
+	jenisBiayaID, _ := strconv.Atoi(c.Query("jenis_biaya_id"))
 
 	input := dto.FindAllBillsInput{
 		SiswaID:          student.ID,
 		StatusPembayaran: status,
+		JenisBiayaID:     uint(jenisBiayaID),
 		Limit:            100,
 		Page:             1,
 	}
@@ -79,6 +90,24 @@ func (h *studentHandler) FindMyBills(c *gin.Context) {
 	utils.SendSuccessResponse(c, http.StatusOK, "Data tagihan berhasil diambil", responses)
 }
 
+func (h *studentHandler) FindMyBillsGrouped(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	student, err := h.studentService.GetStudentProfile(userID)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Profil siswa untuk pengguna ini tidak ditemukan")
+		return
+	}
+
+	bills, err := h.billService.FindStudentBills(student.ID, c.Query("status"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengambil data tagihan")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Data tagihan berhasil diambil", utils.FormatBillGroupResponses(bills))
+}
+
 func (h *studentHandler) InitiatePayment(c *gin.Context) {
 	userID := c.MustGet("userID").(uint)
 	billID, err := strconv.ParseUint(c.Param("id"), 10, 64)
@@ -87,13 +116,70 @@ func (h *studentHandler) InitiatePayment(c *gin.Context) {
 		return
 	}
 
-	snapToken, err := h.paymentService.InitiatePayment(uint(billID), userID)
+	var req utils.InitiatePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	instruction, err := h.paymentService.InitiatePayment(uint(billID), userID, req.Jumlah, req.MetodePembayaran)
 	if err != nil {
+		if errors.Is(err, service.ErrPendingPaymentExists) || errors.Is(err, service.ErrVirtualAccountInUse) {
+			utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
 		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	utils.SendSuccessResponse(c, http.StatusOK, "Token pembayaran berhasil dibuat", gin.H{"snap_token": snapToken})
+	sendPaymentInstruction(c, instruction)
+}
+
+func (h *studentHandler) Checkout(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	var req utils.CheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	instruction, err := h.paymentService.Checkout(req.TagihanIDs, userID, req.MetodePembayaran)
+	if err != nil {
+		if errors.Is(err, service.ErrPendingPaymentExists) || errors.Is(err, service.ErrVirtualAccountInUse) {
+			utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sendPaymentInstruction(c, instruction)
+}
+
+func (h *studentHandler) CancelPayment(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	billID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID tagihan tidak valid")
+		return
+	}
+
+	err = h.paymentService.CancelPendingPayment(uint(billID), userID)
+	if err != nil {
+		if errors.Is(err, service.ErrNoPendingPayment) {
+			utils.SendErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrManualPaymentPending) {
+			utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Pembayaran berhasil dibatalkan, silakan mulai pembayaran baru", nil)
 }
 
 func (h *studentHandler) GetPaymentHistory(c *gin.Context) {
@@ -107,16 +193,145 @@ func (h *studentHandler) GetPaymentHistory(c *gin.Context) {
 
 	var responses []utils.PaymentHistoryResponse
 	for _, p := range payments {
-		responses = append(responses, utils.PaymentHistoryResponse{
+		response := utils.PaymentHistoryResponse{
 			OrderID:           p.OrderID,
 			NamaPeriode:       p.TagihanSPP.PeriodeSPP.NamaBulan,
 			TahunAjaran:       p.TagihanSPP.PeriodeSPP.TahunAjaran,
 			JumlahBayar:       p.JumlahBayar,
+			JumlahRefund:      p.JumlahRefund,
+			BiayaAdmin:        p.BiayaAdmin,
 			StatusPembayaran:  p.StatusPembayaran,
 			MetodePembayaran:  p.MetodePembayaran,
 			TanggalPembayaran: p.TanggalPembayaran,
-		})
+			NomorKuitansi:     p.NomorKuitansi,
+			BankVA:            p.BankVA,
+			NomorVA:           p.NomorVA,
+			BatasPembayaran:   p.TanggalKedaluwarsa,
+			Tagihan:           []utils.PaymentHistoryBillResponse{},
+		}
+		for _, detail := range p.DetailTagihan {
+			response.Tagihan = append(response.Tagihan, utils.PaymentHistoryBillResponse{
+				TagihanID:   detail.TagihanID,
+				NamaPeriode: detail.TagihanSPP.PeriodeSPP.NamaBulan,
+				TahunAjaran: detail.TagihanSPP.PeriodeSPP.TahunAjaran,
+				Jumlah:      detail.Jumlah,
+			})
+		}
+		responses = append(responses, response)
 	}
 
 	utils.SendSuccessResponse(c, http.StatusOK, "Riwayat pembayaran berhasil diambil", responses)
 }
+
+func (h *studentHandler) UploadTransferProof(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	billID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID tagihan tidak valid")
+		return
+	}
+
+	var req utils.UploadTransferProofRequest
+	if err := c.ShouldBind(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	fileHeader, err := c.FormFile("bukti")
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "File bukti transfer wajib diunggah")
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "File bukti transfer tidak dapat dibaca")
+		return
+	}
+	defer file.Close()
+
+	input := dto.UploadTransferProofInput{
+		TagihanID:       uint(billID),
+		UserID:          userID,
+		Jumlah:          req.Jumlah,
+		TanggalTransfer: req.TanggalTransfer,
+		NomorReferensi:  req.NomorReferensi,
+		Keterangan:      req.Keterangan,
+		NamaFile:        fileHeader.Filename,
+		Ukuran:          fileHeader.Size,
+		File:            file,
+	}
+
+	proof, err := h.transferProofService.Upload(input)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "Tagihan tidak ditemukan")
+		case errors.Is(err, service.ErrTransferProofTooLarge):
+			utils.SendErrorResponse(c, http.StatusRequestEntityTooLarge, err.Error())
+		case errors.Is(err, service.ErrUnsupportedProofType):
+			utils.SendErrorResponse(c, http.StatusUnsupportedMediaType, err.Error())
+		case errors.Is(err, service.ErrPendingPaymentExists):
+			utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+		default:
+			utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, "Bukti transfer berhasil diunggah dan menunggu verifikasi bendahara", utils.FormatTransferProofResponse(proof))
+}
+
+func (h *studentHandler) FindMyTransferProofs(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	input := dto.FindAllTransferProofsInput{
+		Page:             1,
+		Limit:            100,
+		UserID:           userID,
+		StatusVerifikasi: c.Query("status_verifikasi"),
+	}
+
+	proofs, _, err := h.transferProofService.FindAll(input)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	var responses []utils.TransferProofResponse
+	for _, proof := range proofs {
+		responses = append(responses, utils.FormatTransferProofResponse(&proof))
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Data bukti transfer berhasil diambil", responses)
+}
+
+func (h *studentHandler) GetReceipt(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	content, filename, err := h.receiptService.GetStudentReceipt(c.Param("order_id"), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "Pembayaran tidak ditemukan")
+		case errors.Is(err, service.ErrReceiptNotAvailable):
+			utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal membuat kuitansi")
+		}
+		return
+	}
+	utils.SendFileResponse(c, "application/pdf", filename, content)
+}
+
+func sendPaymentInstruction(c *gin.Context, instruction *dto.PaymentInstruction) {
+	message := "Token pembayaran berhasil dibuat"
+	if instruction.NomorVA != "" {
+		message = "Nomor virtual account berhasil dibuat"
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, message, utils.PaymentInstructionResponse{
+		SnapToken:       instruction.SnapToken,
+		Bank:            instruction.Bank,
+		NomorVA:         instruction.NomorVA,
+		BatasPembayaran: instruction.TanggalKedaluwarsa,
+	})
+}
@@ -2,7 +2,10 @@ package handler
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"os"
 	"strconv"
 
 	"github.com/hiuncy/spp-payment-api/internal/dto"
@@ -24,25 +27,81 @@ type TreasurerHandler interface {
 	FindPeriodByID(c *gin.Context)
 	UpdatePeriod(c *gin.Context)
 	DeletePeriod(c *gin.Context)
+	RunPeriodLifecycle(c *gin.Context)
+	CreateAcademicYear(c *gin.Context)
+	FindAllAcademicYears(c *gin.Context)
+	FindActiveAcademicYear(c *gin.Context)
+	FindAcademicYearByID(c *gin.Context)
+	UpdateAcademicYear(c *gin.Context)
+	DeleteAcademicYear(c *gin.Context)
+	GeneratePeriods(c *gin.Context)
 	GenerateBills(c *gin.Context)
 	FindAllBills(c *gin.Context)
 	FindBillByID(c *gin.Context)
 	UpdateBill(c *gin.Context)
 	DeleteBill(c *gin.Context)
+	WaiveBills(c *gin.Context)
+	CreateExemption(c *gin.Context)
+	FindAllExemptions(c *gin.Context)
+	FindExemptionByID(c *gin.Context)
+	DeleteExemption(c *gin.Context)
+	SetInstallments(c *gin.Context)
+	DeleteInstallments(c *gin.Context)
+	RecordManualPayment(c *gin.Context)
 	GetLaporanSiswa(c *gin.Context)
 	GetLaporanKelas(c *gin.Context)
 	GetLaporanKeseluruhan(c *gin.Context)
+	GetLaporanPotongan(c *gin.Context)
+	FindAllNotifications(c *gin.Context)
+	FindNotificationByID(c *gin.Context)
+	ReprocessNotification(c *gin.Context)
+	FindAllReconciliations(c *gin.Context)
+	FindReconciliationByID(c *gin.Context)
+	RunReconciliation(c *gin.Context)
+	FindAllTransferProofs(c *gin.Context)
+	FindTransferProofByID(c *gin.Context)
+	GetTransferProofFile(c *gin.Context)
+	ApproveTransferProof(c *gin.Context)
+	RejectTransferProof(c *gin.Context)
+	GetReceipt(c *gin.Context)
+	RequestRefund(c *gin.Context)
+	FindAllRefunds(c *gin.Context)
+	FindRefundByID(c *gin.Context)
+	FindLateFees(c *gin.Context)
+	WaiveLateFee(c *gin.Context)
+	RunLateFees(c *gin.Context)
+	FindStudentDiscounts(c *gin.Context)
+	AssignStudentDiscount(c *gin.Context)
+	UpdateStudentDiscount(c *gin.Context)
+	DeleteStudentDiscount(c *gin.Context)
+	FindFamilySuggestions(c *gin.Context)
+	CreateFamily(c *gin.Context)
+	FindAllFamilies(c *gin.Context)
+	FindFamilyByID(c *gin.Context)
+	UpdateFamily(c *gin.Context)
+	DeleteFamily(c *gin.Context)
 }
 
 type treasurerHandler struct {
-	studentService service.StudentService
-	periodService  service.PeriodService
-	billService    service.BillService
-	reportService  service.ReportService
+	studentService        service.StudentService
+	periodService         service.PeriodService
+	billService           service.BillService
+	paymentService        service.PaymentService
+	reportService         service.ReportService
+	notificationService   service.NotificationService
+	reconciliationService service.ReconciliationService
+	transferProofService  service.TransferProofService
+	receiptService        service.ReceiptService
+	refundService         service.RefundService
+	lateFeeService        service.LateFeeService
+	discountService       service.DiscountService
+	familyService         service.FamilyService
+	academicYearService   service.AcademicYearService
+	exemptionService      service.ExemptionService
 }
 
-func NewTreasurerHandler(studentService service.StudentService, periodService service.PeriodService, billService service.BillService, reportService service.ReportService) TreasurerHandler {
-	return &treasurerHandler{studentService, periodService, billService, reportService}
+func NewTreasurerHandler(studentService service.StudentService, periodService service.PeriodService, billService service.BillService, paymentService service.PaymentService, reportService service.ReportService, notificationService service.NotificationService, reconciliationService service.ReconciliationService, transferProofService service.TransferProofService, receiptService service.ReceiptService, refundService service.RefundService, lateFeeService service.LateFeeService, discountService service.DiscountService, familyService service.FamilyService, academicYearService service.AcademicYearService, exemptionService service.ExemptionService) TreasurerHandler {
+	return &treasurerHandler{studentService, periodService, billService, paymentService, reportService, notificationService, reconciliationService, transferProofService, receiptService, refundService, lateFeeService, discountService, familyService, academicYearService, exemptionService}
 }
 
 func (h *treasurerHandler) CreateStudent(c *gin.Context) {
@@ -65,6 +124,7 @@ func (h *treasurerHandler) CreateStudent(c *gin.Context) {
 		NamaOrangTua:    req.NamaOrangTua,
 		TeleponOrangTua: req.TeleponOrangTua,
 		TahunMasuk:      req.TahunMasuk,
+		TanggalMasuk:    req.TanggalMasuk,
 	}
 
 	student, err := h.studentService.CreateStudent(input)
@@ -155,6 +215,7 @@ func (h *treasurerHandler) UpdateStudent(c *gin.Context) {
 		NamaOrangTua:    req.NamaOrangTua,
 		TeleponOrangTua: req.TeleponOrangTua,
 		TahunMasuk:      req.TahunMasuk,
+		TanggalMasuk:    req.TanggalMasuk,
 		Status:          req.Status,
 		EmailUser:       req.EmailUser,
 		StatusUser:      req.StatusUser,
@@ -205,19 +266,23 @@ func (h *treasurerHandler) CreatePeriod(c *gin.Context) {
 	}
 
 	input := dto.CreatePeriodInput{
-		TahunAjaran:    req.TahunAjaran,
-		Bulan:          req.Bulan,
-		NamaBulan:      req.NamaBulan,
-		TanggalMulai:   req.TanggalMulai,
-		TanggalSelesai: req.TanggalSelesai,
+		TahunAjaranID:     req.TahunAjaranID,
+		Bulan:             req.Bulan,
+		TanggalMulai:      req.TanggalMulai,
+		TanggalSelesai:    req.TanggalSelesai,
+		TanggalJatuhTempo: req.TanggalJatuhTempo,
 	}
 
 	period, err := h.periodService.CreatePeriod(input)
 	if err != nil {
-		if err.Error() == "periode untuk tahun ajaran dan bulan tersebut sudah ada" {
+		if errors.Is(err, service.ErrPeriodExists) {
 			utils.SendErrorResponse(c, http.StatusConflict, err.Error())
 			return
 		}
+		if errors.Is(err, service.ErrInvalidPeriod) {
+			utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal membuat periode")
 		return
 	}
@@ -226,8 +291,9 @@ func (h *treasurerHandler) CreatePeriod(c *gin.Context) {
 }
 
 func (h *treasurerHandler) FindAllPeriods(c *gin.Context) {
+	tahunAjaranID, _ := strconv.Atoi(c.Query("tahun_ajaran_id"))
 	tahunAjaran := c.Query("tahun_ajaran")
-	periods, err := h.periodService.FindAllPeriods(tahunAjaran)
+	periods, err := h.periodService.FindAllPeriods(uint(tahunAjaranID), tahunAjaran)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengambil data periode")
 		return
@@ -268,12 +334,12 @@ func (h *treasurerHandler) UpdatePeriod(c *gin.Context) {
 	}
 
 	input := dto.UpdatePeriodInput{
-		TahunAjaran:    req.TahunAjaran,
-		Bulan:          req.Bulan,
-		NamaBulan:      req.NamaBulan,
-		TanggalMulai:   req.TanggalMulai,
-		TanggalSelesai: req.TanggalSelesai,
-		Status:         req.Status,
+		TahunAjaranID:     req.TahunAjaranID,
+		Bulan:             req.Bulan,
+		TanggalMulai:      req.TanggalMulai,
+		TanggalSelesai:    req.TanggalSelesai,
+		TanggalJatuhTempo: req.TanggalJatuhTempo,
+		Status:            req.Status,
 	}
 
 	period, err := h.periodService.UpdatePeriod(uint(id), input)
@@ -282,6 +348,14 @@ func (h *treasurerHandler) UpdatePeriod(c *gin.Context) {
 			utils.SendErrorResponse(c, http.StatusNotFound, "Periode tidak ditemukan")
 			return
 		}
+		if errors.Is(err, service.ErrPeriodExists) {
+			utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrInvalidPeriod) {
+			utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal memperbarui periode")
 		return
 	}
@@ -301,12 +375,171 @@ func (h *treasurerHandler) DeletePeriod(c *gin.Context) {
 			utils.SendErrorResponse(c, http.StatusNotFound, "Periode tidak ditemukan")
 			return
 		}
+		if errors.Is(err, service.ErrPeriodInUse) {
+			utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal menghapus periode")
 		return
 	}
 	utils.SendSuccessResponse(c, http.StatusOK, "Periode berhasil dihapus", nil)
 }
 
+func (h *treasurerHandler) RunPeriodLifecycle(c *gin.Context) {
+	result, err := h.periodService.RunLifecycle()
+	if err != nil {
+		if errors.Is(err, service.ErrPeriodLifecycleRunning) {
+			utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal memperbarui status periode: "+err.Error())
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Pembaruan status periode berhasil dijalankan", result)
+}
+
+func (h *treasurerHandler) CreateAcademicYear(c *gin.Context) {
+	input, ok := academicYearInput(c)
+	if !ok {
+		return
+	}
+
+	academicYear, err := h.academicYearService.CreateAcademicYear(input)
+	if err != nil {
+		h.sendAcademicYearError(c, err, "Gagal membuat tahun ajaran")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusCreated, "Tahun ajaran berhasil dibuat", utils.FormatAcademicYearResponse(academicYear))
+}
+
+func (h *treasurerHandler) FindAllAcademicYears(c *gin.Context) {
+	academicYears, err := h.academicYearService.FindAllAcademicYears()
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengambil data tahun ajaran")
+		return
+	}
+
+	responses := []utils.AcademicYearResponse{}
+	for _, academicYear := range academicYears {
+		responses = append(responses, utils.FormatAcademicYearResponse(&academicYear))
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Data tahun ajaran berhasil diambil", responses)
+}
+
+func (h *treasurerHandler) FindActiveAcademicYear(c *gin.Context) {
+	academicYear, err := h.academicYearService.FindActiveAcademicYear()
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.SendErrorResponse(c, http.StatusNotFound, "Belum ada tahun ajaran yang aktif")
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengambil tahun ajaran aktif")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Tahun ajaran aktif berhasil diambil", utils.FormatAcademicYearResponse(academicYear))
+}
+
+func (h *treasurerHandler) FindAcademicYearByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID tahun ajaran tidak valid")
+		return
+	}
+
+	academicYear, err := h.academicYearService.FindAcademicYearByID(uint(id))
+	if err != nil {
+		h.sendAcademicYearError(c, err, "Gagal mengambil detail tahun ajaran")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Detail tahun ajaran berhasil diambil", utils.FormatAcademicYearResponse(academicYear))
+}
+
+func (h *treasurerHandler) UpdateAcademicYear(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID tahun ajaran tidak valid")
+		return
+	}
+
+	input, ok := academicYearInput(c)
+	if !ok {
+		return
+	}
+
+	academicYear, err := h.academicYearService.UpdateAcademicYear(uint(id), input)
+	if err != nil {
+		h.sendAcademicYearError(c, err, "Gagal memperbarui tahun ajaran")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Tahun ajaran berhasil diperbarui", utils.FormatAcademicYearResponse(academicYear))
+}
+
+func (h *treasurerHandler) DeleteAcademicYear(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID tahun ajaran tidak valid")
+		return
+	}
+
+	if err := h.academicYearService.DeleteAcademicYear(uint(id)); err != nil {
+		h.sendAcademicYearError(c, err, "Gagal menghapus tahun ajaran")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Tahun ajaran berhasil dihapus", nil)
+}
+
+func (h *treasurerHandler) GeneratePeriods(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID tahun ajaran tidak valid")
+		return
+	}
+
+	var req utils.GeneratePeriodsRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	input := dto.GeneratePeriodsInput{
+		Bulan:             req.Bulan,
+		TanggalJatuhTempo: req.TanggalJatuhTempo,
+	}
+	result, err := h.academicYearService.GeneratePeriods(uint(id), input)
+	if err != nil {
+		h.sendAcademicYearError(c, err, "Gagal membuat periode tahun ajaran")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Periode tahun ajaran berhasil dibuat", result)
+}
+
+func academicYearInput(c *gin.Context) (dto.AcademicYearInput, bool) {
+	var req utils.AcademicYearRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return dto.AcademicYearInput{}, false
+	}
+	return dto.AcademicYearInput{
+		NamaTahunAjaran: req.NamaTahunAjaran,
+		TanggalMulai:    req.TanggalMulai,
+		TanggalSelesai:  req.TanggalSelesai,
+		Aktif:           req.Aktif,
+	}, true
+}
+
+func (h *treasurerHandler) sendAcademicYearError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.SendErrorResponse(c, http.StatusNotFound, "Tahun ajaran tidak ditemukan")
+	case errors.Is(err, service.ErrAcademicYearExists), errors.Is(err, service.ErrAcademicYearInUse):
+		utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrInvalidAcademicYear):
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		utils.SendErrorResponse(c, http.StatusInternalServerError, message)
+	}
+}
+
 func (h *treasurerHandler) GenerateBills(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
@@ -314,12 +547,41 @@ func (h *treasurerHandler) GenerateBills(c *gin.Context) {
 		return
 	}
 
-	err = h.billService.GenerateBillsForPeriod(uint(id))
+	var req utils.GenerateBillsRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	input := dto.GenerateBillsInput{
+		JenisBiayaID: req.JenisBiayaID,
+		KelasIDs:     req.KelasIDs,
+		TingkatIDs:   req.TingkatIDs,
+		SiswaIDs:     req.SiswaIDs,
+		DryRun:       req.DryRun,
+	}
+	result, err := h.billService.GenerateBillsForPeriod(uint(id), input)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.SendErrorResponse(c, http.StatusNotFound, "Periode tidak ditemukan")
+			return
+		}
+		if errors.Is(err, service.ErrInvalidFeeType) {
+			utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrPeriodClosed) {
+			utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal men-generate tagihan: "+err.Error())
 		return
 	}
-	utils.SendSuccessResponse(c, http.StatusOK, "Tagihan untuk periode terpilih berhasil di-generate", nil)
+	if result.DryRun {
+		utils.SendSuccessResponse(c, http.StatusOK, "Simulasi generate tagihan berhasil, tidak ada tagihan yang disimpan", result)
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Tagihan untuk periode terpilih berhasil di-generate", result)
 }
 
 func (h *treasurerHandler) FindAllBills(c *gin.Context) {
@@ -328,6 +590,7 @@ func (h *treasurerHandler) FindAllBills(c *gin.Context) {
 	periodeID, _ := strconv.Atoi(c.Query("periode_id"))
 	siswaID, _ := strconv.Atoi(c.Query("siswa_id"))
 	status := c.Query("status_pembayaran")
+	jenisBiayaID, _ := strconv.Atoi(c.Query("jenis_biaya_id"))
 
 	input := dto.FindAllBillsInput{
 		Page:             page,
@@ -335,6 +598,7 @@ func (h *treasurerHandler) FindAllBills(c *gin.Context) {
 		PeriodeID:        uint(periodeID),
 		SiswaID:          uint(siswaID),
 		StatusPembayaran: status,
+		JenisBiayaID:     uint(jenisBiayaID),
 	}
 
 	bills, total, err := h.billService.FindAllBills(input)
@@ -392,8 +656,7 @@ func (h *treasurerHandler) UpdateBill(c *gin.Context) {
 	}
 
 	input := dto.UpdateBillInput{
-		JumlahTagihan:    req.JumlahTagihan,
-		StatusPembayaran: req.StatusPembayaran,
+		JumlahTagihan: req.JumlahTagihan,
 	}
 
 	bill, err := h.billService.UpdateBill(uint(id), input)
@@ -402,6 +665,14 @@ func (h *treasurerHandler) UpdateBill(c *gin.Context) {
 			utils.SendErrorResponse(c, http.StatusNotFound, "Tagihan tidak ditemukan")
 			return
 		}
+		if errors.Is(err, service.ErrInvalidBillAmount) || errors.Is(err, service.ErrInvalidInstallmentPlan) || errors.Is(err, service.ErrBillWaived) {
+			utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrPendingPaymentExists) {
+			utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal memperbarui tagihan")
 		return
 	}
@@ -421,16 +692,228 @@ func (h *treasurerHandler) DeleteBill(c *gin.Context) {
 			utils.SendErrorResponse(c, http.StatusNotFound, "Tagihan tidak ditemukan")
 			return
 		}
+		if errors.Is(err, service.ErrBillInUse) {
+			utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal menghapus tagihan")
 		return
 	}
 	utils.SendSuccessResponse(c, http.StatusOK, "Tagihan berhasil dihapus", nil)
 }
 
+func (h *treasurerHandler) WaiveBills(c *gin.Context) {
+	var req utils.WaiveBillsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	userID := c.MustGet("userID").(uint)
+	input := dto.WaiveBillsInput{
+		TagihanIDs:     req.TagihanIDs,
+		PeriodeID:      req.PeriodeID,
+		JenisBiayaID:   req.JenisBiayaID,
+		KelasIDs:       req.KelasIDs,
+		TingkatIDs:     req.TingkatIDs,
+		SiswaIDs:       req.SiswaIDs,
+		Alasan:         req.Alasan,
+		DibebaskanOleh: userID,
+	}
+	result, err := h.billService.WaiveBills(input)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidWaiver) {
+			utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal membebaskan tagihan")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Pembebasan tagihan berhasil diproses", result)
+}
+
+func (h *treasurerHandler) CreateExemption(c *gin.Context) {
+	var req utils.ExemptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	userID := c.MustGet("userID").(uint)
+	input := dto.ExemptionInput{
+		PeriodeID:    req.PeriodeID,
+		JenisBiayaID: req.JenisBiayaID,
+		TingkatID:    req.TingkatID,
+		KelasID:      req.KelasID,
+		SiswaID:      req.SiswaID,
+		Alasan:       req.Alasan,
+		DibuatOleh:   userID,
+	}
+	exemption, err := h.exemptionService.CreateExemption(input)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidExemption) {
+			utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal membuat aturan pembebasan")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusCreated, "Aturan pembebasan berhasil dibuat", utils.FormatExemptionResponse(exemption))
+}
+
+func (h *treasurerHandler) FindAllExemptions(c *gin.Context) {
+	periodeID, _ := strconv.Atoi(c.Query("periode_id"))
+	exemptions, err := h.exemptionService.FindAllExemptions(uint(periodeID))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengambil data aturan pembebasan")
+		return
+	}
+
+	responses := []utils.ExemptionResponse{}
+	for _, exemption := range exemptions {
+		responses = append(responses, utils.FormatExemptionResponse(&exemption))
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Data aturan pembebasan berhasil diambil", responses)
+}
+
+func (h *treasurerHandler) FindExemptionByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID aturan pembebasan tidak valid")
+		return
+	}
+
+	exemption, err := h.exemptionService.FindExemptionByID(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.SendErrorResponse(c, http.StatusNotFound, "Aturan pembebasan tidak ditemukan")
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengambil detail aturan pembebasan")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Detail aturan pembebasan berhasil diambil", utils.FormatExemptionResponse(exemption))
+}
+
+func (h *treasurerHandler) DeleteExemption(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID aturan pembebasan tidak valid")
+		return
+	}
+
+	if err := h.exemptionService.DeleteExemption(uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.SendErrorResponse(c, http.StatusNotFound, "Aturan pembebasan tidak ditemukan")
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal menghapus aturan pembebasan")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Aturan pembebasan berhasil dihapus", nil)
+}
+
+func (h *treasurerHandler) SetInstallments(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID tagihan tidak valid")
+		return
+	}
+
+	var req utils.InstallmentPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	var input []dto.InstallmentInput
+	for _, item := range req.Cicilan {
+		input = append(input, dto.InstallmentInput{
+			Jumlah:            item.Jumlah,
+			TanggalJatuhTempo: item.TanggalJatuhTempo,
+		})
+	}
+
+	bill, err := h.billService.SetInstallments(uint(id), input)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.SendErrorResponse(c, http.StatusNotFound, "Tagihan tidak ditemukan")
+			return
+		}
+		if errors.Is(err, service.ErrInvalidInstallmentPlan) || errors.Is(err, service.ErrBillAlreadyPaid) || errors.Is(err, service.ErrBillWaived) {
+			utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal menyimpan rencana cicilan")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Rencana cicilan berhasil disimpan", utils.FormatBillResponse(bill))
+}
+
+func (h *treasurerHandler) DeleteInstallments(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID tagihan tidak valid")
+		return
+	}
+
+	bill, err := h.billService.DeleteInstallments(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.SendErrorResponse(c, http.StatusNotFound, "Tagihan tidak ditemukan")
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal menghapus rencana cicilan")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Rencana cicilan berhasil dihapus", utils.FormatBillResponse(bill))
+}
+
+func (h *treasurerHandler) RecordManualPayment(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID tagihan tidak valid")
+		return
+	}
+
+	var req utils.ManualPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	input := dto.ManualPaymentInput{
+		TagihanID:        uint(id),
+		Jumlah:           req.Jumlah,
+		MetodePembayaran: req.MetodePembayaran,
+		TanggalDiterima:  req.TanggalDiterima,
+		NomorReferensi:   req.NomorReferensi,
+		Keterangan:       req.Keterangan,
+		DiterimaOleh:     userID,
+	}
+
+	payment, err := h.paymentService.RecordManualPayment(input)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "Tagihan tidak ditemukan")
+		case errors.Is(err, service.ErrPendingPaymentExists):
+			utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+		case errors.Is(err, service.ErrInvalidManualPayment), errors.Is(err, service.ErrBillAlreadyPaid), errors.Is(err, service.ErrBillWaived):
+			utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mencatat pembayaran manual")
+		}
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusCreated, "Pembayaran manual berhasil dicatat", utils.FormatPaymentResponse(payment))
+}
+
 func (h *treasurerHandler) GetLaporanSiswa(c *gin.Context) {
 	tahunAjaran := c.Query("tahun_ajaran")
 	nisn := c.Query("nisn")
-	result, err := h.reportService.GetLaporanSiswa(tahunAjaran, nisn)
+	result, err := h.reportService.GetLaporanSiswa(tahunAjaran, nisn, c.Query("kode_biaya"))
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengambil laporan per siswa")
 		return
@@ -441,7 +924,7 @@ func (h *treasurerHandler) GetLaporanSiswa(c *gin.Context) {
 func (h *treasurerHandler) GetLaporanKelas(c *gin.Context) {
 	tahunAjaran := c.Query("tahun_ajaran")
 	namaBulan := c.Query("nama_bulan")
-	result, err := h.reportService.GetLaporanKelas(tahunAjaran, namaBulan)
+	result, err := h.reportService.GetLaporanKelas(tahunAjaran, namaBulan, c.Query("kode_biaya"))
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengambil laporan per kelas")
 		return
@@ -451,10 +934,698 @@ func (h *treasurerHandler) GetLaporanKelas(c *gin.Context) {
 
 func (h *treasurerHandler) GetLaporanKeseluruhan(c *gin.Context) {
 	tahunAjaran := c.Query("tahun_ajaran")
-	result, err := h.reportService.GetLaporanKeseluruhan(tahunAjaran)
+	result, err := h.reportService.GetLaporanKeseluruhan(tahunAjaran, c.Query("kode_biaya"))
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengambil laporan keseluruhan")
 		return
 	}
 	utils.SendSuccessResponse(c, http.StatusOK, "Laporan keseluruhan berhasil diambil", result)
 }
+
+func (h *treasurerHandler) GetLaporanPotongan(c *gin.Context) {
+	tahunAjaran := c.Query("tahun_ajaran")
+	namaBulan := c.Query("nama_bulan")
+	result, err := h.reportService.GetLaporanPotongan(tahunAjaran, namaBulan, c.Query("kode_biaya"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengambil laporan potongan")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Laporan potongan berhasil diambil", result)
+}
+
+func (h *treasurerHandler) FindAllNotifications(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	input := dto.FindAllNotificationsInput{
+		Page:         page,
+		Limit:        limit,
+		OrderID:      c.Query("order_id"),
+		StatusProses: c.Query("status_proses"),
+	}
+
+	notifications, total, err := h.notificationService.FindAllNotifications(input)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengambil data notifikasi")
+		return
+	}
+
+	var responses []utils.NotificationResponse
+	for _, notification := range notifications {
+		responses = append(responses, utils.FormatNotificationResponse(&notification))
+	}
+
+	response := gin.H{
+		"data": responses,
+		"meta": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Data notifikasi berhasil diambil", response)
+}
+
+func (h *treasurerHandler) FindNotificationByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID notifikasi tidak valid")
+		return
+	}
+
+	notification, err := h.notificationService.FindNotificationByID(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.SendErrorResponse(c, http.StatusNotFound, "Notifikasi tidak ditemukan")
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengambil detail notifikasi")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Detail notifikasi berhasil diambil", utils.FormatNotificationResponse(notification))
+}
+
+func (h *treasurerHandler) ReprocessNotification(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID notifikasi tidak valid")
+		return
+	}
+
+	notification, err := h.notificationService.ReprocessNotification(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) && notification == nil {
+			utils.SendErrorResponse(c, http.StatusNotFound, "Notifikasi tidak ditemukan")
+			return
+		}
+		if errors.Is(err, service.ErrNotificationNotRetryable) {
+			utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusUnprocessableEntity, "Gagal memproses ulang notifikasi: "+err.Error())
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Notifikasi berhasil diproses ulang", utils.FormatNotificationResponse(notification))
+}
+
+func (h *treasurerHandler) FindAllReconciliations(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	runs, total, err := h.reconciliationService.FindAllRuns(page, limit)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengambil data rekonsiliasi")
+		return
+	}
+
+	var responses []utils.ReconciliationResponse
+	for _, run := range runs {
+		responses = append(responses, utils.FormatReconciliationResponse(&run))
+	}
+
+	response := gin.H{
+		"data": responses,
+		"meta": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Data rekonsiliasi berhasil diambil", response)
+}
+
+func (h *treasurerHandler) FindReconciliationByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID rekonsiliasi tidak valid")
+		return
+	}
+
+	run, err := h.reconciliationService.FindRunByID(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.SendErrorResponse(c, http.StatusNotFound, "Rekonsiliasi tidak ditemukan")
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengambil detail rekonsiliasi")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Detail rekonsiliasi berhasil diambil", utils.FormatReconciliationResponse(run))
+}
+
+func (h *treasurerHandler) RunReconciliation(c *gin.Context) {
+	run, err := h.reconciliationService.Run(service.ReconciliationTriggerManual)
+	if err != nil {
+		if errors.Is(err, service.ErrReconciliationRunning) {
+			utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal menjalankan rekonsiliasi: "+err.Error())
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Rekonsiliasi pembayaran berhasil dijalankan", utils.FormatReconciliationResponse(run))
+}
+
+func (h *treasurerHandler) FindAllTransferProofs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	siswaID, _ := strconv.ParseUint(c.Query("siswa_id"), 10, 64)
+
+	input := dto.FindAllTransferProofsInput{
+		Page:             page,
+		Limit:            limit,
+		SiswaID:          uint(siswaID),
+		StatusVerifikasi: c.DefaultQuery("status_verifikasi", service.TransferProofStatusMenunggu),
+	}
+
+	proofs, total, err := h.transferProofService.FindAll(input)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengambil data bukti transfer")
+		return
+	}
+
+	var responses []utils.TransferProofResponse
+	for _, proof := range proofs {
+		responses = append(responses, utils.FormatTransferProofResponse(&proof))
+	}
+
+	response := gin.H{
+		"data": responses,
+		"meta": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Data bukti transfer berhasil diambil", response)
+}
+
+func (h *treasurerHandler) FindTransferProofByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID bukti transfer tidak valid")
+		return
+	}
+
+	proof, err := h.transferProofService.FindByID(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.SendErrorResponse(c, http.StatusNotFound, "Bukti transfer tidak ditemukan")
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengambil bukti transfer")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Detail bukti transfer berhasil diambil", utils.FormatTransferProofResponse(proof))
+}
+
+func (h *treasurerHandler) GetTransferProofFile(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID bukti transfer tidak valid")
+		return
+	}
+
+	proof, file, err := h.transferProofService.OpenFile(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, os.ErrNotExist) {
+			utils.SendErrorResponse(c, http.StatusNotFound, "File bukti transfer tidak ditemukan")
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal membuka file bukti transfer")
+		return
+	}
+	defer file.Close()
+
+	headers := map[string]string{
+		"Content-Disposition": fmt.Sprintf("inline; filename=%q", proof.NamaFile),
+	}
+	c.DataFromReader(http.StatusOK, proof.UkuranFile, proof.TipeKonten, file, headers)
+}
+
+func (h *treasurerHandler) ApproveTransferProof(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID bukti transfer tidak valid")
+		return
+	}
+
+	proof, err := h.transferProofService.Approve(uint(id), userID)
+	if err != nil {
+		h.sendTransferProofError(c, err)
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Bukti transfer disetujui dan tagihan diperbarui", utils.FormatTransferProofResponse(proof))
+}
+
+func (h *treasurerHandler) RejectTransferProof(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID bukti transfer tidak valid")
+		return
+	}
+
+	var req utils.RejectTransferProofRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	proof, err := h.transferProofService.Reject(uint(id), userID, req.Alasan)
+	if err != nil {
+		h.sendTransferProofError(c, err)
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Bukti transfer ditolak", utils.FormatTransferProofResponse(proof))
+}
+
+func (h *treasurerHandler) sendTransferProofError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.SendErrorResponse(c, http.StatusNotFound, "Bukti transfer tidak ditemukan")
+	case errors.Is(err, service.ErrTransferProofNotWaiting):
+		utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrTransferProofRejectEmpty):
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal memverifikasi bukti transfer")
+	}
+}
+
+func (h *treasurerHandler) GetReceipt(c *gin.Context) {
+	content, filename, err := h.receiptService.GetReceipt(c.Param("order_id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "Pembayaran tidak ditemukan")
+		case errors.Is(err, service.ErrReceiptNotAvailable):
+			utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal membuat kuitansi")
+		}
+		return
+	}
+	utils.SendFileResponse(c, "application/pdf", filename, content)
+}
+
+func (h *treasurerHandler) RequestRefund(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	var req utils.RefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	input := dto.RefundInput{
+		OrderID:      c.Param("order_id"),
+		Jumlah:       req.Jumlah,
+		Alasan:       req.Alasan,
+		DiajukanOleh: userID,
+	}
+	refund, err := h.refundService.RequestRefund(input)
+	if err != nil {
+		sendRefundError(c, err)
+		return
+	}
+
+	if refund.StatusRefund == service.RefundStatusMenungguPersetujuan {
+		utils.SendSuccessResponse(c, http.StatusAccepted, "Refund melebihi batas dan menunggu persetujuan admin", utils.FormatRefundResponse(refund))
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusCreated, "Refund berhasil diproses", utils.FormatRefundResponse(refund))
+}
+
+func (h *treasurerHandler) FindAllRefunds(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	input := dto.FindAllRefundsInput{
+		Page:         page,
+		Limit:        limit,
+		OrderID:      c.Query("order_id"),
+		StatusRefund: c.Query("status_refund"),
+	}
+
+	refunds, total, err := h.refundService.FindAll(input)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengambil data refund")
+		return
+	}
+
+	var responses []utils.RefundResponse
+	for _, refund := range refunds {
+		responses = append(responses, utils.FormatRefundResponse(&refund))
+	}
+
+	response := gin.H{
+		"data": responses,
+		"meta": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Data refund berhasil diambil", response)
+}
+
+func (h *treasurerHandler) FindRefundByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID refund tidak valid")
+		return
+	}
+
+	refund, err := h.refundService.FindByID(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.SendErrorResponse(c, http.StatusNotFound, "Refund tidak ditemukan")
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengambil detail refund")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Detail refund berhasil diambil", utils.FormatRefundResponse(refund))
+}
+
+func sendRefundError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.SendErrorResponse(c, http.StatusNotFound, "Pembayaran atau refund tidak ditemukan")
+	case errors.Is(err, service.ErrRefundReasonRequired), errors.Is(err, service.ErrRefundRejectEmpty), errors.Is(err, service.ErrInvalidRefundAmount):
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrPaymentNotRefundable), errors.Is(err, service.ErrRefundNotWaiting), errors.Is(err, service.ErrRefundSelfApproval):
+		utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrRefundFailed):
+		utils.SendErrorResponse(c, http.StatusBadGateway, err.Error())
+	default:
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal memproses refund")
+	}
+}
+
+func (h *treasurerHandler) FindLateFees(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID tagihan tidak valid")
+		return
+	}
+
+	fees, err := h.lateFeeService.FindByBillID(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.SendErrorResponse(c, http.StatusNotFound, "Tagihan tidak ditemukan")
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengambil data denda")
+		return
+	}
+
+	responses := []utils.LateFeeResponse{}
+	for _, fee := range fees {
+		responses = append(responses, utils.FormatLateFeeResponse(&fee))
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Data denda berhasil diambil", responses)
+}
+
+func (h *treasurerHandler) WaiveLateFee(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID tagihan tidak valid")
+		return
+	}
+	feeID, err := strconv.ParseUint(c.Param("fee_id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID denda tidak valid")
+		return
+	}
+
+	var req utils.WaiveLateFeeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	bill, err := h.lateFeeService.Waive(uint(id), uint(feeID), userID, req.Alasan)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "Tagihan atau denda tidak ditemukan")
+		case errors.Is(err, service.ErrLateFeeReasonRequired):
+			utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrLateFeeNotActive), errors.Is(err, service.ErrLateFeeAlreadyPaid), errors.Is(err, service.ErrPendingPaymentExists):
+			utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal menghapuskan denda")
+		}
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Denda berhasil dihapuskan", utils.FormatBillResponse(bill))
+}
+
+func (h *treasurerHandler) RunLateFees(c *gin.Context) {
+	result, err := h.lateFeeService.Run()
+	if err != nil {
+		if errors.Is(err, service.ErrLateFeeRunning) {
+			utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal menghitung denda: "+err.Error())
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Perhitungan denda berhasil dijalankan", result)
+}
+
+func (h *treasurerHandler) FindStudentDiscounts(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID siswa tidak valid")
+		return
+	}
+
+	assignments, err := h.discountService.FindStudentDiscounts(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.SendErrorResponse(c, http.StatusNotFound, "Siswa tidak ditemukan")
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengambil data potongan siswa")
+		return
+	}
+
+	responses := []utils.StudentDiscountResponse{}
+	for _, assignment := range assignments {
+		responses = append(responses, utils.FormatStudentDiscountResponse(&assignment))
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Data potongan siswa berhasil diambil", responses)
+}
+
+func (h *treasurerHandler) AssignStudentDiscount(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID siswa tidak valid")
+		return
+	}
+
+	var req utils.StudentDiscountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	assignment, err := h.discountService.AssignDiscount(uint(id), studentDiscountInput(req))
+	if err != nil {
+		h.sendStudentDiscountError(c, err)
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusCreated, "Potongan siswa berhasil ditambahkan", utils.FormatStudentDiscountResponse(assignment))
+}
+
+func (h *treasurerHandler) UpdateStudentDiscount(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID siswa tidak valid")
+		return
+	}
+	discountID, err := strconv.ParseUint(c.Param("discount_id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID potongan tidak valid")
+		return
+	}
+
+	var req utils.StudentDiscountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	assignment, err := h.discountService.UpdateStudentDiscount(uint(id), uint(discountID), studentDiscountInput(req))
+	if err != nil {
+		h.sendStudentDiscountError(c, err)
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Potongan siswa berhasil diperbarui", utils.FormatStudentDiscountResponse(assignment))
+}
+
+func (h *treasurerHandler) DeleteStudentDiscount(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID siswa tidak valid")
+		return
+	}
+	discountID, err := strconv.ParseUint(c.Param("discount_id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID potongan tidak valid")
+		return
+	}
+
+	if err := h.discountService.DeleteStudentDiscount(uint(id), uint(discountID)); err != nil {
+		h.sendStudentDiscountError(c, err)
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Potongan siswa berhasil dihapus", nil)
+}
+
+func (h *treasurerHandler) sendStudentDiscountError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.SendErrorResponse(c, http.StatusNotFound, "Siswa atau potongan tidak ditemukan")
+	case errors.Is(err, service.ErrInvalidDiscount):
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal memproses potongan siswa")
+	}
+}
+
+func studentDiscountInput(req utils.StudentDiscountRequest) dto.StudentDiscountInput {
+	return dto.StudentDiscountInput{
+		JenisPotonganID:  req.JenisPotonganID,
+		PeriodeMulaiID:   req.PeriodeMulaiID,
+		PeriodeSelesaiID: req.PeriodeSelesaiID,
+		Keterangan:       req.Keterangan,
+	}
+}
+
+func (h *treasurerHandler) FindFamilySuggestions(c *gin.Context) {
+	suggestions, err := h.familyService.FindSuggestions()
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mencari saran keluarga")
+		return
+	}
+
+	responses := []utils.FamilySuggestionResponse{}
+	for _, suggestion := range suggestions {
+		response := utils.FamilySuggestionResponse{
+			NamaOrangTua:    suggestion.NamaOrangtua,
+			TeleponOrangTua: suggestion.TeleponOrangtua,
+		}
+		for _, student := range suggestion.Siswa {
+			response.Siswa = append(response.Siswa, utils.FormatFamilyMemberResponse(&student))
+		}
+		responses = append(responses, response)
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Saran keluarga berhasil diambil", responses)
+}
+
+func (h *treasurerHandler) CreateFamily(c *gin.Context) {
+	var req utils.FamilyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	family, err := h.familyService.CreateFamily(familyInput(req))
+	if err != nil {
+		h.sendFamilyError(c, err, "Gagal membuat data keluarga")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusCreated, "Data keluarga berhasil dibuat", utils.FormatFamilyResponse(family))
+}
+
+func (h *treasurerHandler) FindAllFamilies(c *gin.Context) {
+	families, err := h.familyService.FindAllFamilies(c.Query("search"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengambil data keluarga")
+		return
+	}
+
+	responses := []utils.FamilyResponse{}
+	for _, family := range families {
+		responses = append(responses, utils.FormatFamilyResponse(&family))
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Data keluarga berhasil diambil", responses)
+}
+
+func (h *treasurerHandler) FindFamilyByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID keluarga tidak valid")
+		return
+	}
+
+	family, err := h.familyService.FindFamilyByID(uint(id))
+	if err != nil {
+		h.sendFamilyError(c, err, "Gagal mengambil detail keluarga")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Detail keluarga berhasil diambil", utils.FormatFamilyResponse(family))
+}
+
+func (h *treasurerHandler) UpdateFamily(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID keluarga tidak valid")
+		return
+	}
+
+	var req utils.FamilyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	family, err := h.familyService.UpdateFamily(uint(id), familyInput(req))
+	if err != nil {
+		h.sendFamilyError(c, err, "Gagal memperbarui data keluarga")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Data keluarga berhasil diperbarui", utils.FormatFamilyResponse(family))
+}
+
+func (h *treasurerHandler) DeleteFamily(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "ID keluarga tidak valid")
+		return
+	}
+
+	if err := h.familyService.DeleteFamily(uint(id)); err != nil {
+		h.sendFamilyError(c, err, "Gagal menghapus data keluarga")
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Data keluarga berhasil dihapus", nil)
+}
+
+func (h *treasurerHandler) sendFamilyError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.SendErrorResponse(c, http.StatusNotFound, "Keluarga tidak ditemukan")
+	case errors.Is(err, service.ErrInvalidFamily):
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrStudentInOtherFamily):
+		utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+	default:
+		utils.SendErrorResponse(c, http.StatusInternalServerError, message)
+	}
+}
+
+func familyInput(req utils.FamilyRequest) dto.FamilyInput {
+	return dto.FamilyInput{
+		NamaKeluarga:    req.NamaKeluarga,
+		NamaOrangtua:    req.NamaOrangTua,
+		TeleponOrangtua: req.TeleponOrangTua,
+		Keterangan:      req.Keterangan,
+		SiswaIDs:        req.SiswaIDs,
+	}
+}
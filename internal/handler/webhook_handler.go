@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/hiuncy/spp-payment-api/internal/service"
+	"github.com/hiuncy/spp-payment-api/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type WebhookHandler interface {
+	HandleNotification(c *gin.Context)
+}
+
+type webhookHandler struct {
+	notificationService service.NotificationService
+	logService          service.LogService
+}
+
+func NewWebhookHandler(notificationService service.NotificationService, logService service.LogService) WebhookHandler {
+	return &webhookHandler{notificationService, logService}
+}
+
+func (h *webhookHandler) HandleNotification(c *gin.Context) {
+	gateway := c.Param("gateway")
+	if gateway == "" {
+		gateway = service.GatewayMidtrans
+	}
+
+	rawBody, err := c.GetRawData()
+	if err != nil || len(rawBody) == 0 {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Payload notifikasi tidak valid")
+		return
+	}
+
+	err = h.notificationService.HandleNotification(gateway, rawBody, c.Request.Header)
+	if err != nil {
+		if errors.Is(err, service.ErrGatewayNotFound) {
+			utils.SendErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrInvalidNotificationPayload) {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Payload notifikasi tidak valid")
+			return
+		}
+		if errors.Is(err, service.ErrInvalidSignature) || errors.Is(err, service.ErrAmountMismatch) || errors.Is(err, service.ErrGatewayMismatch) {
+			h.recordRejectedNotification(c, gateway, rawBody, err)
+			utils.SendErrorResponse(c, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.SendErrorResponse(c, http.StatusNotFound, "Pembayaran untuk order tersebut tidak ditemukan")
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (h *webhookHandler) recordRejectedNotification(c *gin.Context, gateway string, rawBody []byte, reason error) {
+	detail := reason.Error() + ": " + string(rawBody)
+	_ = h.logService.RecordActivity(nil, "Notifikasi "+gateway+" ditolak", detail, c.ClientIP(), c.Request.UserAgent())
+}
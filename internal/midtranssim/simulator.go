@@ -0,0 +1,525 @@
+package midtranssim
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	StatusPending       = "pending"
+	StatusSettlement    = "settlement"
+	StatusDeny          = "deny"
+	StatusCancel        = "cancel"
+	StatusExpire        = "expire"
+	StatusRefund        = "refund"
+	StatusPartialRefund = "partial_refund"
+
+	timeLayout = "2006-01-02 15:04:05"
+)
+
+var (
+	ErrOrderNotFound     = errors.New("order tidak ditemukan")
+	ErrInvalidTransition = errors.New("status order tidak dapat diubah")
+)
+
+type Options struct {
+	ServerKey       string
+	NotificationURL string
+	HTTPClient      *http.Client
+}
+
+type Order struct {
+	OrderID           string  `json:"order_id"`
+	TransactionID     string  `json:"transaction_id"`
+	Token             string  `json:"token"`
+	GrossAmount       int64   `json:"gross_amount"`
+	TransactionStatus string  `json:"transaction_status"`
+	PaymentType       string  `json:"payment_type,omitempty"`
+	Bank              string  `json:"bank,omitempty"`
+	VANumber          string  `json:"va_number,omitempty"`
+	ExpiryTime        string  `json:"expiry_time,omitempty"`
+	RefundAmount      int64   `json:"refund_amount"`
+	TransactionTime   string  `json:"transaction_time"`
+	SettlementTime    string  `json:"settlement_time,omitempty"`
+	Notifications     []Event `json:"notifications"`
+}
+
+type Event struct {
+	TransactionStatus string `json:"transaction_status"`
+	StatusCode        int    `json:"status_code"`
+	Error             string `json:"error,omitempty"`
+	SentAt            string `json:"sent_at"`
+}
+
+type Simulator struct {
+	options    Options
+	mux        *http.ServeMux
+	mu         sync.Mutex
+	orders     map[string]*Order
+	refundKeys map[string]map[string]any
+	baseURL    string
+}
+
+type Server struct {
+	*httptest.Server
+	*Simulator
+}
+
+func New(options Options) *Simulator {
+	if options.HTTPClient == nil {
+		options.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	s := &Simulator{
+		options:    options,
+		mux:        http.NewServeMux(),
+		orders:     make(map[string]*Order),
+		refundKeys: make(map[string]map[string]any),
+	}
+
+	s.mux.HandleFunc("POST /snap/v1/transactions", s.authorized(s.handleCreateTransaction))
+	s.mux.HandleFunc("POST /v2/charge", s.authorized(s.handleCharge))
+	s.mux.HandleFunc("GET /v2/{order_id}/status", s.authorized(s.handleStatus))
+	s.mux.HandleFunc("POST /v2/{order_id}/cancel", s.authorized(s.handleAPITransition(StatusCancel)))
+	s.mux.HandleFunc("POST /v2/{order_id}/expire", s.authorized(s.handleAPITransition(StatusExpire)))
+	s.mux.HandleFunc("POST /v2/{order_id}/refund", s.authorized(s.handleRefund))
+
+	s.mux.HandleFunc("GET /simulator/orders", s.handleListOrders)
+	s.mux.HandleFunc("GET /simulator/orders/{order_id}", s.handleGetOrder)
+	s.mux.HandleFunc("POST /simulator/orders/{order_id}/pay", s.handleControl(StatusSettlement))
+	s.mux.HandleFunc("POST /simulator/orders/{order_id}/expire", s.handleControl(StatusExpire))
+	s.mux.HandleFunc("POST /simulator/orders/{order_id}/deny", s.handleControl(StatusDeny))
+	return s
+}
+
+func NewServer(options Options) *Server {
+	simulator := New(options)
+	server := httptest.NewServer(simulator)
+	simulator.SetBaseURL(server.URL)
+	return &Server{Server: server, Simulator: simulator}
+}
+
+func (s *Simulator) SetBaseURL(baseURL string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.baseURL = baseURL
+}
+
+func (s *Simulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Simulator) Orders() []Order {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	orders := make([]Order, 0, len(s.orders))
+	for _, order := range s.orders {
+		orders = append(orders, *order)
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].TransactionTime < orders[j].TransactionTime })
+	return orders
+}
+
+func (s *Simulator) Order(orderID string) (Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order, ok := s.orders[orderID]
+	if !ok {
+		return Order{}, ErrOrderNotFound
+	}
+	return *order, nil
+}
+
+func (s *Simulator) Pay(orderID, paymentType string) (Order, error) {
+	return s.complete(orderID, StatusSettlement, paymentType)
+}
+
+func (s *Simulator) Expire(orderID string) (Order, error) {
+	return s.complete(orderID, StatusExpire, "")
+}
+
+func (s *Simulator) Deny(orderID, paymentType string) (Order, error) {
+	return s.complete(orderID, StatusDeny, paymentType)
+}
+
+func (s *Simulator) complete(orderID, status, paymentType string) (Order, error) {
+	payload, err := s.transition(orderID, status, paymentType)
+	if err != nil {
+		return Order{}, err
+	}
+	s.notify(orderID, payload)
+	return s.Order(orderID)
+}
+
+func (s *Simulator) transition(orderID, status, paymentType string) (map[string]any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order, ok := s.orders[orderID]
+	if !ok {
+		return nil, ErrOrderNotFound
+	}
+	if order.TransactionStatus != StatusPending {
+		return nil, fmt.Errorf("%w: %s sudah %s", ErrInvalidTransition, orderID, order.TransactionStatus)
+	}
+
+	order.TransactionStatus = status
+	if paymentType != "" {
+		order.PaymentType = paymentType
+	}
+	if order.PaymentType == "" {
+		order.PaymentType = "bank_transfer"
+	}
+	if status == StatusSettlement {
+		order.SettlementTime = time.Now().Format(timeLayout)
+	}
+	return s.payload(order), nil
+}
+
+type transactionDetails struct {
+	OrderID     string `json:"order_id"`
+	GrossAmount int64  `json:"gross_amount"`
+}
+
+func (s *Simulator) createOrder(details transactionDetails) (*Order, error) {
+	if details.OrderID == "" || details.GrossAmount <= 0 {
+		return nil, errors.New("transaction_details.order_id dan gross_amount wajib diisi")
+	}
+	if _, exists := s.orders[details.OrderID]; exists {
+		return nil, errors.New("transaction_details.order_id sudah digunakan")
+	}
+	order := &Order{
+		OrderID:           details.OrderID,
+		TransactionID:     randomUUID(),
+		GrossAmount:       details.GrossAmount,
+		TransactionStatus: StatusPending,
+		TransactionTime:   time.Now().Format(timeLayout),
+		Notifications:     []Event{},
+	}
+	s.orders[details.OrderID] = order
+	return order, nil
+}
+
+func (s *Simulator) handleCreateTransaction(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TransactionDetails transactionDetails `json:"transaction_details"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error_messages": []string{"request body tidak valid"}})
+		return
+	}
+
+	s.mu.Lock()
+	order, err := s.createOrder(req.TransactionDetails)
+	if err != nil {
+		s.mu.Unlock()
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error_messages": []string{err.Error()}})
+		return
+	}
+	token := randomHex(16)
+	order.Token = token
+	baseURL := s.baseURL
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"token":        token,
+		"redirect_url": baseURL + "/snap/v2/vtweb/" + token,
+	})
+}
+
+func (s *Simulator) handleCharge(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PaymentType        string             `json:"payment_type"`
+		TransactionDetails transactionDetails `json:"transaction_details"`
+		BankTransfer       struct {
+			Bank     string `json:"bank"`
+			VANumber string `json:"va_number"`
+		} `json:"bank_transfer"`
+		CustomExpiry struct {
+			ExpiryDuration int    `json:"expiry_duration"`
+			Unit           string `json:"unit"`
+		} `json:"custom_expiry"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusOK, map[string]any{"status_code": "400", "status_message": "request body tidak valid"})
+		return
+	}
+	if req.PaymentType != "bank_transfer" || req.BankTransfer.Bank == "" {
+		writeJSON(w, http.StatusOK, map[string]any{"status_code": "400", "status_message": "simulator hanya mendukung payment_type bank_transfer dengan bank"})
+		return
+	}
+
+	s.mu.Lock()
+	order, err := s.createOrder(req.TransactionDetails)
+	if err != nil {
+		s.mu.Unlock()
+		writeJSON(w, http.StatusOK, map[string]any{"status_code": "406", "status_message": err.Error()})
+		return
+	}
+	expiry := 24 * time.Hour
+	if req.CustomExpiry.ExpiryDuration > 0 {
+		expiry = time.Duration(req.CustomExpiry.ExpiryDuration) * expiryUnit(req.CustomExpiry.Unit)
+	}
+	order.PaymentType = req.PaymentType
+	order.Bank = req.BankTransfer.Bank
+	order.VANumber = req.BankTransfer.VANumber
+	if order.VANumber == "" {
+		order.VANumber = fmt.Sprintf("%011d", time.Now().UnixNano()%100000000000)
+	}
+	order.ExpiryTime = time.Now().Add(expiry).Format(timeLayout)
+	payload := s.payload(order)
+	s.mu.Unlock()
+
+	payload["status_message"] = "Success, Bank Transfer transaction is created"
+	writeJSON(w, http.StatusOK, payload)
+}
+
+func (s *Simulator) handleStatus(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	order, ok := s.orders[r.PathValue("order_id")]
+	if !ok {
+		s.mu.Unlock()
+		writeNotFound(w)
+		return
+	}
+	payload := s.payload(order)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, payload)
+}
+
+func (s *Simulator) handleAPITransition(status string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		orderID := r.PathValue("order_id")
+		payload, err := s.transition(orderID, status, "")
+		if errors.Is(err, ErrOrderNotFound) {
+			writeNotFound(w)
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusOK, map[string]any{"status_code": "412", "status_message": err.Error()})
+			return
+		}
+
+		go s.notify(orderID, payload)
+		writeJSON(w, http.StatusOK, payload)
+	}
+}
+
+func (s *Simulator) handleRefund(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RefundKey string `json:"refund_key"`
+		Amount    int64  `json:"amount"`
+		Reason    string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"status_code": "400", "status_message": "request body tidak valid"})
+		return
+	}
+
+	orderID := r.PathValue("order_id")
+	s.mu.Lock()
+	order, ok := s.orders[orderID]
+	if !ok {
+		s.mu.Unlock()
+		writeNotFound(w)
+		return
+	}
+	if previous, exists := s.refundKeys[orderID][req.RefundKey]; exists && req.RefundKey != "" {
+		s.mu.Unlock()
+		writeJSON(w, http.StatusOK, previous)
+		return
+	}
+	if order.TransactionStatus != StatusSettlement && order.TransactionStatus != StatusPartialRefund {
+		s.mu.Unlock()
+		writeJSON(w, http.StatusOK, map[string]any{"status_code": "412", "status_message": "Transaksi tidak dapat direfund"})
+		return
+	}
+	amount := req.Amount
+	if amount == 0 {
+		amount = order.GrossAmount - order.RefundAmount
+	}
+	if amount <= 0 || order.RefundAmount+amount > order.GrossAmount {
+		s.mu.Unlock()
+		writeJSON(w, http.StatusOK, map[string]any{"status_code": "412", "status_message": "Nominal refund melebihi nominal transaksi"})
+		return
+	}
+
+	order.RefundAmount += amount
+	order.TransactionStatus = StatusPartialRefund
+	if order.RefundAmount == order.GrossAmount {
+		order.TransactionStatus = StatusRefund
+	}
+	response := s.payload(order)
+	response["refund_key"] = req.RefundKey
+	response["refund_amount"] = strconv.FormatInt(amount, 10) + ".00"
+	response["status_message"] = "Success, refund request is approved"
+	if s.refundKeys[orderID] == nil {
+		s.refundKeys[orderID] = make(map[string]any)
+	}
+	s.refundKeys[orderID][req.RefundKey] = response
+	notification := s.payload(order)
+	s.mu.Unlock()
+
+	go s.notify(orderID, notification)
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Simulator) handleListOrders(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Orders())
+}
+
+func (s *Simulator) handleGetOrder(w http.ResponseWriter, r *http.Request) {
+	order, err := s.Order(r.PathValue("order_id"))
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, order)
+}
+
+func (s *Simulator) handleControl(status string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			PaymentType string `json:"payment_type"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		order, err := s.complete(r.PathValue("order_id"), status, req.PaymentType)
+		switch {
+		case errors.Is(err, ErrOrderNotFound):
+			writeJSON(w, http.StatusNotFound, map[string]any{"error": err.Error()})
+		case err != nil:
+			writeJSON(w, http.StatusConflict, map[string]any{"error": err.Error()})
+		default:
+			writeJSON(w, http.StatusOK, order)
+		}
+	}
+}
+
+func (s *Simulator) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, _, ok := r.BasicAuth()
+		if !ok || key == "" || (s.options.ServerKey != "" && key != s.options.ServerKey) {
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"status_code": "401", "status_message": "Access denied due to unauthorized transaction, please check client or server key"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Simulator) payload(order *Order) map[string]any {
+	statusCode := statusCodeFor(order.TransactionStatus)
+	grossAmount := strconv.FormatInt(order.GrossAmount, 10) + ".00"
+	hash := sha512.Sum512([]byte(order.OrderID + statusCode + grossAmount + s.options.ServerKey))
+
+	payload := map[string]any{
+		"transaction_time":   order.TransactionTime,
+		"transaction_status": order.TransactionStatus,
+		"transaction_id":     order.TransactionID,
+		"status_message":     "midtrans payment notification",
+		"status_code":        statusCode,
+		"signature_key":      hex.EncodeToString(hash[:]),
+		"payment_type":       order.PaymentType,
+		"order_id":           order.OrderID,
+		"merchant_id":        "SIMULATOR",
+		"gross_amount":       grossAmount,
+		"fraud_status":       "accept",
+		"currency":           "IDR",
+	}
+	if order.SettlementTime != "" {
+		payload["settlement_time"] = order.SettlementTime
+	}
+	if order.ExpiryTime != "" {
+		payload["expiry_time"] = order.ExpiryTime
+	}
+	if order.VANumber != "" {
+		if order.Bank == "permata" {
+			payload["permata_va_number"] = order.VANumber
+		} else {
+			payload["va_numbers"] = []map[string]string{{"bank": order.Bank, "va_number": order.VANumber}}
+		}
+	}
+	if order.RefundAmount > 0 {
+		payload["refund_amount"] = strconv.FormatInt(order.RefundAmount, 10) + ".00"
+	}
+	return payload
+}
+
+func (s *Simulator) notify(orderID string, payload map[string]any) {
+	event := Event{
+		TransactionStatus: payload["transaction_status"].(string),
+		SentAt:            time.Now().Format(timeLayout),
+	}
+	if s.options.NotificationURL == "" {
+		event.Error = "notification url belum diatur"
+	} else {
+		body, _ := json.Marshal(payload)
+		resp, err := s.options.HTTPClient.Post(s.options.NotificationURL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			event.Error = err.Error()
+		} else {
+			event.StatusCode = resp.StatusCode
+			resp.Body.Close()
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if order, ok := s.orders[orderID]; ok {
+		order.Notifications = append(order.Notifications, event)
+	}
+}
+
+func statusCodeFor(status string) string {
+	switch status {
+	case StatusSettlement, StatusRefund, StatusPartialRefund:
+		return "200"
+	case StatusPending:
+		return "201"
+	}
+	return "202"
+}
+
+func expiryUnit(unit string) time.Duration {
+	switch strings.TrimSuffix(unit, "s") {
+	case "second":
+		return time.Second
+	case "hour":
+		return time.Hour
+	case "day":
+		return 24 * time.Hour
+	}
+	return time.Minute
+}
+
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeNotFound(w http.ResponseWriter) {
+	writeJSON(w, http.StatusNotFound, map[string]any{"status_code": "404", "status_message": "Transaction doesn't exist."})
+}
+
+func randomHex(size int) string {
+	random := make([]byte, size)
+	_, _ = rand.Read(random)
+	return hex.EncodeToString(random)
+}
+
+func randomUUID() string {
+	id := randomHex(16)
+	return id[0:8] + "-" + id[8:12] + "-" + id[12:16] + "-" + id[16:20] + "-" + id[20:32]
+}
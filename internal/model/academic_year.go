@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+type TahunAjaran struct {
+	ID              uint      `gorm:"primaryKey"`
+	NamaTahunAjaran string    `gorm:"type:varchar(20);not null;unique"`
+	TanggalMulai    time.Time `gorm:"type:date;not null"`
+	TanggalSelesai  time.Time `gorm:"type:date;not null"`
+	Aktif           bool      `gorm:"not null"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
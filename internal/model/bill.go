@@ -6,11 +6,37 @@ type TagihanSPP struct {
 	ID                uint      `gorm:"primaryKey"`
 	SiswaID           uint      `gorm:"not null"`
 	PeriodeID         uint      `gorm:"not null"`
+	JenisBiayaID      uint      `gorm:"not null;default:1"`
+	JumlahAwal        float64   `gorm:"type:decimal(12,2);not null;default:0"`
+	JumlahPotongan    float64   `gorm:"type:decimal(12,2);not null;default:0"`
+	JumlahProrata     float64   `gorm:"type:decimal(12,2);not null;default:0"`
+	KeteranganProrata *string   `gorm:"type:varchar(255)"`
 	JumlahTagihan     float64   `gorm:"type:decimal(12,2);not null"`
-	StatusPembayaran  string    `gorm:"type:enum('belum_bayar', 'pending', 'lunas');default:'belum_bayar'"`
+	JumlahDenda       float64   `gorm:"type:decimal(12,2);not null;default:0"`
+	JumlahTerbayar    float64   `gorm:"type:decimal(12,2);not null;default:0"`
+	StatusPembayaran  string    `gorm:"type:enum('belum_bayar', 'pending', 'sebagian', 'lunas', 'dibebaskan');default:'belum_bayar'"`
+	TanggalJatuhTempo time.Time `gorm:"type:date;not null"`
+	DibebaskanOleh    *uint
+	AlasanPembebasan  *string `gorm:"type:varchar(255)"`
+	TanggalDibebaskan *time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	Siswa             Siswa             `gorm:"foreignKey:SiswaID"`
+	PeriodeSPP        PeriodeSPP        `gorm:"foreignKey:PeriodeID"`
+	JenisBiaya        JenisBiaya        `gorm:"foreignKey:JenisBiayaID"`
+	Cicilan           []CicilanTagihan  `gorm:"foreignKey:TagihanID"`
+	Denda             []DendaTagihan    `gorm:"foreignKey:TagihanID"`
+	Potongan          []PotonganTagihan `gorm:"foreignKey:TagihanID"`
+}
+
+type CicilanTagihan struct {
+	ID                uint      `gorm:"primaryKey"`
+	TagihanID         uint      `gorm:"not null"`
+	Urutan            int       `gorm:"not null"`
+	Jumlah            float64   `gorm:"type:decimal(12,2);not null"`
+	JumlahTerbayar    float64   `gorm:"type:decimal(12,2);not null;default:0"`
+	StatusPembayaran  string    `gorm:"type:enum('belum_bayar', 'sebagian', 'lunas');default:'belum_bayar'"`
 	TanggalJatuhTempo time.Time `gorm:"type:date;not null"`
 	CreatedAt         time.Time
 	UpdatedAt         time.Time
-	Siswa             Siswa      `gorm:"foreignKey:SiswaID"`
-	PeriodeSPP        PeriodeSPP `gorm:"foreignKey:PeriodeID"`
 }
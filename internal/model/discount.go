@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+type JenisPotongan struct {
+	ID               uint     `gorm:"primaryKey"`
+	NamaPotongan     string   `gorm:"type:varchar(100);not null;unique"`
+	JenisBiayaID     uint     `gorm:"not null;default:1"`
+	TipePotongan     string   `gorm:"type:enum('persen', 'nominal');not null"`
+	Nilai            float64  `gorm:"type:decimal(12,2);not null"`
+	MaksimalPotongan *float64 `gorm:"type:decimal(12,2)"`
+	Keterangan       *string  `gorm:"type:text"`
+	Status           string   `gorm:"type:enum('aktif', 'nonaktif');default:'aktif'"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+type PotonganSiswa struct {
+	ID               uint `gorm:"primaryKey"`
+	SiswaID          uint `gorm:"not null"`
+	JenisPotonganID  uint `gorm:"not null"`
+	PeriodeMulaiID   uint `gorm:"not null"`
+	PeriodeSelesaiID *uint
+	Keterangan       *string `gorm:"type:text"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	JenisPotongan    JenisPotongan `gorm:"foreignKey:JenisPotonganID"`
+	PeriodeMulai     PeriodeSPP    `gorm:"foreignKey:PeriodeMulaiID"`
+	PeriodeSelesai   *PeriodeSPP   `gorm:"foreignKey:PeriodeSelesaiID"`
+}
+
+type PotonganTagihan struct {
+	ID              uint `gorm:"primaryKey"`
+	TagihanID       uint `gorm:"not null"`
+	JenisPotonganID uint `gorm:"not null"`
+	PotonganSiswaID *uint
+	NamaPotongan    string  `gorm:"type:varchar(100);not null"`
+	Jumlah          float64 `gorm:"type:decimal(12,2);not null"`
+	CreatedAt       time.Time
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+type PembebasanTagihan struct {
+	ID           uint `gorm:"primaryKey"`
+	PeriodeID    uint `gorm:"not null"`
+	JenisBiayaID *uint
+	TingkatID    *uint
+	KelasID      *uint
+	SiswaID      *uint
+	Alasan       string `gorm:"type:varchar(255);not null"`
+	DibuatOleh   uint   `gorm:"not null"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	PeriodeSPP   PeriodeSPP    `gorm:"foreignKey:PeriodeID"`
+	JenisBiaya   *JenisBiaya   `gorm:"foreignKey:JenisBiayaID"`
+	TingkatKelas *TingkatKelas `gorm:"foreignKey:TingkatID"`
+	Kelas        *Kelas        `gorm:"foreignKey:KelasID"`
+	Siswa        *Siswa        `gorm:"foreignKey:SiswaID"`
+}
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+type Keluarga struct {
+	ID              uint    `gorm:"primaryKey"`
+	NamaKeluarga    string  `gorm:"type:varchar(100);not null"`
+	NamaOrangtua    string  `gorm:"type:varchar(100)"`
+	TeleponOrangtua string  `gorm:"type:varchar(20)"`
+	Keterangan      *string `gorm:"type:text"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Anggota         []Siswa `gorm:"foreignKey:KeluargaID"`
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+type JadwalTarif struct {
+	ID            uint      `gorm:"primaryKey"`
+	JenisBiayaID  uint      `gorm:"not null"`
+	TingkatID     uint      `gorm:"not null"`
+	TahunAjaranID uint      `gorm:"not null"`
+	Jumlah        float64   `gorm:"type:decimal(12,2);not null"`
+	BerlakuMulai  time.Time `gorm:"type:date;not null"`
+	Keterangan    *string   `gorm:"type:varchar(255)"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	JenisBiaya    JenisBiaya   `gorm:"foreignKey:JenisBiayaID"`
+	TingkatKelas  TingkatKelas `gorm:"foreignKey:TingkatID"`
+	TahunAjaran   TahunAjaran  `gorm:"foreignKey:TahunAjaranID"`
+}
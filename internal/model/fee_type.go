@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+type JenisBiaya struct {
+	ID           uint    `gorm:"primaryKey"`
+	KodeBiaya    string  `gorm:"type:varchar(30);not null;unique"`
+	NamaBiaya    string  `gorm:"type:varchar(100);not null"`
+	Periodisitas string  `gorm:"type:enum('bulanan', 'semester', 'sekali');default:'bulanan'"`
+	Wajib        bool    `gorm:"not null"`
+	Keterangan   *string `gorm:"type:text"`
+	Status       string  `gorm:"type:enum('aktif', 'nonaktif');default:'aktif'"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Tarif        []TarifBiaya `gorm:"foreignKey:JenisBiayaID"`
+}
+
+type TarifBiaya struct {
+	ID           uint    `gorm:"primaryKey"`
+	JenisBiayaID uint    `gorm:"not null"`
+	TingkatID    uint    `gorm:"not null"`
+	Jumlah       float64 `gorm:"type:decimal(12,2);not null"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	TingkatKelas TingkatKelas `gorm:"foreignKey:TingkatID"`
+}
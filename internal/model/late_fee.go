@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+type DendaTagihan struct {
+	ID                 uint      `gorm:"primaryKey"`
+	TagihanID          uint      `gorm:"not null"`
+	Urutan             int       `gorm:"not null"`
+	TanggalDenda       time.Time `gorm:"type:date;not null"`
+	Jumlah             float64   `gorm:"type:decimal(12,2);not null"`
+	Keterangan         string    `gorm:"type:varchar(255)"`
+	StatusDenda        string    `gorm:"type:enum('aktif', 'dihapuskan');default:'aktif'"`
+	AlasanPenghapusan  *string   `gorm:"type:text"`
+	DihapuskanOleh     *uint
+	TanggalPenghapusan *time.Time
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+}
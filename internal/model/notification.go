@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+type NotifikasiMidtrans struct {
+	ID                uint    `gorm:"primaryKey"`
+	Gateway           string  `gorm:"type:varchar(30);default:'midtrans'"`
+	OrderID           string  `gorm:"type:varchar(100);not null"`
+	TransactionID     *string `gorm:"type:varchar(100)"`
+	TransactionStatus string  `gorm:"type:varchar(50)"`
+	KunciDeduplikasi  *string `gorm:"type:varchar(255);unique"`
+	RawBody           string  `gorm:"type:longtext;not null"`
+	Headers           *string `gorm:"type:json"`
+	StatusProses      string  `gorm:"type:enum('diterima', 'berhasil', 'gagal', 'ditolak', 'duplikat');default:'diterima'"`
+	Keterangan        *string `gorm:"type:text"`
+	JumlahPercobaan   int     `gorm:"default:0"`
+	TanggalDiterima   time.Time
+	TanggalDiproses   *time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
@@ -3,20 +3,42 @@ package model
 import "time"
 
 type Pembayaran struct {
-	ID                uint    `gorm:"primaryKey"`
-	TagihanID         uint    `gorm:"not null"`
-	SiswaID           uint    `gorm:"not null"`
-	OrderID           string  `gorm:"type:varchar(100);not null;unique"`
-	TransactionID     *string `gorm:"type:varchar(100)"`
-	JumlahBayar       float64 `gorm:"type:decimal(12,2);not null"`
-	MetodePembayaran  *string `gorm:"type:varchar(50)"`
-	StatusPembayaran  string  `gorm:"type:enum('pending', 'settlement', 'cancel', 'expire', 'failure');default:'pending'"`
-	TanggalPembayaran *time.Time
-	TanggalSettlement *time.Time
-	MidtransResponse  *string `gorm:"type:json"`
-	Keterangan        *string `gorm:"type:text"`
-	CreatedAt         time.Time
-	UpdatedAt         time.Time
-	TagihanSPP        TagihanSPP `gorm:"foreignKey:TagihanID"`
-	Siswa             Siswa      `gorm:"foreignKey:SiswaID"`
+	ID                 uint    `gorm:"primaryKey"`
+	TagihanID          uint    `gorm:"not null"`
+	SiswaID            uint    `gorm:"not null"`
+	OrderID            string  `gorm:"type:varchar(100);not null;unique"`
+	Gateway            *string `gorm:"type:varchar(30)"`
+	TransactionID      *string `gorm:"type:varchar(100)"`
+	JumlahBayar        float64 `gorm:"type:decimal(12,2);not null"`
+	JumlahRefund       float64 `gorm:"type:decimal(12,2);default:0"`
+	BiayaAdmin         float64 `gorm:"type:decimal(12,2);default:0"`
+	KanalPembayaran    *string `gorm:"type:varchar(30)"`
+	MetodePembayaran   *string `gorm:"type:varchar(50)"`
+	StatusPembayaran   string  `gorm:"type:enum('pending', 'capture', 'settlement', 'deny', 'cancel', 'expire', 'failure', 'refund', 'partial_refund');default:'pending'"`
+	TanggalPembayaran  *time.Time
+	TanggalSettlement  *time.Time
+	SnapToken          *string `gorm:"type:varchar(255)"`
+	BankVA             *string `gorm:"type:varchar(20)"`
+	NomorVA            *string `gorm:"type:varchar(50)"`
+	TanggalKedaluwarsa *time.Time
+	MidtransResponse   *string `gorm:"type:json"`
+	NomorReferensi     *string `gorm:"type:varchar(100)"`
+	NomorKuitansi      *string `gorm:"type:varchar(30);unique"`
+	DiterimaOleh       *uint
+	Keterangan         *string `gorm:"type:text"`
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	TagihanSPP         TagihanSPP          `gorm:"foreignKey:TagihanID"`
+	Siswa              Siswa               `gorm:"foreignKey:SiswaID"`
+	DetailTagihan      []PembayaranTagihan `gorm:"foreignKey:PembayaranID"`
+}
+
+type PembayaranTagihan struct {
+	ID           uint    `gorm:"primaryKey"`
+	PembayaranID uint    `gorm:"not null"`
+	TagihanID    uint    `gorm:"not null"`
+	Jumlah       float64 `gorm:"type:decimal(12,2);not null"`
+	JumlahRefund float64 `gorm:"type:decimal(12,2);default:0"`
+	CreatedAt    time.Time
+	TagihanSPP   TagihanSPP `gorm:"foreignKey:TagihanID"`
 }
@@ -0,0 +1,9 @@
+package model
+
+import "time"
+
+type UrutanKuitansi struct {
+	Tahun         int `gorm:"primaryKey;autoIncrement:false"`
+	NomorTerakhir int `gorm:"not null"`
+	UpdatedAt     time.Time
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+type RekonsiliasiPembayaran struct {
+	ID               uint    `gorm:"primaryKey"`
+	Pemicu           string  `gorm:"type:enum('terjadwal', 'manual');default:'terjadwal'"`
+	JumlahDiperiksa  int     `gorm:"default:0"`
+	JumlahDiperbarui int     `gorm:"default:0"`
+	JumlahGagal      int     `gorm:"default:0"`
+	Detail           *string `gorm:"type:json"`
+	TanggalMulai     time.Time
+	TanggalSelesai   *time.Time
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
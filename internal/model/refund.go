@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+type RefundPembayaran struct {
+	ID               uint    `gorm:"primaryKey"`
+	PembayaranID     uint    `gorm:"not null"`
+	RefundKey        string  `gorm:"type:varchar(100);not null;unique"`
+	Jumlah           float64 `gorm:"type:decimal(12,2);not null"`
+	Alasan           string  `gorm:"type:text;not null"`
+	MetodeRefund     string  `gorm:"type:varchar(50);not null"`
+	StatusRefund     string  `gorm:"type:enum('menunggu_persetujuan', 'diproses', 'berhasil', 'ditolak', 'gagal');default:'diproses'"`
+	DiajukanOleh     uint    `gorm:"not null"`
+	DisetujuiOleh    *uint
+	TanggalDisetujui *time.Time
+	AlasanPenolakan  *string `gorm:"type:text"`
+	Keterangan       *string `gorm:"type:text"`
+	MidtransResponse *string `gorm:"type:json"`
+	TanggalRefund    *time.Time
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Pembayaran       Pembayaran `gorm:"foreignKey:PembayaranID"`
+}
@@ -9,8 +9,14 @@ type LaporanSiswa struct {
 	NamaTingkat       string     `gorm:"column:nama_tingkat" json:"nama_tingkat"`
 	TahunAjaran       string     `gorm:"column:tahun_ajaran" json:"tahun_ajaran"`
 	NamaBulan         string     `gorm:"column:nama_bulan" json:"nama_bulan"`
+	KodeBiaya         string     `gorm:"column:kode_biaya" json:"kode_biaya"`
+	NamaBiaya         string     `gorm:"column:nama_biaya" json:"nama_biaya"`
 	JumlahTagihan     float64    `gorm:"column:jumlah_tagihan" json:"jumlah_tagihan"`
+	JumlahDenda       float64    `gorm:"column:jumlah_denda" json:"jumlah_denda"`
+	JumlahTerbayar    float64    `gorm:"column:jumlah_terbayar" json:"jumlah_terbayar"`
+	SisaTagihan       float64    `gorm:"column:sisa_tagihan" json:"sisa_tagihan"`
 	StatusPembayaran  string     `gorm:"column:status_pembayaran" json:"status_pembayaran"`
+	AlasanPembebasan  *string    `gorm:"column:alasan_pembebasan" json:"alasan_pembebasan"`
 	TanggalJatuhTempo time.Time  `gorm:"column:tanggal_jatuh_tempo" json:"tanggal_jatuh_tempo"`
 	TanggalSettlement *time.Time `gorm:"column:tanggal_settlement" json:"tanggal_settlement"`
 	MetodePembayaran  *string    `gorm:"column:metode_pembayaran" json:"metode_pembayaran"`
@@ -21,22 +27,46 @@ type LaporanKelas struct {
 	NamaTingkat     string  `gorm:"column:nama_tingkat" json:"nama_tingkat"`
 	TahunAjaran     string  `gorm:"column:tahun_ajaran" json:"tahun_ajaran"`
 	NamaBulan       string  `gorm:"column:nama_bulan" json:"nama_bulan"`
+	KodeBiaya       string  `gorm:"column:kode_biaya" json:"kode_biaya"`
+	NamaBiaya       string  `gorm:"column:nama_biaya" json:"nama_biaya"`
 	TotalSiswa      int     `gorm:"column:total_siswa" json:"total_siswa"`
 	SiswaLunas      int     `gorm:"column:siswa_lunas" json:"siswa_lunas"`
 	SiswaBelumBayar int     `gorm:"column:siswa_belum_bayar" json:"siswa_belum_bayar"`
 	SiswaPending    int     `gorm:"column:siswa_pending" json:"siswa_pending"`
+	SiswaSebagian   int     `gorm:"column:siswa_sebagian" json:"siswa_sebagian"`
+	SiswaDibebaskan int     `gorm:"column:siswa_dibebaskan" json:"siswa_dibebaskan"`
 	TotalTagihan    float64 `gorm:"column:total_tagihan" json:"total_tagihan"`
+	TotalDenda      float64 `gorm:"column:total_denda" json:"total_denda"`
 	TotalTerbayar   float64 `gorm:"column:total_terbayar" json:"total_terbayar"`
+	TotalSisa       float64 `gorm:"column:total_sisa" json:"total_sisa"`
+	TotalDibebaskan float64 `gorm:"column:total_dibebaskan" json:"total_dibebaskan"`
 }
 
 type LaporanKeseluruhan struct {
-	TahunAjaran          string  `gorm:"column:tahun_ajaran" json:"tahun_ajaran"`
-	NamaBulan            string  `gorm:"column:nama_bulan" json:"nama_bulan"`
-	TotalTagihan         int     `gorm:"column:total_tagihan" json:"total_tagihan"`
-	TotalLunas           int     `gorm:"column:total_lunas" json:"total_lunas"`
-	TotalBelumBayar      int     `gorm:"column:total_belum_bayar" json:"total_belum_bayar"`
-	TotalPending         int     `gorm:"column:total_pending" json:"total_pending"`
-	TotalNominalTagihan  float64 `gorm:"column:total_nominal_tagihan" json:"total_nominal_tagihan"`
-	TotalNominalTerbayar float64 `gorm:"column:total_nominal_terbayar" json:"total_nominal_terbayar"`
-	PersentasePembayaran float64 `gorm:"column:persentase_pembayaran" json:"persentase_pembayaran"`
+	TahunAjaran            string  `gorm:"column:tahun_ajaran" json:"tahun_ajaran"`
+	NamaBulan              string  `gorm:"column:nama_bulan" json:"nama_bulan"`
+	KodeBiaya              string  `gorm:"column:kode_biaya" json:"kode_biaya"`
+	NamaBiaya              string  `gorm:"column:nama_biaya" json:"nama_biaya"`
+	TotalTagihan           int     `gorm:"column:total_tagihan" json:"total_tagihan"`
+	TotalLunas             int     `gorm:"column:total_lunas" json:"total_lunas"`
+	TotalBelumBayar        int     `gorm:"column:total_belum_bayar" json:"total_belum_bayar"`
+	TotalPending           int     `gorm:"column:total_pending" json:"total_pending"`
+	TotalSebagian          int     `gorm:"column:total_sebagian" json:"total_sebagian"`
+	TotalDibebaskan        int     `gorm:"column:total_dibebaskan" json:"total_dibebaskan"`
+	TotalNominalTagihan    float64 `gorm:"column:total_nominal_tagihan" json:"total_nominal_tagihan"`
+	TotalNominalDenda      float64 `gorm:"column:total_nominal_denda" json:"total_nominal_denda"`
+	TotalNominalTerbayar   float64 `gorm:"column:total_nominal_terbayar" json:"total_nominal_terbayar"`
+	TotalNominalSisa       float64 `gorm:"column:total_nominal_sisa" json:"total_nominal_sisa"`
+	TotalNominalDibebaskan float64 `gorm:"column:total_nominal_dibebaskan" json:"total_nominal_dibebaskan"`
+	PersentasePembayaran   float64 `gorm:"column:persentase_pembayaran" json:"persentase_pembayaran"`
+}
+
+type LaporanPotongan struct {
+	TahunAjaran   string  `gorm:"column:tahun_ajaran" json:"tahun_ajaran"`
+	NamaBulan     string  `gorm:"column:nama_bulan" json:"nama_bulan"`
+	KodeBiaya     string  `gorm:"column:kode_biaya" json:"kode_biaya"`
+	NamaBiaya     string  `gorm:"column:nama_biaya" json:"nama_biaya"`
+	NamaPotongan  string  `gorm:"column:nama_potongan" json:"nama_potongan"`
+	JumlahSiswa   int     `gorm:"column:jumlah_siswa" json:"jumlah_siswa"`
+	TotalPotongan float64 `gorm:"column:total_potongan" json:"total_potongan"`
 }
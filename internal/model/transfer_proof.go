@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+type BuktiTransfer struct {
+	ID                uint    `gorm:"primaryKey"`
+	PembayaranID      uint    `gorm:"not null"`
+	TagihanID         uint    `gorm:"not null"`
+	SiswaID           uint    `gorm:"not null"`
+	NamaFile          string  `gorm:"type:varchar(255);not null"`
+	LokasiFile        string  `gorm:"type:varchar(255);not null"`
+	TipeKonten        string  `gorm:"type:varchar(100);not null"`
+	UkuranFile        int64   `gorm:"not null"`
+	StatusVerifikasi  string  `gorm:"type:enum('menunggu', 'disetujui', 'ditolak');default:'menunggu'"`
+	AlasanPenolakan   *string `gorm:"type:text"`
+	DiverifikasiOleh  *uint
+	TanggalVerifikasi *time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	Pembayaran        Pembayaran `gorm:"foreignKey:PembayaranID"`
+	TagihanSPP        TagihanSPP `gorm:"foreignKey:TagihanID"`
+	Siswa             Siswa      `gorm:"foreignKey:SiswaID"`
+}
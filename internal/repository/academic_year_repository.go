@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"gorm.io/gorm"
+)
+
+type AcademicYearRepository interface {
+	Create(academicYear *model.TahunAjaran) error
+	FindAll() ([]model.TahunAjaran, error)
+	FindByID(id uint) (*model.TahunAjaran, error)
+	FindByNama(nama string) (*model.TahunAjaran, error)
+	FindActive() (*model.TahunAjaran, error)
+	FindWithPeriods() ([]model.TahunAjaran, error)
+	Update(academicYear *model.TahunAjaran) error
+	Delete(id uint) error
+	DeactivateOthers(id uint) error
+}
+
+type academicYearRepository struct {
+	db *gorm.DB
+}
+
+func NewAcademicYearRepository(db *gorm.DB) AcademicYearRepository {
+	return &academicYearRepository{db}
+}
+
+func (r *academicYearRepository) Create(academicYear *model.TahunAjaran) error {
+	return r.db.Create(academicYear).Error
+}
+
+func (r *academicYearRepository) FindAll() ([]model.TahunAjaran, error) {
+	var academicYears []model.TahunAjaran
+	err := r.db.Order("tanggal_mulai desc").Find(&academicYears).Error
+	return academicYears, err
+}
+
+func (r *academicYearRepository) FindByID(id uint) (*model.TahunAjaran, error) {
+	var academicYear model.TahunAjaran
+	err := r.db.Where("id = ?", id).First(&academicYear).Error
+	return &academicYear, err
+}
+
+func (r *academicYearRepository) FindByNama(nama string) (*model.TahunAjaran, error) {
+	var academicYear model.TahunAjaran
+	err := r.db.Where("nama_tahun_ajaran = ?", nama).First(&academicYear).Error
+	return &academicYear, err
+}
+
+func (r *academicYearRepository) FindActive() (*model.TahunAjaran, error) {
+	var academicYear model.TahunAjaran
+	err := r.db.Where("aktif = ?", true).First(&academicYear).Error
+	return &academicYear, err
+}
+
+func (r *academicYearRepository) FindWithPeriods() ([]model.TahunAjaran, error) {
+	var academicYears []model.TahunAjaran
+	err := r.db.Where("id IN (?)", r.db.Model(&model.PeriodeSPP{}).Select("tahun_ajaran_id")).
+		Order("tanggal_mulai asc").
+		Find(&academicYears).Error
+	return academicYears, err
+}
+
+func (r *academicYearRepository) Update(academicYear *model.TahunAjaran) error {
+	return r.db.Save(academicYear).Error
+}
+
+func (r *academicYearRepository) Delete(id uint) error {
+	return r.db.Where("id = ?", id).Delete(&model.TahunAjaran{}).Error
+}
+
+func (r *academicYearRepository) DeactivateOthers(id uint) error {
+	return r.db.Model(&model.TahunAjaran{}).Where("id <> ? AND aktif = ?", id, true).Update("aktif", false).Error
+}
@@ -4,14 +4,24 @@ import (
 	"github.com/hiuncy/spp-payment-api/internal/model"
 	"github.com/hiuncy/spp-payment-api/internal/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type BillRepository interface {
-	GenerateBills(periodID uint) error
+	FindStudentsForGeneration(params utils.GenerateBillsParams) ([]model.Siswa, error)
+	FindBilledSiswaIDs(feeTypeID uint, periodIDs []uint, siswaIDs []uint) ([]uint, error)
+	CreateBills(bills []model.TagihanSPP) error
 	FindAll(params utils.FindAllBillsParams) ([]model.TagihanSPP, int64, error)
+	FindAllBySiswaID(siswaID uint, status string) ([]model.TagihanSPP, error)
 	FindByID(id uint) (*model.TagihanSPP, error)
+	FindByIDForUpdate(id uint) (*model.TagihanSPP, error)
+	FindByIDsForUpdate(ids []uint) ([]model.TagihanSPP, error)
+	FindForWaiver(params utils.WaiveBillsParams) ([]model.TagihanSPP, error)
 	Update(bill *model.TagihanSPP) error
 	Delete(id uint) error
+	CountPayments(id uint) (int64, error)
+	ReplaceInstallments(billID uint, installments []model.CicilanTagihan) error
+	UpdateInstallment(installment *model.CicilanTagihan) error
 }
 
 type billRepository struct {
@@ -22,8 +32,43 @@ func NewBillRepository(db *gorm.DB) BillRepository {
 	return &billRepository{db}
 }
 
-func (r *billRepository) GenerateBills(periodID uint) error {
-	return r.db.Exec("CALL GenerateTagihanSPP(?)", periodID).Error
+func (r *billRepository) FindStudentsForGeneration(params utils.GenerateBillsParams) ([]model.Siswa, error) {
+	var students []model.Siswa
+	query := r.db.Preload("Kelas.TingkatKelas").
+		Joins("JOIN kelas k ON k.id = siswa.kelas_id").
+		Where("siswa.status = ?", "aktif")
+	if len(params.KelasIDs) > 0 {
+		query = query.Where("siswa.kelas_id IN ?", params.KelasIDs)
+	}
+	if len(params.TingkatIDs) > 0 {
+		query = query.Where("k.tingkat_id IN ?", params.TingkatIDs)
+	}
+	if len(params.SiswaIDs) > 0 {
+		query = query.Where("siswa.id IN ?", params.SiswaIDs)
+	}
+	err := query.Order("k.tingkat_id asc, k.nama_kelas asc, siswa.nama_lengkap asc").Find(&students).Error
+	return students, err
+}
+
+func (r *billRepository) FindBilledSiswaIDs(feeTypeID uint, periodIDs []uint, siswaIDs []uint) ([]uint, error) {
+	var ids []uint
+	if len(siswaIDs) == 0 {
+		return ids, nil
+	}
+	query := r.db.Model(&model.TagihanSPP{}).
+		Where("jenis_biaya_id = ? AND siswa_id IN ?", feeTypeID, siswaIDs)
+	if periodIDs != nil {
+		query = query.Where("periode_id IN ?", periodIDs)
+	}
+	err := query.Distinct("siswa_id").Pluck("siswa_id", &ids).Error
+	return ids, err
+}
+
+func (r *billRepository) CreateBills(bills []model.TagihanSPP) error {
+	if len(bills) == 0 {
+		return nil
+	}
+	return r.db.Omit(clause.Associations).CreateInBatches(&bills, 200).Error
 }
 
 func (r *billRepository) FindAll(params utils.FindAllBillsParams) ([]model.TagihanSPP, int64, error) {
@@ -41,6 +86,9 @@ func (r *billRepository) FindAll(params utils.FindAllBillsParams) ([]model.Tagih
 	if params.StatusPembayaran != "" {
 		query = query.Where("status_pembayaran = ?", params.StatusPembayaran)
 	}
+	if params.JenisBiayaID != 0 {
+		query = query.Where("jenis_biaya_id = ?", params.JenisBiayaID)
+	}
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
@@ -50,22 +98,115 @@ func (r *billRepository) FindAll(params utils.FindAllBillsParams) ([]model.Tagih
 	err := query.Limit(params.Limit).Offset(offset).
 		Preload("Siswa").
 		Preload("PeriodeSPP").
+		Preload("JenisBiaya").
+		Preload("Cicilan", orderByUrutan).
+		Preload("Potongan").
 		Order("id desc").
 		Find(&bills).Error
 
 	return bills, total, err
 }
 
+func (r *billRepository) FindAllBySiswaID(siswaID uint, status string) ([]model.TagihanSPP, error) {
+	var bills []model.TagihanSPP
+	query := r.db.Where("siswa_id = ?", siswaID)
+	if status != "" {
+		query = query.Where("status_pembayaran = ?", status)
+	}
+	err := query.Preload("Siswa").
+		Preload("PeriodeSPP").
+		Preload("JenisBiaya").
+		Preload("Cicilan", orderByUrutan).
+		Preload("Potongan").
+		Order("id desc").
+		Find(&bills).Error
+	return bills, err
+}
+
 func (r *billRepository) FindByID(id uint) (*model.TagihanSPP, error) {
 	var bill model.TagihanSPP
-	err := r.db.Preload("Siswa").Preload("PeriodeSPP").Where("id = ?", id).First(&bill).Error
+	err := r.db.Preload("Siswa").Preload("PeriodeSPP").Preload("JenisBiaya").Preload("Cicilan", orderByUrutan).Preload("Denda", orderByUrutan).Preload("Potongan").Where("id = ?", id).First(&bill).Error
+	return &bill, err
+}
+
+func (r *billRepository) FindByIDForUpdate(id uint) (*model.TagihanSPP, error) {
+	var bill model.TagihanSPP
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Cicilan", orderByUrutan).Where("id = ?", id).First(&bill).Error
 	return &bill, err
 }
 
+func (r *billRepository) FindByIDsForUpdate(ids []uint) ([]model.TagihanSPP, error) {
+	var bills []model.TagihanSPP
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("PeriodeSPP").
+		Preload("JenisBiaya").
+		Preload("Cicilan", orderByUrutan).
+		Where("id IN ?", ids).
+		Order("id asc").
+		Find(&bills).Error
+	return bills, err
+}
+
+func (r *billRepository) FindForWaiver(params utils.WaiveBillsParams) ([]model.TagihanSPP, error) {
+	var bills []model.TagihanSPP
+	query := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Siswa").
+		Preload("PeriodeSPP").
+		Preload("JenisBiaya").
+		Joins("JOIN siswa s ON s.id = tagihan_spp.siswa_id").
+		Joins("JOIN kelas k ON k.id = s.kelas_id")
+	if len(params.TagihanIDs) > 0 {
+		query = query.Where("tagihan_spp.id IN ?", params.TagihanIDs)
+	}
+	if params.PeriodeID != 0 {
+		query = query.Where("tagihan_spp.periode_id = ?", params.PeriodeID)
+	}
+	if params.JenisBiayaID != 0 {
+		query = query.Where("tagihan_spp.jenis_biaya_id = ?", params.JenisBiayaID)
+	}
+	if len(params.KelasIDs) > 0 {
+		query = query.Where("s.kelas_id IN ?", params.KelasIDs)
+	}
+	if len(params.TingkatIDs) > 0 {
+		query = query.Where("k.tingkat_id IN ?", params.TingkatIDs)
+	}
+	if len(params.SiswaIDs) > 0 {
+		query = query.Where("tagihan_spp.siswa_id IN ?", params.SiswaIDs)
+	}
+	err := query.Order("tagihan_spp.id asc").Find(&bills).Error
+	return bills, err
+}
+
 func (r *billRepository) Update(bill *model.TagihanSPP) error {
-	return r.db.Save(bill).Error
+	return r.db.Omit(clause.Associations).Save(bill).Error
 }
 
 func (r *billRepository) Delete(id uint) error {
 	return r.db.Where("id = ?", id).Delete(&model.TagihanSPP{}).Error
 }
+
+func (r *billRepository) CountPayments(id uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.Pembayaran{}).
+		Where("tagihan_id = ? OR id IN (?)", id, r.db.Model(&model.PembayaranTagihan{}).Select("pembayaran_id").Where("tagihan_id = ?", id)).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *billRepository) ReplaceInstallments(billID uint, installments []model.CicilanTagihan) error {
+	if err := r.db.Where("tagihan_id = ?", billID).Delete(&model.CicilanTagihan{}).Error; err != nil {
+		return err
+	}
+	if len(installments) == 0 {
+		return nil
+	}
+	return r.db.Create(&installments).Error
+}
+
+func (r *billRepository) UpdateInstallment(installment *model.CicilanTagihan) error {
+	return r.db.Save(installment).Error
+}
+
+func orderByUrutan(db *gorm.DB) *gorm.DB {
+	return db.Order("urutan asc")
+}
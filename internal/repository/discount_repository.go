@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"gorm.io/gorm"
+)
+
+type DiscountRepository interface {
+	CreateType(discountType *model.JenisPotongan) error
+	FindAllTypes() ([]model.JenisPotongan, error)
+	FindTypeByID(id uint) (*model.JenisPotongan, error)
+	FindTypeByName(name string) (*model.JenisPotongan, error)
+	UpdateType(discountType *model.JenisPotongan) error
+	DeleteType(id uint) error
+	CreateAssignment(assignment *model.PotonganSiswa) error
+	FindAssignmentsBySiswaID(siswaID uint) ([]model.PotonganSiswa, error)
+	FindAssignmentByID(id uint) (*model.PotonganSiswa, error)
+	FindActiveAssignments(siswaIDs []uint, feeTypeID uint, period *model.PeriodeSPP) ([]model.PotonganSiswa, error)
+	UpdateAssignment(assignment *model.PotonganSiswa) error
+	DeleteAssignment(id uint) error
+	CreateBillDiscounts(discounts []model.PotonganTagihan) error
+}
+
+type discountRepository struct {
+	db *gorm.DB
+}
+
+func NewDiscountRepository(db *gorm.DB) DiscountRepository {
+	return &discountRepository{db}
+}
+
+func (r *discountRepository) CreateType(discountType *model.JenisPotongan) error {
+	return r.db.Create(discountType).Error
+}
+
+func (r *discountRepository) FindAllTypes() ([]model.JenisPotongan, error) {
+	var discountTypes []model.JenisPotongan
+	err := r.db.Order("nama_potongan asc").Find(&discountTypes).Error
+	return discountTypes, err
+}
+
+func (r *discountRepository) FindTypeByID(id uint) (*model.JenisPotongan, error) {
+	var discountType model.JenisPotongan
+	err := r.db.Where("id = ?", id).First(&discountType).Error
+	return &discountType, err
+}
+
+func (r *discountRepository) FindTypeByName(name string) (*model.JenisPotongan, error) {
+	var discountType model.JenisPotongan
+	err := r.db.Where("nama_potongan = ?", name).First(&discountType).Error
+	return &discountType, err
+}
+
+func (r *discountRepository) UpdateType(discountType *model.JenisPotongan) error {
+	return r.db.Save(discountType).Error
+}
+
+func (r *discountRepository) DeleteType(id uint) error {
+	return r.db.Where("id = ?", id).Delete(&model.JenisPotongan{}).Error
+}
+
+func (r *discountRepository) CreateAssignment(assignment *model.PotonganSiswa) error {
+	return r.db.Omit("JenisPotongan", "PeriodeMulai", "PeriodeSelesai").Create(assignment).Error
+}
+
+func (r *discountRepository) FindAssignmentsBySiswaID(siswaID uint) ([]model.PotonganSiswa, error) {
+	var assignments []model.PotonganSiswa
+	err := r.db.Preload("JenisPotongan").Preload("PeriodeMulai").Preload("PeriodeSelesai").
+		Where("siswa_id = ?", siswaID).
+		Order("id asc").
+		Find(&assignments).Error
+	return assignments, err
+}
+
+func (r *discountRepository) FindAssignmentByID(id uint) (*model.PotonganSiswa, error) {
+	var assignment model.PotonganSiswa
+	err := r.db.Preload("JenisPotongan").Preload("PeriodeMulai").Preload("PeriodeSelesai").Where("id = ?", id).First(&assignment).Error
+	return &assignment, err
+}
+
+func (r *discountRepository) FindActiveAssignments(siswaIDs []uint, feeTypeID uint, period *model.PeriodeSPP) ([]model.PotonganSiswa, error) {
+	var assignments []model.PotonganSiswa
+	if len(siswaIDs) == 0 {
+		return assignments, nil
+	}
+	err := r.db.Preload("JenisPotongan").
+		Joins("JOIN jenis_potongan jp ON jp.id = potongan_siswa.jenis_potongan_id AND jp.status = ? AND jp.jenis_biaya_id = ?", "aktif", feeTypeID).
+		Joins("JOIN periode_spp mulai ON mulai.id = potongan_siswa.periode_mulai_id").
+		Joins("LEFT JOIN periode_spp selesai ON selesai.id = potongan_siswa.periode_selesai_id").
+		Where("potongan_siswa.siswa_id IN ?", siswaIDs).
+		Where("mulai.tanggal_mulai <= ?", period.TanggalMulai).
+		Where("selesai.id IS NULL OR selesai.tanggal_mulai >= ?", period.TanggalMulai).
+		Order("potongan_siswa.id asc").
+		Find(&assignments).Error
+	return assignments, err
+}
+
+func (r *discountRepository) UpdateAssignment(assignment *model.PotonganSiswa) error {
+	return r.db.Omit("JenisPotongan", "PeriodeMulai", "PeriodeSelesai").Save(assignment).Error
+}
+
+func (r *discountRepository) DeleteAssignment(id uint) error {
+	return r.db.Where("id = ?", id).Delete(&model.PotonganSiswa{}).Error
+}
+
+func (r *discountRepository) CreateBillDiscounts(discounts []model.PotonganTagihan) error {
+	if len(discounts) == 0 {
+		return nil
+	}
+	return r.db.Create(&discounts).Error
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"gorm.io/gorm"
+)
+
+type ExemptionRepository interface {
+	Create(exemption *model.PembebasanTagihan) error
+	FindAll(periodeID uint) ([]model.PembebasanTagihan, error)
+	FindByID(id uint) (*model.PembebasanTagihan, error)
+	FindByPeriode(periodeID, feeTypeID uint) ([]model.PembebasanTagihan, error)
+	Delete(id uint) error
+}
+
+type exemptionRepository struct {
+	db *gorm.DB
+}
+
+func NewExemptionRepository(db *gorm.DB) ExemptionRepository {
+	return &exemptionRepository{db}
+}
+
+func (r *exemptionRepository) Create(exemption *model.PembebasanTagihan) error {
+	return r.db.Omit("PeriodeSPP", "JenisBiaya", "TingkatKelas", "Kelas", "Siswa").Create(exemption).Error
+}
+
+func (r *exemptionRepository) FindAll(periodeID uint) ([]model.PembebasanTagihan, error) {
+	var exemptions []model.PembebasanTagihan
+	query := r.preload()
+	if periodeID != 0 {
+		query = query.Where("periode_id = ?", periodeID)
+	}
+	err := query.Order("id desc").Find(&exemptions).Error
+	return exemptions, err
+}
+
+func (r *exemptionRepository) FindByID(id uint) (*model.PembebasanTagihan, error) {
+	var exemption model.PembebasanTagihan
+	err := r.preload().Where("id = ?", id).First(&exemption).Error
+	return &exemption, err
+}
+
+func (r *exemptionRepository) FindByPeriode(periodeID, feeTypeID uint) ([]model.PembebasanTagihan, error) {
+	var exemptions []model.PembebasanTagihan
+	err := r.db.Where("periode_id = ? AND (jenis_biaya_id IS NULL OR jenis_biaya_id = ?)", periodeID, feeTypeID).
+		Find(&exemptions).Error
+	return exemptions, err
+}
+
+func (r *exemptionRepository) Delete(id uint) error {
+	return r.db.Where("id = ?", id).Delete(&model.PembebasanTagihan{}).Error
+}
+
+func (r *exemptionRepository) preload() *gorm.DB {
+	return r.db.Preload("PeriodeSPP").Preload("JenisBiaya").Preload("TingkatKelas").Preload("Kelas").Preload("Siswa")
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"gorm.io/gorm"
+)
+
+type FamilyRepository interface {
+	Create(family *model.Keluarga) error
+	FindAll(search string) ([]model.Keluarga, error)
+	FindByID(id uint) (*model.Keluarga, error)
+	Update(family *model.Keluarga) error
+	Delete(id uint) error
+	SetMembers(familyID uint, siswaIDs []uint) error
+	FindStudentsByIDs(ids []uint) ([]model.Siswa, error)
+	FindSuggestionCandidates() ([]model.Siswa, error)
+	FindActiveSiblings(siswaIDs []uint) ([]model.Siswa, error)
+}
+
+type familyRepository struct {
+	db *gorm.DB
+}
+
+func NewFamilyRepository(db *gorm.DB) FamilyRepository {
+	return &familyRepository{db}
+}
+
+func (r *familyRepository) Create(family *model.Keluarga) error {
+	return r.db.Omit("Anggota").Create(family).Error
+}
+
+func (r *familyRepository) FindAll(search string) ([]model.Keluarga, error) {
+	var families []model.Keluarga
+	query := r.db
+	if search != "" {
+		query = query.Where("nama_keluarga LIKE ? OR nama_orangtua LIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+	err := query.Preload("Anggota", orderBySiblingRank).Preload("Anggota.Kelas").Order("nama_keluarga asc").Find(&families).Error
+	return families, err
+}
+
+func (r *familyRepository) FindByID(id uint) (*model.Keluarga, error) {
+	var family model.Keluarga
+	err := r.db.Preload("Anggota", orderBySiblingRank).Preload("Anggota.Kelas").Where("id = ?", id).First(&family).Error
+	return &family, err
+}
+
+func (r *familyRepository) Update(family *model.Keluarga) error {
+	return r.db.Omit("Anggota").Save(family).Error
+}
+
+func (r *familyRepository) Delete(id uint) error {
+	if err := r.db.Model(&model.Siswa{}).Where("keluarga_id = ?", id).Update("keluarga_id", nil).Error; err != nil {
+		return err
+	}
+	return r.db.Where("id = ?", id).Delete(&model.Keluarga{}).Error
+}
+
+func (r *familyRepository) SetMembers(familyID uint, siswaIDs []uint) error {
+	if err := r.db.Model(&model.Siswa{}).Where("keluarga_id = ? AND id NOT IN ?", familyID, siswaIDs).Update("keluarga_id", nil).Error; err != nil {
+		return err
+	}
+	return r.db.Model(&model.Siswa{}).Where("id IN ?", siswaIDs).Update("keluarga_id", familyID).Error
+}
+
+func (r *familyRepository) FindStudentsByIDs(ids []uint) ([]model.Siswa, error) {
+	var students []model.Siswa
+	err := r.db.Where("id IN ?", ids).Order("id asc").Find(&students).Error
+	return students, err
+}
+
+func (r *familyRepository) FindSuggestionCandidates() ([]model.Siswa, error) {
+	var students []model.Siswa
+	err := r.db.Preload("Kelas").
+		Where("status = ?", "aktif").
+		Where("nama_orangtua <> '' AND telepon_orangtua <> ''").
+		Scopes(orderBySiblingRank).
+		Find(&students).Error
+	return students, err
+}
+
+func (r *familyRepository) FindActiveSiblings(siswaIDs []uint) ([]model.Siswa, error) {
+	var students []model.Siswa
+	if len(siswaIDs) == 0 {
+		return students, nil
+	}
+	err := r.db.Where("status = ?", "aktif").
+		Where("keluarga_id IN (?)", r.db.Model(&model.Siswa{}).Select("keluarga_id").Where("id IN ? AND keluarga_id IS NOT NULL", siswaIDs)).
+		Scopes(orderBySiblingRank).
+		Find(&students).Error
+	return students, err
+}
+
+func orderBySiblingRank(db *gorm.DB) *gorm.DB {
+	return db.Order("tanggal_lahir IS NULL, tanggal_lahir asc, tanggal_masuk IS NULL, tanggal_masuk asc, id asc")
+}
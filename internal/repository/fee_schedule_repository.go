@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+type FeeScheduleRepository interface {
+	Create(schedule *model.JadwalTarif) error
+	FindAll(params utils.FindAllFeeSchedulesParams) ([]model.JadwalTarif, error)
+	FindByID(id uint) (*model.JadwalTarif, error)
+	FindEffective(feeTypeID, tahunAjaranID uint, date time.Time) ([]model.JadwalTarif, error)
+	Update(schedule *model.JadwalTarif) error
+	Delete(id uint) error
+	CountBills(schedule *model.JadwalTarif) (int64, error)
+	CountActiveStudentsByLevel() (map[uint]int64, error)
+}
+
+type feeScheduleRepository struct {
+	db *gorm.DB
+}
+
+func NewFeeScheduleRepository(db *gorm.DB) FeeScheduleRepository {
+	return &feeScheduleRepository{db}
+}
+
+func (r *feeScheduleRepository) Create(schedule *model.JadwalTarif) error {
+	return r.db.Omit("JenisBiaya", "TingkatKelas", "TahunAjaran").Create(schedule).Error
+}
+
+func (r *feeScheduleRepository) FindAll(params utils.FindAllFeeSchedulesParams) ([]model.JadwalTarif, error) {
+	var schedules []model.JadwalTarif
+	query := r.preload()
+	if params.TahunAjaranID != 0 {
+		query = query.Where("tahun_ajaran_id = ?", params.TahunAjaranID)
+	}
+	if params.JenisBiayaID != 0 {
+		query = query.Where("jenis_biaya_id = ?", params.JenisBiayaID)
+	}
+	if params.TingkatID != 0 {
+		query = query.Where("tingkat_id = ?", params.TingkatID)
+	}
+	err := query.Order("tahun_ajaran_id desc, jenis_biaya_id asc, tingkat_id asc, berlaku_mulai asc").Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *feeScheduleRepository) FindByID(id uint) (*model.JadwalTarif, error) {
+	var schedule model.JadwalTarif
+	err := r.preload().Where("id = ?", id).First(&schedule).Error
+	return &schedule, err
+}
+
+func (r *feeScheduleRepository) FindEffective(feeTypeID, tahunAjaranID uint, date time.Time) ([]model.JadwalTarif, error) {
+	var schedules []model.JadwalTarif
+	err := r.db.Where("jenis_biaya_id = ? AND tahun_ajaran_id = ? AND berlaku_mulai <= ?", feeTypeID, tahunAjaranID, date).
+		Order("berlaku_mulai asc").
+		Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *feeScheduleRepository) Update(schedule *model.JadwalTarif) error {
+	return r.db.Omit("JenisBiaya", "TingkatKelas", "TahunAjaran").Save(schedule).Error
+}
+
+func (r *feeScheduleRepository) Delete(id uint) error {
+	return r.db.Where("id = ?", id).Delete(&model.JadwalTarif{}).Error
+}
+
+func (r *feeScheduleRepository) CountBills(schedule *model.JadwalTarif) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.TagihanSPP{}).
+		Joins("JOIN periode_spp ON periode_spp.id = tagihan_spp.periode_id").
+		Joins("JOIN siswa ON siswa.id = tagihan_spp.siswa_id").
+		Joins("JOIN kelas ON kelas.id = siswa.kelas_id").
+		Where("tagihan_spp.jenis_biaya_id = ? AND periode_spp.tahun_ajaran_id = ? AND periode_spp.tanggal_mulai >= ? AND kelas.tingkat_id = ?",
+			schedule.JenisBiayaID, schedule.TahunAjaranID, schedule.BerlakuMulai, schedule.TingkatID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *feeScheduleRepository) CountActiveStudentsByLevel() (map[uint]int64, error) {
+	var rows []struct {
+		TingkatID uint
+		Jumlah    int64
+	}
+	err := r.db.Model(&model.Siswa{}).
+		Select("kelas.tingkat_id, COUNT(siswa.id) AS jumlah").
+		Joins("JOIN kelas ON kelas.id = siswa.kelas_id").
+		Where("siswa.status = ?", "aktif").
+		Group("kelas.tingkat_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.TingkatID] = row.Jumlah
+	}
+	return counts, nil
+}
+
+func (r *feeScheduleRepository) preload() *gorm.DB {
+	return r.db.Preload("JenisBiaya").Preload("TingkatKelas").Preload("TahunAjaran")
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"gorm.io/gorm"
+)
+
+type FeeTypeRepository interface {
+	Create(feeType *model.JenisBiaya) error
+	FindAll() ([]model.JenisBiaya, error)
+	FindByID(id uint) (*model.JenisBiaya, error)
+	FindByKode(kode string) (*model.JenisBiaya, error)
+	Update(feeType *model.JenisBiaya) error
+	Delete(id uint) error
+	ReplaceRates(feeTypeID uint, rates []model.TarifBiaya) error
+}
+
+type feeTypeRepository struct {
+	db *gorm.DB
+}
+
+func NewFeeTypeRepository(db *gorm.DB) FeeTypeRepository {
+	return &feeTypeRepository{db}
+}
+
+func (r *feeTypeRepository) Create(feeType *model.JenisBiaya) error {
+	return r.db.Omit("Tarif").Create(feeType).Error
+}
+
+func (r *feeTypeRepository) FindAll() ([]model.JenisBiaya, error) {
+	var feeTypes []model.JenisBiaya
+	err := r.db.Preload("Tarif.TingkatKelas").Order("id asc").Find(&feeTypes).Error
+	return feeTypes, err
+}
+
+func (r *feeTypeRepository) FindByID(id uint) (*model.JenisBiaya, error) {
+	var feeType model.JenisBiaya
+	err := r.db.Preload("Tarif.TingkatKelas").Where("id = ?", id).First(&feeType).Error
+	return &feeType, err
+}
+
+func (r *feeTypeRepository) FindByKode(kode string) (*model.JenisBiaya, error) {
+	var feeType model.JenisBiaya
+	err := r.db.Preload("Tarif.TingkatKelas").Where("kode_biaya = ?", kode).First(&feeType).Error
+	return &feeType, err
+}
+
+func (r *feeTypeRepository) Update(feeType *model.JenisBiaya) error {
+	return r.db.Omit("Tarif").Save(feeType).Error
+}
+
+func (r *feeTypeRepository) Delete(id uint) error {
+	if err := r.db.Where("jenis_biaya_id = ?", id).Delete(&model.TarifBiaya{}).Error; err != nil {
+		return err
+	}
+	return r.db.Where("id = ?", id).Delete(&model.JenisBiaya{}).Error
+}
+
+func (r *feeTypeRepository) ReplaceRates(feeTypeID uint, rates []model.TarifBiaya) error {
+	if err := r.db.Where("jenis_biaya_id = ?", feeTypeID).Delete(&model.TarifBiaya{}).Error; err != nil {
+		return err
+	}
+	if len(rates) == 0 {
+		return nil
+	}
+	return r.db.Omit("TingkatKelas").Create(&rates).Error
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LateFeeRepository interface {
+	FindOverdueBillIDs(dueBefore time.Time, statuses []string) ([]uint, error)
+	FindByTagihanID(tagihanID uint) ([]model.DendaTagihan, error)
+	FindByIDForUpdate(id uint) (*model.DendaTagihan, error)
+	Create(fee *model.DendaTagihan) error
+	Update(fee *model.DendaTagihan) error
+}
+
+type lateFeeRepository struct {
+	db *gorm.DB
+}
+
+func NewLateFeeRepository(db *gorm.DB) LateFeeRepository {
+	return &lateFeeRepository{db}
+}
+
+func (r *lateFeeRepository) FindOverdueBillIDs(dueBefore time.Time, statuses []string) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&model.TagihanSPP{}).
+		Joins("JOIN jenis_biaya jb ON jb.id = tagihan_spp.jenis_biaya_id AND jb.wajib = ?", true).
+		Where("tagihan_spp.status_pembayaran IN ?", statuses).
+		Where("tagihan_spp.tanggal_jatuh_tempo < ? OR tagihan_spp.id IN (?)", dueBefore,
+			r.db.Model(&model.CicilanTagihan{}).Select("tagihan_id").Where("tanggal_jatuh_tempo < ? AND status_pembayaran <> ?", dueBefore, "lunas")).
+		Order("tagihan_spp.id asc").
+		Pluck("tagihan_spp.id", &ids).Error
+	return ids, err
+}
+
+func (r *lateFeeRepository) FindByTagihanID(tagihanID uint) ([]model.DendaTagihan, error) {
+	var fees []model.DendaTagihan
+	err := r.db.Where("tagihan_id = ?", tagihanID).Order("urutan asc").Find(&fees).Error
+	return fees, err
+}
+
+func (r *lateFeeRepository) FindByIDForUpdate(id uint) (*model.DendaTagihan, error) {
+	var fee model.DendaTagihan
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&fee).Error
+	return &fee, err
+}
+
+func (r *lateFeeRepository) Create(fee *model.DendaTagihan) error {
+	return r.db.Create(fee).Error
+}
+
+func (r *lateFeeRepository) Update(fee *model.DendaTagihan) error {
+	return r.db.Save(fee).Error
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"gorm.io/gorm"
+)
+
+type LogRepository interface {
+	Create(log *model.LogAktivitas) error
+}
+
+type logRepository struct {
+	db *gorm.DB
+}
+
+func NewLogRepository(db *gorm.DB) LogRepository {
+	return &logRepository{db}
+}
+
+func (r *logRepository) Create(log *model.LogAktivitas) error {
+	return r.db.Create(log).Error
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+type NotificationRepository interface {
+	Create(notification *model.NotifikasiMidtrans) error
+	FindAll(params utils.FindAllNotificationsParams) ([]model.NotifikasiMidtrans, int64, error)
+	FindByID(id uint) (*model.NotifikasiMidtrans, error)
+	FindByDedupKey(key string) (*model.NotifikasiMidtrans, error)
+	Update(notification *model.NotifikasiMidtrans) error
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db}
+}
+
+func (r *notificationRepository) Create(notification *model.NotifikasiMidtrans) error {
+	return r.db.Create(notification).Error
+}
+
+func (r *notificationRepository) FindAll(params utils.FindAllNotificationsParams) ([]model.NotifikasiMidtrans, int64, error) {
+	var notifications []model.NotifikasiMidtrans
+	var total int64
+
+	query := r.db.Model(&model.NotifikasiMidtrans{})
+
+	if params.OrderID != "" {
+		query = query.Where("order_id = ?", params.OrderID)
+	}
+	if params.StatusProses != "" {
+		query = query.Where("status_proses = ?", params.StatusProses)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	err := query.Limit(params.Limit).Offset(offset).
+		Order("id desc").
+		Find(&notifications).Error
+
+	return notifications, total, err
+}
+
+func (r *notificationRepository) FindByID(id uint) (*model.NotifikasiMidtrans, error) {
+	var notification model.NotifikasiMidtrans
+	err := r.db.Where("id = ?", id).First(&notification).Error
+	return &notification, err
+}
+
+func (r *notificationRepository) FindByDedupKey(key string) (*model.NotifikasiMidtrans, error) {
+	var notification model.NotifikasiMidtrans
+	err := r.db.Where("kunci_deduplikasi = ?", key).First(&notification).Error
+	return &notification, err
+}
+
+func (r *notificationRepository) Update(notification *model.NotifikasiMidtrans) error {
+	return r.db.Save(notification).Error
+}
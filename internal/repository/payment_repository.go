@@ -1,8 +1,11 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/hiuncy/spp-payment-api/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PaymentRepository interface {
@@ -10,6 +13,14 @@ type PaymentRepository interface {
 	Delete(id uint) error
 	FindAllBySiswaID(siswaID uint) ([]model.Pembayaran, error)
 	FindByOrderID(orderID string) (*model.Pembayaran, error)
+	FindByOrderIDForUpdate(orderID string) (*model.Pembayaran, error)
+	FindByOrderIDWithDetails(orderID string) (*model.Pembayaran, error)
+	FindOtherByTagihanID(tagihanID, excludeID uint) ([]model.Pembayaran, error)
+	FindPendingCreatedBefore(cutoff time.Time, excludedMethods []string) ([]model.Pembayaran, error)
+	FindPendingByTagihanIDs(tagihanIDs []uint) ([]model.Pembayaran, error)
+	FindPendingBySiswaID(siswaID uint) ([]model.Pembayaran, error)
+	Update(payment *model.Pembayaran) error
+	UpdateDetail(detail *model.PembayaranTagihan) error
 }
 
 type paymentRepository struct {
@@ -21,7 +32,7 @@ func NewPaymentRepository(db *gorm.DB) PaymentRepository {
 }
 
 func (r *paymentRepository) Create(payment *model.Pembayaran) error {
-	return r.db.Create(payment).Error
+	return r.db.Omit("TagihanSPP", "Siswa", "DetailTagihan.TagihanSPP").Create(payment).Error
 }
 
 func (r *paymentRepository) Delete(id uint) error {
@@ -32,6 +43,9 @@ func (r *paymentRepository) FindAllBySiswaID(siswaID uint) ([]model.Pembayaran,
 	var payments []model.Pembayaran
 	err := r.db.Where("siswa_id = ?", siswaID).
 		Preload("TagihanSPP.PeriodeSPP").
+		Preload("TagihanSPP.JenisBiaya").
+		Preload("DetailTagihan.TagihanSPP.PeriodeSPP").
+		Preload("DetailTagihan.TagihanSPP.JenisBiaya").
 		Order("id desc").
 		Find(&payments).Error
 	return payments, err
@@ -39,6 +53,74 @@ func (r *paymentRepository) FindAllBySiswaID(siswaID uint) ([]model.Pembayaran,
 
 func (r *paymentRepository) FindByOrderID(orderID string) (*model.Pembayaran, error) {
 	var payment model.Pembayaran
-	err := r.db.Where("order_id = ?", orderID).First(&payment).Error
+	err := r.db.Preload("DetailTagihan").Where("order_id = ?", orderID).First(&payment).Error
+	return &payment, err
+}
+
+func (r *paymentRepository) FindByOrderIDForUpdate(orderID string) (*model.Pembayaran, error) {
+	var payment model.Pembayaran
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("DetailTagihan").
+		Where("order_id = ?", orderID).
+		First(&payment).Error
 	return &payment, err
 }
+
+func (r *paymentRepository) FindByOrderIDWithDetails(orderID string) (*model.Pembayaran, error) {
+	var payment model.Pembayaran
+	err := r.db.Preload("Siswa.Kelas").
+		Preload("TagihanSPP.PeriodeSPP").
+		Preload("TagihanSPP.JenisBiaya").
+		Preload("DetailTagihan.TagihanSPP.PeriodeSPP").
+		Preload("DetailTagihan.TagihanSPP.JenisBiaya").
+		Where("order_id = ?", orderID).
+		First(&payment).Error
+	return &payment, err
+}
+
+func (r *paymentRepository) FindOtherByTagihanID(tagihanID, excludeID uint) ([]model.Pembayaran, error) {
+	var payments []model.Pembayaran
+	err := r.db.Joins("JOIN pembayaran_tagihan ON pembayaran_tagihan.pembayaran_id = pembayaran.id").
+		Where("pembayaran_tagihan.tagihan_id = ? AND pembayaran.id <> ?", tagihanID, excludeID).
+		Find(&payments).Error
+	return payments, err
+}
+
+func (r *paymentRepository) FindPendingCreatedBefore(cutoff time.Time, excludedMethods []string) ([]model.Pembayaran, error) {
+	var payments []model.Pembayaran
+	query := r.db.Where("status_pembayaran = ? AND created_at < ?", "pending", cutoff)
+	if len(excludedMethods) > 0 {
+		query = query.Where("metode_pembayaran IS NULL OR metode_pembayaran NOT IN ?", excludedMethods)
+	}
+	err := query.Order("id asc").
+		Find(&payments).Error
+	return payments, err
+}
+
+func (r *paymentRepository) FindPendingByTagihanIDs(tagihanIDs []uint) ([]model.Pembayaran, error) {
+	var payments []model.Pembayaran
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("DetailTagihan").
+		Where("status_pembayaran = ?", "pending").
+		Where("id IN (?)", r.db.Model(&model.PembayaranTagihan{}).Select("pembayaran_id").Where("tagihan_id IN ?", tagihanIDs)).
+		Order("id asc").
+		Find(&payments).Error
+	return payments, err
+}
+
+func (r *paymentRepository) FindPendingBySiswaID(siswaID uint) ([]model.Pembayaran, error) {
+	var payments []model.Pembayaran
+	err := r.db.Preload("DetailTagihan").
+		Where("siswa_id = ? AND status_pembayaran = ?", siswaID, "pending").
+		Order("id asc").
+		Find(&payments).Error
+	return payments, err
+}
+
+func (r *paymentRepository) Update(payment *model.Pembayaran) error {
+	return r.db.Omit("TagihanSPP", "Siswa", "DetailTagihan").Save(payment).Error
+}
+
+func (r *paymentRepository) UpdateDetail(detail *model.PembayaranTagihan) error {
+	return r.db.Omit("TagihanSPP").Save(detail).Error
+}
@@ -1,17 +1,25 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/hiuncy/spp-payment-api/internal/model"
 	"gorm.io/gorm"
 )
 
 type PeriodRepository interface {
 	Create(period *model.PeriodeSPP) error
-	FindAll(tahunAjaran string) ([]model.PeriodeSPP, error)
+	FindAll(tahunAjaranID uint, tahunAjaran string) ([]model.PeriodeSPP, error)
 	FindByID(id uint) (*model.PeriodeSPP, error)
 	FindByTahunAjaranAndBulan(tahunAjaran string, bulan int) (*model.PeriodeSPP, error)
+	FindIDsByTahunAjaranAndBulan(tahunAjaran string, bulan []int) ([]uint, error)
 	Update(period *model.PeriodeSPP) error
 	Delete(id uint) error
+	RenameTahunAjaran(tahunAjaranID uint, nama string) error
+	FindDueForActivation(today time.Time) ([]model.PeriodeSPP, error)
+	FindDueForClosing(today time.Time) ([]model.PeriodeSPP, error)
+	UpdateStatus(id uint, status string) error
+	CountBills(id uint) (int64, error)
 }
 
 type periodRepository struct {
@@ -26,10 +34,13 @@ func (r *periodRepository) Create(period *model.PeriodeSPP) error {
 	return r.db.Create(period).Error
 }
 
-func (r *periodRepository) FindAll(tahunAjaran string) ([]model.PeriodeSPP, error) {
+func (r *periodRepository) FindAll(tahunAjaranID uint, tahunAjaran string) ([]model.PeriodeSPP, error) {
 	var periods []model.PeriodeSPP
 	query := r.db
 
+	if tahunAjaranID != 0 {
+		query = query.Where("tahun_ajaran_id = ?", tahunAjaranID)
+	}
 	if tahunAjaran != "" {
 		query = query.Where("tahun_ajaran = ?", tahunAjaran)
 	}
@@ -50,6 +61,12 @@ func (r *periodRepository) FindByTahunAjaranAndBulan(tahunAjaran string, bulan i
 	return &period, err
 }
 
+func (r *periodRepository) FindIDsByTahunAjaranAndBulan(tahunAjaran string, bulan []int) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&model.PeriodeSPP{}).Where("tahun_ajaran = ? AND bulan IN ?", tahunAjaran, bulan).Pluck("id", &ids).Error
+	return ids, err
+}
+
 func (r *periodRepository) Update(period *model.PeriodeSPP) error {
 	return r.db.Save(period).Error
 }
@@ -57,3 +74,33 @@ func (r *periodRepository) Update(period *model.PeriodeSPP) error {
 func (r *periodRepository) Delete(id uint) error {
 	return r.db.Where("id = ?", id).Delete(&model.PeriodeSPP{}).Error
 }
+
+func (r *periodRepository) RenameTahunAjaran(tahunAjaranID uint, nama string) error {
+	return r.db.Model(&model.PeriodeSPP{}).Where("tahun_ajaran_id = ?", tahunAjaranID).Update("tahun_ajaran", nama).Error
+}
+
+func (r *periodRepository) FindDueForActivation(today time.Time) ([]model.PeriodeSPP, error) {
+	var periods []model.PeriodeSPP
+	err := r.db.Where("status = ? AND tanggal_mulai <= ? AND tanggal_selesai >= ?", "belum_aktif", today, today).
+		Order("tanggal_mulai asc, id asc").
+		Find(&periods).Error
+	return periods, err
+}
+
+func (r *periodRepository) FindDueForClosing(today time.Time) ([]model.PeriodeSPP, error) {
+	var periods []model.PeriodeSPP
+	err := r.db.Where("status IN ? AND tanggal_selesai < ?", []string{"belum_aktif", "aktif"}, today).
+		Order("tanggal_selesai asc, id asc").
+		Find(&periods).Error
+	return periods, err
+}
+
+func (r *periodRepository) UpdateStatus(id uint, status string) error {
+	return r.db.Model(&model.PeriodeSPP{}).Where("id = ?", id).Update("status", status).Error
+}
+
+func (r *periodRepository) CountBills(id uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.TagihanSPP{}).Where("periode_id = ?", id).Count(&count).Error
+	return count, err
+}
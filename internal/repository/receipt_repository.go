@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReceiptRepository interface {
+	NextNumber(year int) (int, error)
+}
+
+type receiptRepository struct {
+	db *gorm.DB
+}
+
+func NewReceiptRepository(db *gorm.DB) ReceiptRepository {
+	return &receiptRepository{db}
+}
+
+func (r *receiptRepository) NextNumber(year int) (int, error) {
+	sequence := model.UrutanKuitansi{Tahun: year, NomorTerakhir: 1}
+	err := r.db.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{"nomor_terakhir": gorm.Expr("nomor_terakhir + 1")}),
+	}).Create(&sequence).Error
+	if err != nil {
+		return 0, err
+	}
+
+	if err := r.db.Where("tahun = ?", year).First(&sequence).Error; err != nil {
+		return 0, err
+	}
+	return sequence.NomorTerakhir, nil
+}
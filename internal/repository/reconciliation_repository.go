@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"gorm.io/gorm"
+)
+
+type ReconciliationRepository interface {
+	Create(run *model.RekonsiliasiPembayaran) error
+	FindAll(page, limit int) ([]model.RekonsiliasiPembayaran, int64, error)
+	FindByID(id uint) (*model.RekonsiliasiPembayaran, error)
+	Update(run *model.RekonsiliasiPembayaran) error
+}
+
+type reconciliationRepository struct {
+	db *gorm.DB
+}
+
+func NewReconciliationRepository(db *gorm.DB) ReconciliationRepository {
+	return &reconciliationRepository{db}
+}
+
+func (r *reconciliationRepository) Create(run *model.RekonsiliasiPembayaran) error {
+	return r.db.Create(run).Error
+}
+
+func (r *reconciliationRepository) FindAll(page, limit int) ([]model.RekonsiliasiPembayaran, int64, error) {
+	var runs []model.RekonsiliasiPembayaran
+	var total int64
+
+	query := r.db.Model(&model.RekonsiliasiPembayaran{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Limit(limit).Offset(offset).Order("id desc").Find(&runs).Error
+	return runs, total, err
+}
+
+func (r *reconciliationRepository) FindByID(id uint) (*model.RekonsiliasiPembayaran, error) {
+	var run model.RekonsiliasiPembayaran
+	err := r.db.Where("id = ?", id).First(&run).Error
+	return &run, err
+}
+
+func (r *reconciliationRepository) Update(run *model.RekonsiliasiPembayaran) error {
+	return r.db.Save(run).Error
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RefundRepository interface {
+	Create(refund *model.RefundPembayaran) error
+	FindAll(params utils.FindAllRefundsParams) ([]model.RefundPembayaran, int64, error)
+	FindByID(id uint) (*model.RefundPembayaran, error)
+	FindByIDForUpdate(id uint) (*model.RefundPembayaran, error)
+	SumByPembayaranID(pembayaranID uint, statuses []string) (float64, error)
+	Update(refund *model.RefundPembayaran) error
+}
+
+type refundRepository struct {
+	db *gorm.DB
+}
+
+func NewRefundRepository(db *gorm.DB) RefundRepository {
+	return &refundRepository{db}
+}
+
+func (r *refundRepository) Create(refund *model.RefundPembayaran) error {
+	return r.db.Omit(clause.Associations).Create(refund).Error
+}
+
+func (r *refundRepository) FindAll(params utils.FindAllRefundsParams) ([]model.RefundPembayaran, int64, error) {
+	var refunds []model.RefundPembayaran
+	var total int64
+
+	query := r.db.Model(&model.RefundPembayaran{})
+
+	if params.OrderID != "" {
+		query = query.Where("pembayaran_id IN (?)", r.db.Model(&model.Pembayaran{}).Select("id").Where("order_id = ?", params.OrderID))
+	}
+	if params.StatusRefund != "" {
+		query = query.Where("status_refund = ?", params.StatusRefund)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	err := query.Limit(params.Limit).Offset(offset).
+		Preload("Pembayaran.Siswa").
+		Order("id desc").
+		Find(&refunds).Error
+
+	return refunds, total, err
+}
+
+func (r *refundRepository) FindByID(id uint) (*model.RefundPembayaran, error) {
+	var refund model.RefundPembayaran
+	err := r.db.Preload("Pembayaran.Siswa").Where("id = ?", id).First(&refund).Error
+	return &refund, err
+}
+
+func (r *refundRepository) FindByIDForUpdate(id uint) (*model.RefundPembayaran, error) {
+	var refund model.RefundPembayaran
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&refund).Error
+	return &refund, err
+}
+
+func (r *refundRepository) SumByPembayaranID(pembayaranID uint, statuses []string) (float64, error) {
+	var total float64
+	err := r.db.Model(&model.RefundPembayaran{}).
+		Select("COALESCE(SUM(jumlah), 0)").
+		Where("pembayaran_id = ? AND status_refund IN ?", pembayaranID, statuses).
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *refundRepository) Update(refund *model.RefundPembayaran) error {
+	return r.db.Omit(clause.Associations).Save(refund).Error
+}
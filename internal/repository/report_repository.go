@@ -6,9 +6,10 @@ import (
 )
 
 type ReportRepository interface {
-	GetLaporanSiswa(tahunAjaran, nisn string) ([]model.LaporanSiswa, error)
-	GetLaporanKelas(tahunAjaran, namaBulan string) ([]model.LaporanKelas, error)
-	GetLaporanKeseluruhan(tahunAjaran string) ([]model.LaporanKeseluruhan, error)
+	GetLaporanSiswa(tahunAjaran, nisn, kodeBiaya string) ([]model.LaporanSiswa, error)
+	GetLaporanKelas(tahunAjaran, namaBulan, kodeBiaya string) ([]model.LaporanKelas, error)
+	GetLaporanKeseluruhan(tahunAjaran, kodeBiaya string) ([]model.LaporanKeseluruhan, error)
+	GetLaporanPotongan(tahunAjaran, namaBulan, kodeBiaya string) ([]model.LaporanPotongan, error)
 }
 
 type reportRepository struct {
@@ -19,7 +20,7 @@ func NewReportRepository(db *gorm.DB) ReportRepository {
 	return &reportRepository{db}
 }
 
-func (r *reportRepository) GetLaporanSiswa(tahunAjaran, nisn string) ([]model.LaporanSiswa, error) {
+func (r *reportRepository) GetLaporanSiswa(tahunAjaran, nisn, kodeBiaya string) ([]model.LaporanSiswa, error) {
 	var results []model.LaporanSiswa
 	query := r.db.Table("v_laporan_siswa")
 	if tahunAjaran != "" {
@@ -28,11 +29,14 @@ func (r *reportRepository) GetLaporanSiswa(tahunAjaran, nisn string) ([]model.La
 	if nisn != "" {
 		query = query.Where("nisn = ?", nisn)
 	}
+	if kodeBiaya != "" {
+		query = query.Where("kode_biaya = ?", kodeBiaya)
+	}
 	err := query.Find(&results).Error
 	return results, err
 }
 
-func (r *reportRepository) GetLaporanKelas(tahunAjaran, namaBulan string) ([]model.LaporanKelas, error) {
+func (r *reportRepository) GetLaporanKelas(tahunAjaran, namaBulan, kodeBiaya string) ([]model.LaporanKelas, error) {
 	var results []model.LaporanKelas
 	query := r.db.Table("v_laporan_kelas")
 	if tahunAjaran != "" {
@@ -41,16 +45,38 @@ func (r *reportRepository) GetLaporanKelas(tahunAjaran, namaBulan string) ([]mod
 	if namaBulan != "" {
 		query = query.Where("nama_bulan = ?", namaBulan)
 	}
+	if kodeBiaya != "" {
+		query = query.Where("kode_biaya = ?", kodeBiaya)
+	}
 	err := query.Find(&results).Error
 	return results, err
 }
 
-func (r *reportRepository) GetLaporanKeseluruhan(tahunAjaran string) ([]model.LaporanKeseluruhan, error) {
+func (r *reportRepository) GetLaporanKeseluruhan(tahunAjaran, kodeBiaya string) ([]model.LaporanKeseluruhan, error) {
 	var results []model.LaporanKeseluruhan
 	query := r.db.Table("v_laporan_keseluruhan")
 	if tahunAjaran != "" {
 		query = query.Where("tahun_ajaran = ?", tahunAjaran)
 	}
+	if kodeBiaya != "" {
+		query = query.Where("kode_biaya = ?", kodeBiaya)
+	}
+	err := query.Find(&results).Error
+	return results, err
+}
+
+func (r *reportRepository) GetLaporanPotongan(tahunAjaran, namaBulan, kodeBiaya string) ([]model.LaporanPotongan, error) {
+	var results []model.LaporanPotongan
+	query := r.db.Table("v_laporan_potongan")
+	if tahunAjaran != "" {
+		query = query.Where("tahun_ajaran = ?", tahunAjaran)
+	}
+	if namaBulan != "" {
+		query = query.Where("nama_bulan = ?", namaBulan)
+	}
+	if kodeBiaya != "" {
+		query = query.Where("kode_biaya = ?", kodeBiaya)
+	}
 	err := query.Find(&results).Error
 	return results, err
 }
@@ -4,12 +4,14 @@ import (
 	"github.com/hiuncy/spp-payment-api/internal/model"
 	"github.com/hiuncy/spp-payment-api/internal/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type StudentRepository interface {
 	Create(student *model.Siswa) error
 	FindAll(params utils.FindAllStudentsParams) ([]model.Siswa, int64, error)
 	FindByID(id uint) (*model.Siswa, error)
+	FindByIDForUpdate(id uint) (*model.Siswa, error)
 	FindByNISN(nisn string) (*model.Siswa, error)
 	Update(student *model.Siswa) error
 	Delete(id uint) error
@@ -65,6 +67,12 @@ func (r *studentRepository) FindByID(id uint) (*model.Siswa, error) {
 	return &student, err
 }
 
+func (r *studentRepository) FindByIDForUpdate(id uint) (*model.Siswa, error) {
+	var student model.Siswa
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&student).Error
+	return &student, err
+}
+
 func (r *studentRepository) FindByNISN(nisn string) (*model.Siswa, error) {
 	var student model.Siswa
 	err := r.db.Where("nisn = ?", nisn).First(&student).Error
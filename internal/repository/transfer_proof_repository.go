@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TransferProofRepository interface {
+	Create(proof *model.BuktiTransfer) error
+	FindAll(params utils.FindAllTransferProofsParams) ([]model.BuktiTransfer, int64, error)
+	FindByID(id uint) (*model.BuktiTransfer, error)
+	FindByIDForUpdate(id uint) (*model.BuktiTransfer, error)
+	Update(proof *model.BuktiTransfer) error
+}
+
+type transferProofRepository struct {
+	db *gorm.DB
+}
+
+func NewTransferProofRepository(db *gorm.DB) TransferProofRepository {
+	return &transferProofRepository{db}
+}
+
+func (r *transferProofRepository) Create(proof *model.BuktiTransfer) error {
+	return r.db.Omit(clause.Associations).Create(proof).Error
+}
+
+func (r *transferProofRepository) FindAll(params utils.FindAllTransferProofsParams) ([]model.BuktiTransfer, int64, error) {
+	var proofs []model.BuktiTransfer
+	var total int64
+
+	query := r.db.Model(&model.BuktiTransfer{})
+
+	if params.SiswaID != 0 {
+		query = query.Where("siswa_id = ?", params.SiswaID)
+	}
+	if params.StatusVerifikasi != "" {
+		query = query.Where("status_verifikasi = ?", params.StatusVerifikasi)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	err := query.Limit(params.Limit).Offset(offset).
+		Preload("Pembayaran").
+		Preload("TagihanSPP.PeriodeSPP").
+		Preload("Siswa").
+		Order("id asc").
+		Find(&proofs).Error
+
+	return proofs, total, err
+}
+
+func (r *transferProofRepository) FindByID(id uint) (*model.BuktiTransfer, error) {
+	var proof model.BuktiTransfer
+	err := r.db.Preload("Pembayaran").
+		Preload("TagihanSPP.PeriodeSPP").
+		Preload("Siswa").
+		Where("id = ?", id).
+		First(&proof).Error
+	return &proof, err
+}
+
+func (r *transferProofRepository) FindByIDForUpdate(id uint) (*model.BuktiTransfer, error) {
+	var proof model.BuktiTransfer
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&proof).Error
+	return &proof, err
+}
+
+func (r *transferProofRepository) Update(proof *model.BuktiTransfer) error {
+	return r.db.Omit(clause.Associations).Save(proof).Error
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/dto"
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	SettingPeriodMonths = "periode_bulan"
+	SettingPeriodDueDay = "periode_tanggal_jatuh_tempo"
+)
+
+var (
+	ErrAcademicYearExists  = errors.New("tahun ajaran sudah ada")
+	ErrAcademicYearInUse   = errors.New("tahun ajaran masih memiliki periode dan tidak dapat dihapus")
+	ErrInvalidAcademicYear = errors.New("tahun ajaran tidak valid")
+)
+
+type AcademicYearService interface {
+	CreateAcademicYear(input dto.AcademicYearInput) (*model.TahunAjaran, error)
+	FindAllAcademicYears() ([]model.TahunAjaran, error)
+	FindAcademicYearByID(id uint) (*model.TahunAjaran, error)
+	FindActiveAcademicYear() (*model.TahunAjaran, error)
+	UpdateAcademicYear(id uint, input dto.AcademicYearInput) (*model.TahunAjaran, error)
+	DeleteAcademicYear(id uint) error
+	GeneratePeriods(id uint, input dto.GeneratePeriodsInput) (*PeriodGenerationResult, error)
+}
+
+type PeriodGenerationResult struct {
+	JumlahDibuat   int                `json:"jumlah_dibuat"`
+	JumlahDilewati int                `json:"jumlah_dilewati"`
+	Periode        []model.PeriodeSPP `json:"periode"`
+}
+
+type academicYearService struct {
+	repo           repository.AcademicYearRepository
+	settingService SettingService
+	db             *gorm.DB
+}
+
+func NewAcademicYearService(repo repository.AcademicYearRepository, settingService SettingService, db *gorm.DB) AcademicYearService {
+	return &academicYearService{repo, settingService, db}
+}
+
+func (s *academicYearService) CreateAcademicYear(input dto.AcademicYearInput) (*model.TahunAjaran, error) {
+	academicYear := &model.TahunAjaran{}
+	if err := applyAcademicYearInput(academicYear, input); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.FindByNama(academicYear.NamaTahunAjaran); err == nil {
+		return nil, ErrAcademicYearExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repoTx := repository.NewAcademicYearRepository(tx)
+		if err := repoTx.Create(academicYear); err != nil {
+			return err
+		}
+		if academicYear.Aktif {
+			return repoTx.DeactivateOthers(academicYear.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return academicYear, nil
+}
+
+func (s *academicYearService) FindAllAcademicYears() ([]model.TahunAjaran, error) {
+	return s.repo.FindAll()
+}
+
+func (s *academicYearService) FindAcademicYearByID(id uint) (*model.TahunAjaran, error) {
+	return s.repo.FindByID(id)
+}
+
+func (s *academicYearService) FindActiveAcademicYear() (*model.TahunAjaran, error) {
+	return s.repo.FindActive()
+}
+
+func (s *academicYearService) UpdateAcademicYear(id uint, input dto.AcademicYearInput) (*model.TahunAjaran, error) {
+	academicYear, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyAcademicYearInput(academicYear, input); err != nil {
+		return nil, err
+	}
+	if existing, err := s.repo.FindByNama(academicYear.NamaTahunAjaran); err == nil && existing.ID != academicYear.ID {
+		return nil, ErrAcademicYearExists
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repoTx := repository.NewAcademicYearRepository(tx)
+		if err := repoTx.Update(academicYear); err != nil {
+			return err
+		}
+		if err := repository.NewPeriodRepository(tx).RenameTahunAjaran(academicYear.ID, academicYear.NamaTahunAjaran); err != nil {
+			return err
+		}
+		if academicYear.Aktif {
+			return repoTx.DeactivateOthers(academicYear.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return academicYear, nil
+}
+
+func (s *academicYearService) DeleteAcademicYear(id uint) error {
+	if _, err := s.repo.FindByID(id); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return ErrAcademicYearInUse
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *academicYearService) GeneratePeriods(id uint, input dto.GeneratePeriodsInput) (*PeriodGenerationResult, error) {
+	academicYear, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	months, dueDay, err := s.periodRules(input)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PeriodGenerationResult{Periode: []model.PeriodeSPP{}}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		periodRepo := repository.NewPeriodRepository(tx)
+		start := academicYear.TanggalMulai
+		for month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, start.Location()); !month.After(academicYear.TanggalSelesai); month = month.AddDate(0, 1, 0) {
+			bulan := int(month.Month())
+			if len(months) > 0 && !slices.Contains(months, bulan) {
+				continue
+			}
+			if _, err := periodRepo.FindByTahunAjaranAndBulan(academicYear.NamaTahunAjaran, bulan); err == nil {
+				result.JumlahDilewati++
+				continue
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+
+			period := model.PeriodeSPP{
+				TahunAjaranID:     academicYear.ID,
+				TahunAjaran:       academicYear.NamaTahunAjaran,
+				Bulan:             bulan,
+				NamaBulan:         monthName(bulan),
+				TanggalMulai:      month,
+				TanggalSelesai:    periodDueDate(month, 0),
+				TanggalJatuhTempo: periodDueDate(month, dueDay),
+				Status:            PeriodStatusBelumAktif,
+			}
+			if period.TanggalMulai.Before(academicYear.TanggalMulai) {
+				period.TanggalMulai = academicYear.TanggalMulai
+			}
+			if period.TanggalSelesai.After(academicYear.TanggalSelesai) {
+				period.TanggalSelesai = academicYear.TanggalSelesai
+			}
+			if period.TanggalJatuhTempo.Before(period.TanggalMulai) || period.TanggalJatuhTempo.After(period.TanggalSelesai) {
+				period.TanggalJatuhTempo = period.TanggalSelesai
+			}
+			if err := periodRepo.Create(&period); err != nil {
+				return err
+			}
+			result.Periode = append(result.Periode, period)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.JumlahDibuat = len(result.Periode)
+	return result, nil
+}
+
+func (s *academicYearService) periodRules(input dto.GeneratePeriodsInput) ([]int, int, error) {
+	values, err := s.settingService.GetSettingValues()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	months := input.Bulan
+	if len(months) == 0 {
+		if months, err = periodMonths(values); err != nil {
+			return nil, 0, err
+		}
+	}
+	for _, month := range months {
+		if month < 1 || month > 12 {
+			return nil, 0, fmt.Errorf("%w: bulan harus di antara 1 dan 12", ErrInvalidAcademicYear)
+		}
+	}
+
+	dueDay := input.TanggalJatuhTempo
+	if dueDay == 0 {
+		dueDay, _ = strconv.Atoi(strings.TrimSpace(values[SettingPeriodDueDay]))
+	}
+	if dueDay < 0 || dueDay > 31 {
+		return nil, 0, fmt.Errorf("%w: tanggal jatuh tempo harus di antara 1 dan 31", ErrInvalidAcademicYear)
+	}
+	return months, dueDay, nil
+}
+
+func periodMonths(values map[string]string) ([]int, error) {
+	var months []int
+	for _, raw := range strings.Split(values[SettingPeriodMonths], ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		month, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("pengaturan %s tidak valid", SettingPeriodMonths)
+		}
+		months = append(months, month)
+	}
+	return months, nil
+}
+
+func applyAcademicYearInput(academicYear *model.TahunAjaran, input dto.AcademicYearInput) error {
+	name := strings.TrimSpace(input.NamaTahunAjaran)
+	if name == "" {
+		return fmt.Errorf("%w: nama tahun ajaran wajib diisi", ErrInvalidAcademicYear)
+	}
+	start, err := time.Parse("2006-01-02", input.TanggalMulai)
+	if err != nil {
+		return fmt.Errorf("%w: format tanggal mulai harus YYYY-MM-DD", ErrInvalidAcademicYear)
+	}
+	end, err := time.Parse("2006-01-02", input.TanggalSelesai)
+	if err != nil {
+		return fmt.Errorf("%w: format tanggal selesai harus YYYY-MM-DD", ErrInvalidAcademicYear)
+	}
+	if !end.After(start) {
+		return fmt.Errorf("%w: tanggal selesai harus setelah tanggal mulai", ErrInvalidAcademicYear)
+	}
+	if !end.Before(start.AddDate(1, 0, 0)) {
+		return fmt.Errorf("%w: tahun ajaran tidak boleh lebih dari 12 bulan", ErrInvalidAcademicYear)
+	}
+
+	academicYear.NamaTahunAjaran = name
+	academicYear.TanggalMulai = start
+	academicYear.TanggalSelesai = end
+	academicYear.Aktif = input.Aktif
+	return nil
+}
+
+func periodDueDate(month time.Time, day int) time.Time {
+	last := month.AddDate(0, 1, -1)
+	if day <= 0 || day > last.Day() {
+		return last
+	}
+	return time.Date(month.Year(), month.Month(), day, 0, 0, 0, 0, month.Location())
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/dto"
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/repository"
+)
+
+func TestGeneratePeriodsKeepsPeriodOpenAfterDueDate(t *testing.T) {
+	db := openTestDB(t)
+	year := &model.TahunAjaran{
+		NamaTahunAjaran: fmt.Sprintf("GEN-%d", time.Now().UnixNano()%100000000000),
+		TanggalMulai:    time.Date(2030, time.July, 1, 0, 0, 0, 0, time.Local),
+		TanggalSelesai:  time.Date(2030, time.August, 31, 0, 0, 0, 0, time.Local),
+	}
+	if err := db.Create(year).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM periode_spp WHERE tahun_ajaran_id = ?", year.ID)
+		db.Delete(year)
+	})
+
+	academicYearService := NewAcademicYearService(repository.NewAcademicYearRepository(db), NewSettingService(repository.NewSettingRepository(db), db), db)
+	result, err := academicYearService.GeneratePeriods(year.ID, dto.GeneratePeriodsInput{Bulan: []int{7}, TanggalJatuhTempo: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Periode) != 1 {
+		t.Fatalf("got %d periods, want 1", len(result.Periode))
+	}
+	period := result.Periode[0]
+	if got := period.TanggalSelesai.Format("2006-01-02"); got != "2030-07-31" {
+		t.Fatalf("tanggal selesai = %s, want 2030-07-31", got)
+	}
+	if got := period.TanggalJatuhTempo.Format("2006-01-02"); got != "2030-07-10" {
+		t.Fatalf("tanggal jatuh tempo = %s, want 2030-07-10", got)
+	}
+
+	closing, err := repository.NewPeriodRepository(db).FindDueForClosing(time.Date(2030, time.July, 11, 0, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, due := range closing {
+		if due.ID == period.ID {
+			t.Fatal("period is closed the day after its due date")
+		}
+	}
+}
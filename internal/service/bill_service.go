@@ -1,30 +1,271 @@
 package service
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/hiuncy/spp-payment-api/internal/dto"
 	"github.com/hiuncy/spp-payment-api/internal/model"
 	"github.com/hiuncy/spp-payment-api/internal/repository"
 	"github.com/hiuncy/spp-payment-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidInstallmentPlan = errors.New("rencana cicilan tidak valid")
+	ErrBillAlreadyPaid        = errors.New("tagihan sudah lunas")
+	ErrInvalidBillAmount      = errors.New("jumlah tagihan tidak valid")
+	ErrBillWaived             = errors.New("tagihan sudah dibebaskan")
+	ErrBillInUse              = errors.New("tagihan sudah memiliki pembayaran dan tidak dapat dihapus")
+	ErrInvalidWaiver          = errors.New("pembebasan tagihan tidak valid")
 )
 
+type BillGenerationResult struct {
+	DryRun           bool                    `json:"dry_run"`
+	PeriodeID        uint                    `json:"periode_id"`
+	JenisBiayaID     uint                    `json:"jenis_biaya_id"`
+	JumlahDibuat     int                     `json:"jumlah_dibuat"`
+	JumlahDilewati   int                     `json:"jumlah_dilewati"`
+	JumlahDibebaskan int                     `json:"jumlah_dibebaskan"`
+	JumlahBelumMasuk int                     `json:"jumlah_belum_masuk"`
+	JumlahGagal      int                     `json:"jumlah_gagal"`
+	TotalProrata     float64                 `json:"total_prorata"`
+	TotalAwal        float64                 `json:"total_awal"`
+	TotalPotongan    float64                 `json:"total_potongan"`
+	TotalTagihan     float64                 `json:"total_tagihan"`
+	PerKelas         []BillGenerationClass   `json:"per_kelas"`
+	Gagal            []BillGenerationFailure `json:"gagal"`
+}
+
+type BillGenerationClass struct {
+	KelasID          uint    `json:"kelas_id"`
+	NamaKelas        string  `json:"nama_kelas"`
+	JumlahDibuat     int     `json:"jumlah_dibuat"`
+	JumlahDilewati   int     `json:"jumlah_dilewati"`
+	JumlahDibebaskan int     `json:"jumlah_dibebaskan"`
+	JumlahBelumMasuk int     `json:"jumlah_belum_masuk"`
+	TotalProrata     float64 `json:"total_prorata"`
+	TotalAwal        float64 `json:"total_awal"`
+	TotalPotongan    float64 `json:"total_potongan"`
+	TotalTagihan     float64 `json:"total_tagihan"`
+}
+
+type BillGenerationFailure struct {
+	SiswaID   uint   `json:"siswa_id"`
+	NamaSiswa string `json:"nama_siswa"`
+	Alasan    string `json:"alasan"`
+}
+
+type BillWaiverResult struct {
+	JumlahDibebaskan int                 `json:"jumlah_dibebaskan"`
+	JumlahDilewati   int                 `json:"jumlah_dilewati"`
+	TotalDibebaskan  float64             `json:"total_dibebaskan"`
+	Dilewati         []BillWaiverSkipped `json:"dilewati"`
+}
+
+type BillWaiverSkipped struct {
+	TagihanID uint   `json:"tagihan_id"`
+	SiswaID   uint   `json:"siswa_id"`
+	NamaSiswa string `json:"nama_siswa"`
+	Alasan    string `json:"alasan"`
+}
+
 type BillService interface {
-	GenerateBillsForPeriod(periodID uint) error
+	GenerateBillsForPeriod(periodID uint, input dto.GenerateBillsInput) (*BillGenerationResult, error)
 	FindAllBills(input dto.FindAllBillsInput) ([]model.TagihanSPP, int64, error)
+	FindStudentBills(siswaID uint, status string) ([]model.TagihanSPP, error)
 	FindBillByID(id uint) (*model.TagihanSPP, error)
 	UpdateBill(id uint, input dto.UpdateBillInput) (*model.TagihanSPP, error)
 	DeleteBill(id uint) error
+	SetInstallments(id uint, input []dto.InstallmentInput) (*model.TagihanSPP, error)
+	DeleteInstallments(id uint) (*model.TagihanSPP, error)
+	WaiveBills(input dto.WaiveBillsInput) (*BillWaiverResult, error)
 }
 
 type billService struct {
-	repo repository.BillRepository
+	repo           repository.BillRepository
+	feeTypeRepo    repository.FeeTypeRepository
+	settingService SettingService
+	db             *gorm.DB
+}
+
+func NewBillService(repo repository.BillRepository, feeTypeRepo repository.FeeTypeRepository, settingService SettingService, db *gorm.DB) BillService {
+	return &billService{repo, feeTypeRepo, settingService, db}
+}
+
+func (s *billService) GenerateBillsForPeriod(periodID uint, input dto.GenerateBillsInput) (*BillGenerationResult, error) {
+	feeType, err := s.generationFeeType(input.JenisBiayaID)
+	if err != nil {
+		return nil, err
+	}
+	if input.DryRun {
+		result, _, err := s.planBills(s.db, periodID, feeType, input)
+		return result, err
+	}
+
+	var result *BillGenerationResult
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var bills []model.TagihanSPP
+		var err error
+		result, bills, err = s.planBills(tx, periodID, feeType, input)
+		if err != nil {
+			return err
+		}
+		return createGeneratedBills(tx, bills)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *billService) planBills(db *gorm.DB, periodID uint, feeType *model.JenisBiaya, input dto.GenerateBillsInput) (*BillGenerationResult, []model.TagihanSPP, error) {
+	siblingRules, err := loadSiblingDiscountRules(s.settingService)
+	if err != nil {
+		return nil, nil, err
+	}
+	prorationPolicy := ProrationNone
+	if feeType.Periodisitas == FeeRecurrenceMonthly {
+		if prorationPolicy, err = loadProrationPolicy(s.settingService); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	period, err := repository.NewPeriodRepository(db).FindByID(periodID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if period.Status == PeriodStatusSelesai {
+		return nil, nil, ErrPeriodClosed
+	}
+	students, err := repository.NewBillRepository(db).FindStudentsForGeneration(utils.GenerateBillsParams{
+		KelasIDs:   input.KelasIDs,
+		TingkatIDs: input.TingkatIDs,
+		SiswaIDs:   input.SiswaIDs,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	billed, err := billedStudents(db, feeType, period, students)
+	if err != nil {
+		return nil, nil, err
+	}
+	exemptions, err := repository.NewExemptionRepository(db).FindByPeriode(period.ID, feeType.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	rates, err := effectiveRates(repository.NewFeeScheduleRepository(db), feeType, period.TahunAjaranID, period.TanggalMulai)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := &BillGenerationResult{
+		DryRun:       input.DryRun,
+		PeriodeID:    periodID,
+		JenisBiayaID: feeType.ID,
+		PerKelas:     []BillGenerationClass{},
+		Gagal:        []BillGenerationFailure{},
+	}
+	classIndex := make(map[uint]int)
+	studentClass := make(map[uint]int, len(students))
+	bills := make([]model.TagihanSPP, 0, len(students))
+	for _, student := range students {
+		i, ok := classIndex[student.KelasID]
+		if !ok {
+			i = len(result.PerKelas)
+			classIndex[student.KelasID] = i
+			result.PerKelas = append(result.PerKelas, BillGenerationClass{KelasID: student.KelasID, NamaKelas: student.Kelas.NamaKelas})
+		}
+		studentClass[student.ID] = i
+
+		if billed[student.ID] {
+			result.JumlahDilewati++
+			result.PerKelas[i].JumlahDilewati++
+			continue
+		}
+		if exempted(exemptions, &student, feeType.ID) {
+			result.JumlahDibebaskan++
+			result.PerKelas[i].JumlahDibebaskan++
+			continue
+		}
+		if enrolledAfter(&student, period) {
+			result.JumlahBelumMasuk++
+			result.PerKelas[i].JumlahBelumMasuk++
+			continue
+		}
+		amount, ok := rates[student.Kelas.TingkatID]
+		if !ok && feeType.KodeBiaya == FeeTypeCodeSPP && student.Kelas.TingkatKelas.BiayaSPP > 0 {
+			amount, ok = student.Kelas.TingkatKelas.BiayaSPP, true
+		}
+		if !ok {
+			result.Gagal = append(result.Gagal, BillGenerationFailure{
+				SiswaID:   student.ID,
+				NamaSiswa: student.NamaLengkap,
+				Alasan:    fmt.Sprintf("tarif %s untuk %s belum diatur", feeType.NamaBiaya, student.Kelas.TingkatKelas.NamaTingkat),
+			})
+			continue
+		}
+		prorated, note := prorate(amount, &student, period, prorationPolicy)
+		bills = append(bills, model.TagihanSPP{
+			SiswaID:           student.ID,
+			PeriodeID:         period.ID,
+			JenisBiayaID:      feeType.ID,
+			JumlahAwal:        prorated,
+			JumlahProrata:     amount - prorated,
+			KeteranganProrata: note,
+			JumlahTagihan:     prorated,
+			StatusPembayaran:  BillStatusBelumBayar,
+			TanggalJatuhTempo: period.TanggalJatuhTempo,
+		})
+	}
+
+	if err := calculateDiscounts(db, bills, period, feeType.ID, siblingRules); err != nil {
+		return nil, nil, err
+	}
+	for _, bill := range bills {
+		summary := &result.PerKelas[studentClass[bill.SiswaID]]
+		summary.JumlahDibuat++
+		summary.TotalProrata += bill.JumlahProrata
+		summary.TotalAwal += bill.JumlahAwal
+		summary.TotalPotongan += bill.JumlahPotongan
+		summary.TotalTagihan += bill.JumlahTagihan
+		result.TotalProrata += bill.JumlahProrata
+		result.TotalAwal += bill.JumlahAwal
+		result.TotalPotongan += bill.JumlahPotongan
+		result.TotalTagihan += bill.JumlahTagihan
+	}
+	result.JumlahDibuat = len(bills)
+	result.JumlahGagal = len(result.Gagal)
+	return result, bills, nil
 }
 
-func NewBillService(repo repository.BillRepository) BillService {
-	return &billService{repo}
+func createGeneratedBills(tx *gorm.DB, bills []model.TagihanSPP) error {
+	if err := repository.NewBillRepository(tx).CreateBills(bills); err != nil {
+		return err
+	}
+
+	var discounts []model.PotonganTagihan
+	for _, bill := range bills {
+		for _, discount := range bill.Potongan {
+			discount.TagihanID = bill.ID
+			discounts = append(discounts, discount)
+		}
+	}
+	return repository.NewDiscountRepository(tx).CreateBillDiscounts(discounts)
 }
 
-func (s *billService) GenerateBillsForPeriod(periodID uint) error {
-	return s.repo.GenerateBills(periodID)
+func (s *billService) generationFeeType(id uint) (*model.JenisBiaya, error) {
+	feeType, err := findFeeType(s.feeTypeRepo, id)
+	if err != nil {
+		return nil, err
+	}
+	if feeType.Status != "aktif" {
+		return nil, fmt.Errorf("%w: jenis biaya %s tidak aktif", ErrInvalidFeeType, feeType.NamaBiaya)
+	}
+	return feeType, nil
 }
 
 func (s *billService) FindAllBills(input dto.FindAllBillsInput) ([]model.TagihanSPP, int64, error) {
@@ -40,31 +281,295 @@ func (s *billService) FindAllBills(input dto.FindAllBillsInput) ([]model.Tagihan
 		PeriodeID:        input.PeriodeID,
 		SiswaID:          input.SiswaID,
 		StatusPembayaran: input.StatusPembayaran,
+		JenisBiayaID:     input.JenisBiayaID,
 	}
 	return s.repo.FindAll(params)
 }
 
+func (s *billService) FindStudentBills(siswaID uint, status string) ([]model.TagihanSPP, error) {
+	return s.repo.FindAllBySiswaID(siswaID, status)
+}
+
 func (s *billService) FindBillByID(id uint) (*model.TagihanSPP, error) {
 	return s.repo.FindByID(id)
 }
 
 func (s *billService) UpdateBill(id uint, input dto.UpdateBillInput) (*model.TagihanSPP, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		billRepoTx := repository.NewBillRepository(tx)
+		bill, err := billRepoTx.FindByIDForUpdate(id)
+		if err != nil {
+			return err
+		}
+		if bill.StatusPembayaran == BillStatusDibebaskan {
+			return ErrBillWaived
+		}
+		pendings, err := repository.NewPaymentRepository(tx).FindPendingByTagihanIDs([]uint{bill.ID})
+		if err != nil {
+			return err
+		}
+		if len(pendings) > 0 {
+			return ErrPendingPaymentExists
+		}
+		if toCents(input.JumlahTagihan+bill.JumlahDenda) < toCents(bill.JumlahTerbayar) {
+			return fmt.Errorf("%w: tidak boleh lebih kecil dari jumlah yang sudah dibayar (%.0f)", ErrInvalidBillAmount, bill.JumlahTerbayar)
+		}
+		if len(bill.Cicilan) > 0 && toCents(input.JumlahTagihan) != toCents(bill.JumlahTagihan) {
+			return fmt.Errorf("%w: hapus rencana cicilan sebelum mengubah jumlah tagihan", ErrInvalidInstallmentPlan)
+		}
+		bill.JumlahTagihan = input.JumlahTagihan
+		bill.JumlahAwal = input.JumlahTagihan + bill.JumlahPotongan
+		bill.StatusPembayaran = billStatusFor(billTotal(bill), bill.JumlahTerbayar, false)
+		return billRepoTx.Update(bill)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(id)
+}
+
+func (s *billService) DeleteBill(id uint) error {
 	bill, err := s.repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if toCents(bill.JumlahTerbayar) > 0 {
+		return ErrBillInUse
+	}
+	count, err := s.repo.CountPayments(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrBillInUse
+	}
+	if err := s.repo.Delete(id); err != nil {
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return ErrBillInUse
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *billService) SetInstallments(id uint, input []dto.InstallmentInput) (*model.TagihanSPP, error) {
+	if len(input) < 2 {
+		return nil, fmt.Errorf("%w: minimal terdiri dari 2 cicilan", ErrInvalidInstallmentPlan)
+	}
+
+	installments := make([]model.CicilanTagihan, 0, len(input))
+	var total float64
+	for _, item := range input {
+		dueDate, err := time.Parse("2006-01-02", item.TanggalJatuhTempo)
+		if err != nil {
+			return nil, fmt.Errorf("%w: format tanggal jatuh tempo harus YYYY-MM-DD", ErrInvalidInstallmentPlan)
+		}
+		if item.Jumlah <= 0 {
+			return nil, fmt.Errorf("%w: jumlah cicilan harus lebih dari 0", ErrInvalidInstallmentPlan)
+		}
+		total += item.Jumlah
+		installments = append(installments, model.CicilanTagihan{
+			TagihanID:         id,
+			Jumlah:            item.Jumlah,
+			TanggalJatuhTempo: dueDate,
+			StatusPembayaran:  BillStatusBelumBayar,
+		})
+	}
+	sort.SliceStable(installments, func(i, j int) bool {
+		return installments[i].TanggalJatuhTempo.Before(installments[j].TanggalJatuhTempo)
+	})
+	for i := range installments {
+		installments[i].Urutan = i + 1
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repoTx := repository.NewBillRepository(tx)
+		bill, err := repoTx.FindByIDForUpdate(id)
+		if err != nil {
+			return err
+		}
+		if bill.StatusPembayaran == BillStatusLunas {
+			return ErrBillAlreadyPaid
+		}
+		if bill.StatusPembayaran == BillStatusDibebaskan {
+			return ErrBillWaived
+		}
+		if toCents(total) != toCents(bill.JumlahTagihan) {
+			return fmt.Errorf("%w: total cicilan harus sama dengan jumlah tagihan (%.0f)", ErrInvalidInstallmentPlan, bill.JumlahTagihan)
+		}
+
+		if err := repoTx.ReplaceInstallments(id, installments); err != nil {
+			return err
+		}
+		bill.Cicilan = installments
+		return allocateInstallments(repoTx, bill)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(id)
+}
+
+func (s *billService) DeleteInstallments(id uint) (*model.TagihanSPP, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repoTx := repository.NewBillRepository(tx)
+		if _, err := repoTx.FindByIDForUpdate(id); err != nil {
+			return err
+		}
+		return repoTx.ReplaceInstallments(id, nil)
+	})
 	if err != nil {
 		return nil, err
 	}
-	bill.JumlahTagihan = input.JumlahTagihan
-	bill.StatusPembayaran = input.StatusPembayaran
-	if err := s.repo.Update(bill); err != nil {
+	return s.repo.FindByID(id)
+}
+
+func (s *billService) WaiveBills(input dto.WaiveBillsInput) (*BillWaiverResult, error) {
+	if len(input.TagihanIDs) == 0 && input.PeriodeID == 0 {
+		return nil, fmt.Errorf("%w: pilih tagihan atau periode yang akan dibebaskan", ErrInvalidWaiver)
+	}
+	reason := strings.TrimSpace(input.Alasan)
+	if reason == "" {
+		return nil, fmt.Errorf("%w: alasan pembebasan wajib diisi", ErrInvalidWaiver)
+	}
+
+	result := &BillWaiverResult{Dilewati: []BillWaiverSkipped{}}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repoTx := repository.NewBillRepository(tx)
+		bills, err := repoTx.FindForWaiver(utils.WaiveBillsParams{
+			TagihanIDs:   input.TagihanIDs,
+			PeriodeID:    input.PeriodeID,
+			JenisBiayaID: input.JenisBiayaID,
+			KelasIDs:     input.KelasIDs,
+			TingkatIDs:   input.TingkatIDs,
+			SiswaIDs:     input.SiswaIDs,
+		})
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for i := range bills {
+			bill := &bills[i]
+			if skip := waiverBlocker(bill); skip != "" {
+				result.Dilewati = append(result.Dilewati, BillWaiverSkipped{
+					TagihanID: bill.ID,
+					SiswaID:   bill.SiswaID,
+					NamaSiswa: bill.Siswa.NamaLengkap,
+					Alasan:    skip,
+				})
+				continue
+			}
+			bill.StatusPembayaran = BillStatusDibebaskan
+			bill.DibebaskanOleh = &input.DibebaskanOleh
+			bill.AlasanPembebasan = &reason
+			bill.TanggalDibebaskan = &now
+			if err := repoTx.Update(bill); err != nil {
+				return err
+			}
+			result.JumlahDibebaskan++
+			result.TotalDibebaskan += billTotal(bill)
+		}
+		result.JumlahDilewati = len(result.Dilewati)
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-	return bill, nil
+	return result, nil
 }
 
-func (s *billService) DeleteBill(id uint) error {
-	_, err := s.repo.FindByID(id)
+func waiverBlocker(bill *model.TagihanSPP) string {
+	switch {
+	case bill.StatusPembayaran == BillStatusDibebaskan:
+		return "tagihan sudah dibebaskan"
+	case bill.StatusPembayaran == BillStatusLunas:
+		return "tagihan sudah lunas"
+	case bill.StatusPembayaran == BillStatusPending:
+		return "tagihan memiliki pembayaran yang masih pending"
+	case toCents(bill.JumlahTerbayar) > 0:
+		return "tagihan sudah dibayar sebagian"
+	}
+	return ""
+}
+
+func exempted(exemptions []model.PembebasanTagihan, student *model.Siswa, feeTypeID uint) bool {
+	for _, exemption := range exemptions {
+		if exemption.JenisBiayaID != nil && *exemption.JenisBiayaID != feeTypeID {
+			continue
+		}
+		switch {
+		case exemption.SiswaID != nil:
+			if *exemption.SiswaID == student.ID {
+				return true
+			}
+		case exemption.KelasID != nil:
+			if *exemption.KelasID == student.KelasID {
+				return true
+			}
+		case exemption.TingkatID != nil:
+			if *exemption.TingkatID == student.Kelas.TingkatID {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+func billedStudents(tx *gorm.DB, feeType *model.JenisBiaya, period *model.PeriodeSPP, students []model.Siswa) (map[uint]bool, error) {
+	siswaIDs := make([]uint, 0, len(students))
+	for _, student := range students {
+		siswaIDs = append(siswaIDs, student.ID)
+	}
+
+	periodIDs := []uint{period.ID}
+	switch feeType.Periodisitas {
+	case FeeRecurrenceOnce:
+		periodIDs = nil
+	case FeeRecurrenceSemester:
+		months := []int{1, 2, 3, 4, 5, 6}
+		if period.Bulan >= 7 {
+			months = []int{7, 8, 9, 10, 11, 12}
+		}
+		var err error
+		periodIDs, err = repository.NewPeriodRepository(tx).FindIDsByTahunAjaranAndBulan(period.TahunAjaran, months)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ids, err := repository.NewBillRepository(tx).FindBilledSiswaIDs(feeType.ID, periodIDs, siswaIDs)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	billed := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		billed[id] = true
+	}
+	return billed, nil
+}
+
+func allocateInstallments(repo repository.BillRepository, bill *model.TagihanSPP) error {
+	remaining := bill.JumlahTerbayar
+	for i := range bill.Cicilan {
+		installment := &bill.Cicilan[i]
+		paid := installment.Jumlah
+		if toCents(remaining) < toCents(paid) {
+			paid = remaining
+		}
+		remaining -= paid
+
+		status := billStatusFor(installment.Jumlah, paid, false)
+		if toCents(paid) == toCents(installment.JumlahTerbayar) && status == installment.StatusPembayaran {
+			continue
+		}
+		installment.JumlahTerbayar = paid
+		installment.StatusPembayaran = status
+		if err := repo.UpdateInstallment(installment); err != nil {
+			return err
+		}
 	}
-	return s.repo.Delete(id)
+	return nil
 }
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/dto"
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/repository"
+)
+
+func TestGenerateBillsDryRunMatchesRealRun(t *testing.T) {
+	db := openTestDB(t)
+	setTestSettings(t, db, map[string]string{
+		SettingSiblingDiscountEnabled: "false",
+		SettingProrationPolicy:        ProrationNone,
+	})
+	student, year := createTestStudent(t, db)
+	period := createTestPeriod(t, db, year, 1)
+
+	discountType := &model.JenisPotongan{
+		NamaPotongan: fmt.Sprintf("Potongan E2E %d", time.Now().UnixNano()),
+		JenisBiayaID: 1,
+		TipePotongan: DiscountTypePercent,
+		Nilai:        10,
+		Status:       "aktif",
+	}
+	if err := db.Create(discountType).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Delete(discountType) })
+	if err := db.Create(&model.PotonganSiswa{SiswaID: student.ID, JenisPotonganID: discountType.ID, PeriodeMulaiID: period.ID}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	billService := NewBillService(repository.NewBillRepository(db), repository.NewFeeTypeRepository(db), NewSettingService(repository.NewSettingRepository(db), db), db)
+	input := dto.GenerateBillsInput{SiswaIDs: []uint{student.ID}, DryRun: true}
+
+	preview, err := billService.GenerateBillsForPeriod(period.ID, input)
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	var count int64
+	db.Model(&model.TagihanSPP{}).Where("siswa_id = ?", student.ID).Count(&count)
+	if count != 0 {
+		t.Fatalf("dry run stored %d bills", count)
+	}
+	if preview.JumlahDibuat != 1 || toCents(preview.TotalAwal) != toCents(150000) || toCents(preview.TotalPotongan) != toCents(15000) || toCents(preview.TotalTagihan) != toCents(135000) {
+		t.Fatalf("unexpected preview: %+v", preview)
+	}
+
+	input.DryRun = false
+	result, err := billService.GenerateBillsForPeriod(period.ID, input)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if result.JumlahDibuat != preview.JumlahDibuat || toCents(result.TotalTagihan) != toCents(preview.TotalTagihan) || toCents(result.TotalPotongan) != toCents(preview.TotalPotongan) {
+		t.Fatalf("result %+v differs from preview %+v", result, preview)
+	}
+
+	var bill model.TagihanSPP
+	if err := db.Preload("Potongan").Where("siswa_id = ? AND periode_id = ?", student.ID, period.ID).First(&bill).Error; err != nil {
+		t.Fatal(err)
+	}
+	if toCents(bill.JumlahTagihan) != toCents(135000) || len(bill.Potongan) != 1 || toCents(bill.Potongan[0].Jumlah) != toCents(15000) {
+		t.Fatalf("unexpected stored bill: tagihan=%.2f potongan=%+v", bill.JumlahTagihan, bill.Potongan)
+	}
+
+	again, err := billService.GenerateBillsForPeriod(period.ID, dto.GenerateBillsInput{SiswaIDs: []uint{student.ID}, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.JumlahDibuat != 0 || again.JumlahDilewati != 1 {
+		t.Fatalf("billed student must be skipped: %+v", again)
+	}
+}
+
+func TestGenerateBillsSkipsExemptions(t *testing.T) {
+	db := openTestDB(t)
+	setTestSettings(t, db, map[string]string{
+		SettingSiblingDiscountEnabled: "false",
+		SettingProrationPolicy:        ProrationNone,
+	})
+	otherFeeType := &model.JenisBiaya{
+		KodeBiaya:    fmt.Sprintf("E2E-%d", time.Now().UnixNano()%10000000000),
+		NamaBiaya:    "Biaya E2E",
+		Periodisitas: "sekali",
+		Status:       "aktif",
+	}
+	if err := db.Create(otherFeeType).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Delete(otherFeeType) })
+	billService := NewBillService(repository.NewBillRepository(db), repository.NewFeeTypeRepository(db), NewSettingService(repository.NewSettingRepository(db), db), db)
+
+	sppFeeType := uint(1)
+	tests := []struct {
+		name      string
+		exemption func(student *model.Siswa) model.PembebasanTagihan
+		exempt    bool
+	}{
+		{
+			name: "student",
+			exemption: func(student *model.Siswa) model.PembebasanTagihan {
+				return model.PembebasanTagihan{SiswaID: &student.ID}
+			},
+			exempt: true,
+		},
+		{
+			name: "fee type",
+			exemption: func(student *model.Siswa) model.PembebasanTagihan {
+				return model.PembebasanTagihan{JenisBiayaID: &sppFeeType}
+			},
+			exempt: true,
+		},
+		{
+			name: "other fee type",
+			exemption: func(student *model.Siswa) model.PembebasanTagihan {
+				return model.PembebasanTagihan{JenisBiayaID: &otherFeeType.ID, SiswaID: &student.ID}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			student, year := createTestStudent(t, db)
+			period := createTestPeriod(t, db, year, 1)
+			exemption := tt.exemption(student)
+			exemption.PeriodeID = period.ID
+			exemption.Alasan = "Pembebasan E2E"
+			exemption.DibuatOleh = student.UserID
+			if err := db.Create(&exemption).Error; err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { db.Delete(&exemption) })
+
+			wantCreated, wantExempt := 1, 0
+			if tt.exempt {
+				wantCreated, wantExempt = 0, 1
+			}
+			for _, dryRun := range []bool{true, false} {
+				result, err := billService.GenerateBillsForPeriod(period.ID, dto.GenerateBillsInput{SiswaIDs: []uint{student.ID}, DryRun: dryRun})
+				if err != nil {
+					t.Fatalf("generate (dry run %v) failed: %v", dryRun, err)
+				}
+				if result.JumlahDibuat != wantCreated || result.JumlahDibebaskan != wantExempt {
+					t.Fatalf("dry run %v: dibuat = %d dibebaskan = %d, want %d and %d", dryRun, result.JumlahDibuat, result.JumlahDibebaskan, wantCreated, wantExempt)
+				}
+			}
+
+			var count int64
+			db.Model(&model.TagihanSPP{}).Where("siswa_id = ? AND periode_id = ?", student.ID, period.ID).Count(&count)
+			if count != int64(wantCreated) {
+				t.Fatalf("stored %d bills, want %d", count, wantCreated)
+			}
+		})
+	}
+}
+
+func TestUpdateBillRejectsPendingPayment(t *testing.T) {
+	db := openTestDB(t)
+	student, bills := createTestBills(t, db, 150000)
+	createTestPayment(t, db, student, PaymentStatusPending, bills[0])
+
+	billService := NewBillService(repository.NewBillRepository(db), repository.NewFeeTypeRepository(db), NewSettingService(repository.NewSettingRepository(db), db), db)
+	if _, err := billService.UpdateBill(bills[0].ID, dto.UpdateBillInput{JumlahTagihan: 175000}); !errors.Is(err, ErrPendingPaymentExists) {
+		t.Fatalf("err = %v, want %v", err, ErrPendingPaymentExists)
+	}
+
+	var bill model.TagihanSPP
+	if err := db.First(&bill, bills[0].ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if toCents(bill.JumlahTagihan) != toCents(150000) {
+		t.Fatalf("jumlah tagihan = %.2f, want 150000", bill.JumlahTagihan)
+	}
+}
+
+func TestDeleteBillRejectsBillsWithPayments(t *testing.T) {
+	db := openTestDB(t)
+	student, bills := createTestBills(t, db, 150000, 150000)
+	createTestPayment(t, db, student, PaymentStatusCancel, bills[0])
+
+	billService := NewBillService(repository.NewBillRepository(db), repository.NewFeeTypeRepository(db), NewSettingService(repository.NewSettingRepository(db), db), db)
+	if err := billService.DeleteBill(bills[0].ID); !errors.Is(err, ErrBillInUse) {
+		t.Fatalf("err = %v, want %v", err, ErrBillInUse)
+	}
+	if err := billService.DeleteBill(bills[1].ID); err != nil {
+		t.Fatalf("deleting an unpaid bill failed: %v", err)
+	}
+
+	var count int64
+	if err := db.Model(&model.TagihanSPP{}).Where("id IN ?", []uint{bills[0].ID, bills[1].ID}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("got %d remaining bills, want 1", count)
+	}
+}
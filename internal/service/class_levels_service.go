@@ -2,6 +2,8 @@ package service
 
 import (
 	"errors"
+	"log"
+	"strings"
 
 	"github.com/hiuncy/spp-payment-api/internal/dto"
 	"github.com/hiuncy/spp-payment-api/internal/model"
@@ -20,10 +22,11 @@ type ClassLevelService interface {
 
 type classLevelService struct {
 	repo repository.ClassLevelRepository
+	db   *gorm.DB
 }
 
-func NewClassLevelService(repo repository.ClassLevelRepository) ClassLevelService {
-	return &classLevelService{repo}
+func NewClassLevelService(repo repository.ClassLevelRepository, db *gorm.DB) ClassLevelService {
+	return &classLevelService{repo, db}
 }
 
 func (s *classLevelService) CreateClassLevel(input dto.CreateClassLevelInput) (*model.TingkatKelas, error) {
@@ -79,12 +82,24 @@ func (s *classLevelService) UpdateClassLevel(id uint, input dto.UpdateClassLevel
 		}
 	}
 
+	previousFee := classLevel.BiayaSPP
 	classLevel.Tingkat = input.Tingkat
 	classLevel.NamaTingkat = input.NamaTingkat
 	classLevel.BiayaSPP = input.BiayaSPP
 	classLevel.Status = input.Status
 
-	err = s.repo.Update(classLevel)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if previousFee != classLevel.BiayaSPP {
+			pinned, err := keepClassLevelFee(tx, classLevel.ID, previousFee)
+			if err != nil {
+				return err
+			}
+			if len(pinned) > 0 {
+				log.Printf("class level %d fee changed from %.2f to %.2f; previous fee kept for academic years %s", classLevel.ID, previousFee, classLevel.BiayaSPP, strings.Join(pinned, ", "))
+			}
+		}
+		return repository.NewClassLevelRepository(tx).Update(classLevel)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,347 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/dto"
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	DiscountTypePercent = "persen"
+	DiscountTypeFlat    = "nominal"
+)
+
+var (
+	ErrDiscountTypeExists = errors.New("jenis potongan sudah ada")
+	ErrDiscountTypeInUse  = errors.New("jenis potongan masih digunakan dan tidak dapat dihapus")
+	ErrInvalidDiscount    = errors.New("potongan tidak valid")
+)
+
+type DiscountService interface {
+	CreateDiscountType(input dto.DiscountTypeInput) (*model.JenisPotongan, error)
+	FindAllDiscountTypes() ([]model.JenisPotongan, error)
+	FindDiscountTypeByID(id uint) (*model.JenisPotongan, error)
+	UpdateDiscountType(id uint, input dto.DiscountTypeInput) (*model.JenisPotongan, error)
+	DeleteDiscountType(id uint) error
+	AssignDiscount(siswaID uint, input dto.StudentDiscountInput) (*model.PotonganSiswa, error)
+	FindStudentDiscounts(siswaID uint) ([]model.PotonganSiswa, error)
+	UpdateStudentDiscount(siswaID, id uint, input dto.StudentDiscountInput) (*model.PotonganSiswa, error)
+	DeleteStudentDiscount(siswaID, id uint) error
+}
+
+type discountService struct {
+	repo        repository.DiscountRepository
+	studentRepo repository.StudentRepository
+	periodRepo  repository.PeriodRepository
+	feeTypeRepo repository.FeeTypeRepository
+}
+
+func NewDiscountService(repo repository.DiscountRepository, studentRepo repository.StudentRepository, periodRepo repository.PeriodRepository, feeTypeRepo repository.FeeTypeRepository) DiscountService {
+	return &discountService{repo, studentRepo, periodRepo, feeTypeRepo}
+}
+
+func (s *discountService) CreateDiscountType(input dto.DiscountTypeInput) (*model.JenisPotongan, error) {
+	if err := validateDiscountType(input); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.FindTypeByName(input.NamaPotongan); err == nil {
+		return nil, ErrDiscountTypeExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	discountType := &model.JenisPotongan{Status: "aktif"}
+	if err := s.applyDiscountTypeInput(discountType, input); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateType(discountType); err != nil {
+		return nil, err
+	}
+	return discountType, nil
+}
+
+func (s *discountService) FindAllDiscountTypes() ([]model.JenisPotongan, error) {
+	return s.repo.FindAllTypes()
+}
+
+func (s *discountService) FindDiscountTypeByID(id uint) (*model.JenisPotongan, error) {
+	return s.repo.FindTypeByID(id)
+}
+
+func (s *discountService) UpdateDiscountType(id uint, input dto.DiscountTypeInput) (*model.JenisPotongan, error) {
+	discountType, err := s.repo.FindTypeByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateDiscountType(input); err != nil {
+		return nil, err
+	}
+	if existing, err := s.repo.FindTypeByName(input.NamaPotongan); err == nil && existing.ID != discountType.ID {
+		return nil, ErrDiscountTypeExists
+	}
+
+	if err := s.applyDiscountTypeInput(discountType, input); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateType(discountType); err != nil {
+		return nil, err
+	}
+	return discountType, nil
+}
+
+func (s *discountService) DeleteDiscountType(id uint) error {
+	if _, err := s.repo.FindTypeByID(id); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteType(id); err != nil {
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return ErrDiscountTypeInUse
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *discountService) AssignDiscount(siswaID uint, input dto.StudentDiscountInput) (*model.PotonganSiswa, error) {
+	if _, err := s.studentRepo.FindByID(siswaID); err != nil {
+		return nil, err
+	}
+	assignment := &model.PotonganSiswa{SiswaID: siswaID}
+	if err := s.applyAssignmentInput(assignment, input); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateAssignment(assignment); err != nil {
+		return nil, err
+	}
+	return s.repo.FindAssignmentByID(assignment.ID)
+}
+
+func (s *discountService) FindStudentDiscounts(siswaID uint) ([]model.PotonganSiswa, error) {
+	if _, err := s.studentRepo.FindByID(siswaID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindAssignmentsBySiswaID(siswaID)
+}
+
+func (s *discountService) UpdateStudentDiscount(siswaID, id uint, input dto.StudentDiscountInput) (*model.PotonganSiswa, error) {
+	assignment, err := s.repo.FindAssignmentByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if assignment.SiswaID != siswaID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if err := s.applyAssignmentInput(assignment, input); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateAssignment(assignment); err != nil {
+		return nil, err
+	}
+	return s.repo.FindAssignmentByID(id)
+}
+
+func (s *discountService) DeleteStudentDiscount(siswaID, id uint) error {
+	assignment, err := s.repo.FindAssignmentByID(id)
+	if err != nil {
+		return err
+	}
+	if assignment.SiswaID != siswaID {
+		return gorm.ErrRecordNotFound
+	}
+	return s.repo.DeleteAssignment(id)
+}
+
+func (s *discountService) applyAssignmentInput(assignment *model.PotonganSiswa, input dto.StudentDiscountInput) error {
+	if _, err := s.repo.FindTypeByID(input.JenisPotonganID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: jenis potongan tidak ditemukan", ErrInvalidDiscount)
+		}
+		return err
+	}
+	start, err := s.periodRepo.FindByID(input.PeriodeMulaiID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: periode mulai tidak ditemukan", ErrInvalidDiscount)
+		}
+		return err
+	}
+	if input.PeriodeSelesaiID != nil {
+		end, err := s.periodRepo.FindByID(*input.PeriodeSelesaiID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: periode selesai tidak ditemukan", ErrInvalidDiscount)
+			}
+			return err
+		}
+		if end.TanggalMulai.Before(start.TanggalMulai) {
+			return fmt.Errorf("%w: periode selesai tidak boleh sebelum periode mulai", ErrInvalidDiscount)
+		}
+	}
+
+	assignment.JenisPotonganID = input.JenisPotonganID
+	assignment.PeriodeMulaiID = input.PeriodeMulaiID
+	assignment.PeriodeSelesaiID = input.PeriodeSelesaiID
+	assignment.Keterangan = nil
+	if note := strings.TrimSpace(input.Keterangan); note != "" {
+		assignment.Keterangan = &note
+	}
+	return nil
+}
+
+func validateDiscountType(input dto.DiscountTypeInput) error {
+	switch input.TipePotongan {
+	case DiscountTypePercent:
+		if input.Nilai <= 0 || input.Nilai > 100 {
+			return fmt.Errorf("%w: persentase potongan harus di antara 0 dan 100", ErrInvalidDiscount)
+		}
+	case DiscountTypeFlat:
+		if input.Nilai <= 0 {
+			return fmt.Errorf("%w: nominal potongan harus lebih dari 0", ErrInvalidDiscount)
+		}
+	default:
+		return fmt.Errorf("%w: tipe potongan harus persen atau nominal", ErrInvalidDiscount)
+	}
+	if input.MaksimalPotongan != nil && *input.MaksimalPotongan < 0 {
+		return fmt.Errorf("%w: maksimal potongan tidak boleh negatif", ErrInvalidDiscount)
+	}
+	return nil
+}
+
+func (s *discountService) applyDiscountTypeInput(discountType *model.JenisPotongan, input dto.DiscountTypeInput) error {
+	feeType, err := findFeeType(s.feeTypeRepo, input.JenisBiayaID)
+	if err != nil {
+		return err
+	}
+
+	discountType.JenisBiayaID = feeType.ID
+	discountType.NamaPotongan = strings.TrimSpace(input.NamaPotongan)
+	discountType.TipePotongan = input.TipePotongan
+	discountType.Nilai = input.Nilai
+	discountType.MaksimalPotongan = input.MaksimalPotongan
+	discountType.Keterangan = nil
+	if note := strings.TrimSpace(input.Keterangan); note != "" {
+		discountType.Keterangan = &note
+	}
+	if input.Status != "" {
+		discountType.Status = input.Status
+	}
+	return nil
+}
+
+func discountAmount(discountType *model.JenisPotongan, amount float64) float64 {
+	discount := discountType.Nilai
+	if discountType.TipePotongan == DiscountTypePercent {
+		discount = amount * discountType.Nilai / 100
+	}
+	if discountType.MaksimalPotongan != nil && *discountType.MaksimalPotongan > 0 {
+		discount = math.Min(discount, *discountType.MaksimalPotongan)
+	}
+	return math.Round(discount)
+}
+
+func calculateDiscounts(db *gorm.DB, bills []model.TagihanSPP, period *model.PeriodeSPP, feeTypeID uint, siblingRules map[int]uint) error {
+	if len(bills) == 0 {
+		return nil
+	}
+	repo := repository.NewDiscountRepository(db)
+
+	siswaIDs := make([]uint, 0, len(bills))
+	for _, bill := range bills {
+		siswaIDs = append(siswaIDs, bill.SiswaID)
+	}
+	assignments, err := repo.FindActiveAssignments(siswaIDs, feeTypeID, period)
+	if err != nil {
+		return err
+	}
+	bySiswa := make(map[uint][]model.PotonganSiswa)
+	for _, assignment := range assignments {
+		bySiswa[assignment.SiswaID] = append(bySiswa[assignment.SiswaID], assignment)
+	}
+
+	var ranks map[uint]int
+	siblingTypes := make(map[uint]*model.JenisPotongan)
+	if len(siblingRules) > 0 {
+		if ranks, err = siblingRanks(db, siswaIDs); err != nil {
+			return err
+		}
+		for _, discountTypeID := range siblingRules {
+			discountType, err := repo.FindTypeByID(discountTypeID)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("%w: jenis potongan %d tidak ditemukan", ErrInvalidSiblingSetting, discountTypeID)
+				}
+				return err
+			}
+			siblingTypes[discountTypeID] = discountType
+		}
+	}
+
+	now := time.Now()
+	for i := range bills {
+		bill := &bills[i]
+		if toCents(bill.JumlahAwal) == 0 {
+			bill.JumlahAwal = bill.JumlahTagihan
+		}
+
+		var discounts []billDiscount
+		for _, assignment := range bySiswa[bill.SiswaID] {
+			assignmentID := assignment.ID
+			discounts = append(discounts, billDiscount{
+				discountType: &assignment.JenisPotongan,
+				assignmentID: &assignmentID,
+			})
+		}
+		if discountTypeID, ok := siblingDiscountFor(siblingRules, ranks[bill.SiswaID]); ok && siblingTypes[discountTypeID].Status == "aktif" && siblingTypes[discountTypeID].JenisBiayaID == bill.JenisBiayaID {
+			discounts = append(discounts, billDiscount{discountType: siblingTypes[discountTypeID]})
+		}
+		applyBillDiscounts(bill, discounts, now)
+	}
+	return nil
+}
+
+type billDiscount struct {
+	discountType *model.JenisPotongan
+	assignmentID *uint
+}
+
+func applyBillDiscounts(bill *model.TagihanSPP, discounts []billDiscount, now time.Time) {
+	var applied []model.PotonganTagihan
+	var total float64
+	var names []string
+	for _, discount := range discounts {
+		amount := math.Max(math.Min(discountAmount(discount.discountType, bill.JumlahAwal), bill.JumlahAwal-total), 0)
+		if toCents(amount) <= 0 {
+			continue
+		}
+		applied = append(applied, model.PotonganTagihan{
+			JenisPotonganID: discount.discountType.ID,
+			PotonganSiswaID: discount.assignmentID,
+			NamaPotongan:    discount.discountType.NamaPotongan,
+			Jumlah:          amount,
+		})
+		names = append(names, discount.discountType.NamaPotongan)
+		total += amount
+	}
+	if len(applied) == 0 {
+		return
+	}
+
+	bill.Potongan = applied
+	bill.JumlahPotongan = total
+	bill.JumlahTagihan = math.Max(bill.JumlahAwal-total, 0)
+	bill.StatusPembayaran = billStatusFor(billTotal(bill), bill.JumlahTerbayar, false)
+	if toCents(bill.JumlahTagihan) == 0 {
+		reason := "potongan penuh: " + strings.Join(names, ", ")
+		bill.JumlahTagihan = 0
+		bill.StatusPembayaran = BillStatusDibebaskan
+		bill.AlasanPembebasan = &reason
+		bill.TanggalDibebaskan = &now
+	}
+}
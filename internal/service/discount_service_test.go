@@ -0,0 +1,82 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/model"
+)
+
+func TestDiscountAmount(t *testing.T) {
+	limit := func(value float64) *float64 { return &value }
+	tests := []struct {
+		name         string
+		discountType model.JenisPotongan
+		amount       float64
+		want         float64
+	}{
+		{name: "flat", discountType: model.JenisPotongan{TipePotongan: DiscountTypeFlat, Nilai: 50000}, amount: 150000, want: 50000},
+		{name: "percent", discountType: model.JenisPotongan{TipePotongan: DiscountTypePercent, Nilai: 12.5}, amount: 150000, want: 18750},
+		{name: "percent rounded down", discountType: model.JenisPotongan{TipePotongan: DiscountTypePercent, Nilai: 33}, amount: 100001, want: 33000},
+		{name: "percent rounded up", discountType: model.JenisPotongan{TipePotongan: DiscountTypePercent, Nilai: 2.5}, amount: 100020, want: 2501},
+		{name: "percent capped", discountType: model.JenisPotongan{TipePotongan: DiscountTypePercent, Nilai: 50, MaksimalPotongan: limit(60000)}, amount: 150000, want: 60000},
+		{name: "zero cap ignored", discountType: model.JenisPotongan{TipePotongan: DiscountTypePercent, Nilai: 50, MaksimalPotongan: limit(0)}, amount: 150000, want: 75000},
+		{name: "flat capped", discountType: model.JenisPotongan{TipePotongan: DiscountTypeFlat, Nilai: 80000, MaksimalPotongan: limit(50000)}, amount: 150000, want: 50000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := discountAmount(&tt.discountType, tt.amount); toCents(got) != toCents(tt.want) {
+				t.Fatalf("discountAmount = %.2f, want %.2f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyBillDiscounts(t *testing.T) {
+	percent := func(id uint, name string, value float64) billDiscount {
+		return billDiscount{discountType: &model.JenisPotongan{ID: id, NamaPotongan: name, TipePotongan: DiscountTypePercent, Nilai: value}}
+	}
+	flat := func(id uint, name string, value float64) billDiscount {
+		return billDiscount{discountType: &model.JenisPotongan{ID: id, NamaPotongan: name, TipePotongan: DiscountTypeFlat, Nilai: value}}
+	}
+	tests := []struct {
+		name      string
+		discounts []billDiscount
+		want      []float64
+		wantTotal float64
+		wantBill  float64
+		status    string
+	}{
+		{name: "no discounts", wantBill: 150000, status: BillStatusBelumBayar},
+		{name: "single percent", discounts: []billDiscount{percent(1, "Prestasi", 25)}, want: []float64{37500}, wantTotal: 37500, wantBill: 112500, status: BillStatusBelumBayar},
+		{name: "stacked from original amount", discounts: []billDiscount{percent(1, "Prestasi", 50), percent(2, "Saudara", 20)}, want: []float64{75000, 30000}, wantTotal: 105000, wantBill: 45000, status: BillStatusBelumBayar},
+		{name: "stacked discount clamped to remainder", discounts: []billDiscount{percent(1, "Prestasi", 50), flat(2, "Yatim", 100000)}, want: []float64{75000, 75000}, wantTotal: 150000, wantBill: 0, status: BillStatusDibebaskan},
+		{name: "discount after full coverage skipped", discounts: []billDiscount{flat(1, "Yatim", 200000), percent(2, "Saudara", 10)}, want: []float64{150000}, wantTotal: 150000, wantBill: 0, status: BillStatusDibebaskan},
+		{name: "full percent waives bill", discounts: []billDiscount{percent(1, "Beasiswa", 100)}, want: []float64{150000}, wantTotal: 150000, wantBill: 0, status: BillStatusDibebaskan},
+	}
+	now := time.Now()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bill := &model.TagihanSPP{JumlahAwal: 150000, JumlahTagihan: 150000, StatusPembayaran: BillStatusBelumBayar}
+			applyBillDiscounts(bill, tt.discounts, now)
+
+			if len(bill.Potongan) != len(tt.want) {
+				t.Fatalf("got %d discounts, want %d", len(bill.Potongan), len(tt.want))
+			}
+			for i, discount := range bill.Potongan {
+				if toCents(discount.Jumlah) != toCents(tt.want[i]) {
+					t.Fatalf("discount %s = %.2f, want %.2f", discount.NamaPotongan, discount.Jumlah, tt.want[i])
+				}
+			}
+			if toCents(bill.JumlahPotongan) != toCents(tt.wantTotal) || toCents(bill.JumlahTagihan) != toCents(tt.wantBill) {
+				t.Fatalf("potongan = %.2f tagihan = %.2f, want %.2f and %.2f", bill.JumlahPotongan, bill.JumlahTagihan, tt.wantTotal, tt.wantBill)
+			}
+			if bill.StatusPembayaran != tt.status {
+				t.Fatalf("status = %s, want %s", bill.StatusPembayaran, tt.status)
+			}
+			if waived := bill.StatusPembayaran == BillStatusDibebaskan; waived != (bill.AlasanPembebasan != nil && bill.TanggalDibebaskan != nil) {
+				t.Fatalf("waiver reason = %v date = %v for status %s", bill.AlasanPembebasan, bill.TanggalDibebaskan, bill.StatusPembayaran)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hiuncy/spp-payment-api/internal/dto"
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/repository"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidExemption = errors.New("aturan pembebasan tidak valid")
+
+type ExemptionService interface {
+	CreateExemption(input dto.ExemptionInput) (*model.PembebasanTagihan, error)
+	FindAllExemptions(periodeID uint) ([]model.PembebasanTagihan, error)
+	FindExemptionByID(id uint) (*model.PembebasanTagihan, error)
+	DeleteExemption(id uint) error
+}
+
+type exemptionService struct {
+	repo       repository.ExemptionRepository
+	periodRepo repository.PeriodRepository
+}
+
+func NewExemptionService(repo repository.ExemptionRepository, periodRepo repository.PeriodRepository) ExemptionService {
+	return &exemptionService{repo, periodRepo}
+}
+
+func (s *exemptionService) CreateExemption(input dto.ExemptionInput) (*model.PembebasanTagihan, error) {
+	reason := strings.TrimSpace(input.Alasan)
+	if reason == "" {
+		return nil, fmt.Errorf("%w: alasan pembebasan wajib diisi", ErrInvalidExemption)
+	}
+	scopes := 0
+	for _, id := range []*uint{input.TingkatID, input.KelasID, input.SiswaID} {
+		if id != nil {
+			scopes++
+		}
+	}
+	if scopes > 1 {
+		return nil, fmt.Errorf("%w: pilih salah satu dari tingkat, kelas, atau siswa", ErrInvalidExemption)
+	}
+	if _, err := s.periodRepo.FindByID(input.PeriodeID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: periode tidak ditemukan", ErrInvalidExemption)
+		}
+		return nil, err
+	}
+
+	exemption := &model.PembebasanTagihan{
+		PeriodeID:    input.PeriodeID,
+		JenisBiayaID: input.JenisBiayaID,
+		TingkatID:    input.TingkatID,
+		KelasID:      input.KelasID,
+		SiswaID:      input.SiswaID,
+		Alasan:       reason,
+		DibuatOleh:   input.DibuatOleh,
+	}
+	if err := s.repo.Create(exemption); err != nil {
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return nil, fmt.Errorf("%w: jenis biaya, tingkat, kelas, atau siswa tidak ditemukan", ErrInvalidExemption)
+		}
+		return nil, err
+	}
+	return s.repo.FindByID(exemption.ID)
+}
+
+func (s *exemptionService) FindAllExemptions(periodeID uint) ([]model.PembebasanTagihan, error) {
+	return s.repo.FindAll(periodeID)
+}
+
+func (s *exemptionService) FindExemptionByID(id uint) (*model.PembebasanTagihan, error) {
+	return s.repo.FindByID(id)
+}
+
+func (s *exemptionService) DeleteExemption(id uint) error {
+	if _, err := s.repo.FindByID(id); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/dto"
+)
+
+const FakeGatewaySignatureHeader = "X-Fake-Signature"
+
+type fakeTransaction struct {
+	TransactionID     string `json:"transaction_id"`
+	OrderID           string `json:"order_id"`
+	TransactionStatus string `json:"transaction_status"`
+	GrossAmount       string `json:"gross_amount"`
+	RefundAmount      int64  `json:"refund_amount"`
+	PaymentType       string `json:"payment_type"`
+	Bank              string `json:"bank,omitempty"`
+	VANumber          string `json:"va_number,omitempty"`
+	TransactionTime   string `json:"transaction_time"`
+	SettlementTime    string `json:"settlement_time,omitempty"`
+}
+
+type fakeGateway struct {
+	secret       string
+	mu           sync.Mutex
+	transactions map[string]*fakeTransaction
+}
+
+func NewFakeGateway(secret string) PaymentGateway {
+	return &fakeGateway{secret: secret, transactions: make(map[string]*fakeTransaction)}
+}
+
+func (g *fakeGateway) Name() string {
+	return GatewayFake
+}
+
+func (g *fakeGateway) CreateCharge(req dto.TransactionRequest) (string, error) {
+	if req.OrderID == "" || req.GrossAmount <= 0 {
+		return "", errors.New("order id dan nominal transaksi wajib diisi")
+	}
+	random := make([]byte, 12)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, exists := g.transactions[req.OrderID]; exists {
+		return "", fmt.Errorf("order id %s sudah digunakan", req.OrderID)
+	}
+	g.transactions[req.OrderID] = &fakeTransaction{
+		TransactionID:     "FAKE-" + hex.EncodeToString(random),
+		OrderID:           req.OrderID,
+		TransactionStatus: PaymentStatusPending,
+		GrossAmount:       strconv.FormatInt(req.GrossAmount, 10) + ".00",
+		PaymentType:       GatewayFake,
+		TransactionTime:   time.Now().Format("2006-01-02 15:04:05"),
+	}
+	return "fake-token-" + hex.EncodeToString(random), nil
+}
+
+func (g *fakeGateway) CreateVirtualAccount(req dto.TransactionRequest, bank string) (*dto.VirtualAccount, error) {
+	if _, err := g.CreateCharge(req); err != nil {
+		return nil, err
+	}
+
+	number := req.VANumber
+	if number == "" {
+		random := make([]byte, 5)
+		if _, err := rand.Read(random); err != nil {
+			return nil, err
+		}
+		for _, b := range random {
+			number += fmt.Sprintf("%02d", int(b)%100)
+		}
+	}
+	number = "8808" + number
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	transaction := g.transactions[req.OrderID]
+	transaction.PaymentType = "bank_transfer"
+	transaction.Bank = bank
+	transaction.VANumber = number
+	rawResponse, _ := json.Marshal(transaction)
+	account := &dto.VirtualAccount{
+		Bank:          bank,
+		Number:        number,
+		TransactionID: transaction.TransactionID,
+		RawResponse:   string(rawResponse),
+	}
+	if req.Expiry > 0 {
+		expiresAt := time.Now().Add(req.Expiry)
+		account.ExpiresAt = &expiresAt
+	}
+	return account, nil
+}
+
+func (g *fakeGateway) GetTransactionStatus(orderID string) (*dto.PaymentStatusUpdate, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	transaction, ok := g.transactions[orderID]
+	if !ok {
+		return nil, ErrTransactionNotFound
+	}
+	return transaction.update(), nil
+}
+
+func (g *fakeGateway) CancelTransaction(orderID string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	transaction, ok := g.transactions[orderID]
+	if !ok {
+		return ErrTransactionNotFound
+	}
+	if transaction.TransactionStatus != PaymentStatusPending {
+		return fmt.Errorf("transaksi %s tidak dapat dibatalkan dari status %s", orderID, transaction.TransactionStatus)
+	}
+	transaction.TransactionStatus = PaymentStatusCancel
+	return nil
+}
+
+func (g *fakeGateway) RefundTransaction(orderID, refundKey string, amount int64, reason string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	transaction, ok := g.transactions[orderID]
+	if !ok {
+		return "", ErrTransactionNotFound
+	}
+	if !isPaymentSettled(transaction.TransactionStatus) {
+		return "", fmt.Errorf("transaksi %s belum lunas", orderID)
+	}
+	gross, _ := strconv.ParseFloat(transaction.GrossAmount, 64)
+	if amount <= 0 || transaction.RefundAmount+amount > int64(gross) {
+		return "", errors.New("nominal refund melebihi nominal transaksi")
+	}
+
+	transaction.RefundAmount += amount
+	transaction.TransactionStatus = PaymentStatusPartialRefund
+	if transaction.RefundAmount == int64(gross) {
+		transaction.TransactionStatus = PaymentStatusRefund
+	}
+	rawResponse, _ := json.Marshal(map[string]any{
+		"order_id":           orderID,
+		"refund_key":         refundKey,
+		"refund_amount":      amount,
+		"reason":             reason,
+		"transaction_status": transaction.TransactionStatus,
+	})
+	return string(rawResponse), nil
+}
+
+func (g *fakeGateway) ParseNotification(rawBody []byte, headers http.Header) (*dto.PaymentStatusUpdate, error) {
+	if !g.verifySignature(rawBody, headers.Get(FakeGatewaySignatureHeader)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var notification fakeTransaction
+	if err := json.Unmarshal(rawBody, &notification); err != nil || notification.OrderID == "" {
+		return nil, ErrInvalidNotificationPayload
+	}
+	if notification.GrossAmount == "" {
+		return nil, ErrAmountMismatch
+	}
+
+	g.mu.Lock()
+	if transaction, ok := g.transactions[notification.OrderID]; ok {
+		transaction.TransactionStatus = notification.TransactionStatus
+		transaction.SettlementTime = notification.SettlementTime
+		if notification.TransactionID == "" {
+			notification.TransactionID = transaction.TransactionID
+		}
+	}
+	g.mu.Unlock()
+
+	update := notification.update()
+	update.RawResponse = string(rawBody)
+	return update, nil
+}
+
+func (g *fakeGateway) verifySignature(rawBody []byte, signature string) bool {
+	if signature == "" || g.secret == "" {
+		return false
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(g.secret))
+	mac.Write(rawBody)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+func (t *fakeTransaction) update() *dto.PaymentStatusUpdate {
+	rawResponse, _ := json.Marshal(t)
+	return &dto.PaymentStatusUpdate{
+		Gateway:           GatewayFake,
+		OrderID:           t.OrderID,
+		TransactionID:     t.TransactionID,
+		TransactionStatus: t.TransactionStatus,
+		PaymentType:       t.PaymentType,
+		GrossAmount:       t.GrossAmount,
+		TransactionTime:   t.TransactionTime,
+		SettlementTime:    t.SettlementTime,
+		RawResponse:       string(rawResponse),
+	}
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/hiuncy/spp-payment-api/internal/dto"
+)
+
+const testFakeGatewaySecret = "test-fake-secret"
+
+func fakeGatewaySignature(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func fakeGatewayNotify(t *testing.T, gateway PaymentGateway, fields map[string]string) *dto.PaymentStatusUpdate {
+	t.Helper()
+	body, err := json.Marshal(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	headers := http.Header{}
+	headers.Set(FakeGatewaySignatureHeader, fakeGatewaySignature(body, testFakeGatewaySecret))
+	update, err := gateway.ParseNotification(body, headers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return update
+}
+
+func fakeGatewayCharge(t *testing.T, gateway PaymentGateway, orderID string, amount int64) {
+	t.Helper()
+	if _, err := gateway.CreateCharge(dto.TransactionRequest{OrderID: orderID, GrossAmount: amount}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func fakeGatewayStatus(t *testing.T, gateway PaymentGateway, orderID string) string {
+	t.Helper()
+	update, err := gateway.GetTransactionStatus(orderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return update.TransactionStatus
+}
+
+func TestFakeGatewaySettleFlow(t *testing.T) {
+	gateway := NewFakeGateway(testFakeGatewaySecret)
+	fakeGatewayCharge(t, gateway, "SPP-1", 150000)
+	if status := fakeGatewayStatus(t, gateway, "SPP-1"); status != PaymentStatusPending {
+		t.Fatalf("status = %q, want %q", status, PaymentStatusPending)
+	}
+
+	update := fakeGatewayNotify(t, gateway, map[string]string{
+		"order_id":           "SPP-1",
+		"transaction_status": "settlement",
+		"gross_amount":       "150000.00",
+		"settlement_time":    "2025-07-05 10:00:00",
+	})
+	if update.Gateway != GatewayFake || update.OrderID != "SPP-1" || update.TransactionStatus != PaymentStatusSettlement || update.TransactionID == "" {
+		t.Fatalf("unexpected update: %+v", update)
+	}
+	if status := fakeGatewayStatus(t, gateway, "SPP-1"); status != PaymentStatusSettlement {
+		t.Fatalf("status = %q, want %q", status, PaymentStatusSettlement)
+	}
+	if err := gateway.CancelTransaction("SPP-1"); err == nil {
+		t.Fatal("settled transaction must not be cancelled")
+	}
+
+	if _, err := gateway.RefundTransaction("SPP-1", "RF-1", 50000, "salah bayar"); err != nil {
+		t.Fatalf("unexpected refund error: %v", err)
+	}
+	if status := fakeGatewayStatus(t, gateway, "SPP-1"); status != PaymentStatusPartialRefund {
+		t.Fatalf("status = %q, want %q", status, PaymentStatusPartialRefund)
+	}
+	if _, err := gateway.RefundTransaction("SPP-1", "RF-2", 150000, "salah bayar"); err == nil {
+		t.Fatal("refund above the gross amount must be rejected")
+	}
+}
+
+func TestFakeGatewayExpireFlow(t *testing.T) {
+	gateway := NewFakeGateway(testFakeGatewaySecret)
+	fakeGatewayCharge(t, gateway, "SPP-2", 150000)
+
+	update := fakeGatewayNotify(t, gateway, map[string]string{
+		"order_id":           "SPP-2",
+		"transaction_status": "expire",
+		"gross_amount":       "150000.00",
+	})
+	if update.TransactionStatus != PaymentStatusExpire {
+		t.Fatalf("status = %q, want %q", update.TransactionStatus, PaymentStatusExpire)
+	}
+	if status := fakeGatewayStatus(t, gateway, "SPP-2"); status != PaymentStatusExpire {
+		t.Fatalf("status = %q, want %q", status, PaymentStatusExpire)
+	}
+	if err := gateway.CancelTransaction("SPP-2"); err == nil {
+		t.Fatal("expired transaction must not be cancelled")
+	}
+	if _, err := gateway.RefundTransaction("SPP-2", "RF-1", 1000, "salah bayar"); err == nil {
+		t.Fatal("expired transaction must not be refunded")
+	}
+}
+
+func TestFakeGatewayCancelPending(t *testing.T) {
+	gateway := NewFakeGateway(testFakeGatewaySecret)
+	fakeGatewayCharge(t, gateway, "SPP-3", 150000)
+
+	if err := gateway.CancelTransaction("SPP-3"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status := fakeGatewayStatus(t, gateway, "SPP-3"); status != PaymentStatusCancel {
+		t.Fatalf("status = %q, want %q", status, PaymentStatusCancel)
+	}
+	if err := gateway.CancelTransaction("SPP-404"); !errors.Is(err, ErrTransactionNotFound) {
+		t.Fatalf("err = %v, want %v", err, ErrTransactionNotFound)
+	}
+	if _, err := gateway.GetTransactionStatus("SPP-404"); !errors.Is(err, ErrTransactionNotFound) {
+		t.Fatalf("err = %v, want %v", err, ErrTransactionNotFound)
+	}
+}
+
+func TestFakeGatewayRejectsDuplicateOrder(t *testing.T) {
+	gateway := NewFakeGateway(testFakeGatewaySecret)
+	fakeGatewayCharge(t, gateway, "SPP-4", 150000)
+
+	if _, err := gateway.CreateCharge(dto.TransactionRequest{OrderID: "SPP-4", GrossAmount: 150000}); err == nil {
+		t.Fatal("duplicate order id must be rejected")
+	}
+}
+
+func TestFakeGatewayParseNotificationSignature(t *testing.T) {
+	body := []byte(`{"order_id":"SPP-5","transaction_status":"settlement","gross_amount":"150000.00"}`)
+
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		body      []byte
+		wantErr   error
+	}{
+		{name: "valid signature", secret: testFakeGatewaySecret, signature: fakeGatewaySignature(body, testFakeGatewaySecret), body: body},
+		{name: "signed with another secret", secret: testFakeGatewaySecret, signature: fakeGatewaySignature(body, "fake-gateway-secret"), body: body, wantErr: ErrInvalidSignature},
+		{name: "tampered body", secret: testFakeGatewaySecret, signature: fakeGatewaySignature(body, testFakeGatewaySecret), body: []byte(`{"order_id":"SPP-5","transaction_status":"settlement","gross_amount":"1.00"}`), wantErr: ErrInvalidSignature},
+		{name: "missing signature", secret: testFakeGatewaySecret, body: body, wantErr: ErrInvalidSignature},
+		{name: "signature not hex", secret: testFakeGatewaySecret, signature: "bukan-hex", body: body, wantErr: ErrInvalidSignature},
+		{name: "secret not configured", secret: "", signature: fakeGatewaySignature(body, ""), body: body, wantErr: ErrInvalidSignature},
+		{name: "missing gross amount", secret: testFakeGatewaySecret, signature: fakeGatewaySignature([]byte(`{"order_id":"SPP-5"}`), testFakeGatewaySecret), body: []byte(`{"order_id":"SPP-5"}`), wantErr: ErrAmountMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := NewFakeGateway(tt.secret)
+			headers := http.Header{}
+			headers.Set(FakeGatewaySignatureHeader, tt.signature)
+			update, err := gateway.ParseNotification(tt.body, headers)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if update.OrderID != "SPP-5" || update.TransactionStatus != PaymentStatusSettlement {
+				t.Fatalf("unexpected update: %+v", update)
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/hiuncy/spp-payment-api/internal/dto"
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	SettingSiblingDiscountEnabled = "potongan_saudara_aktif"
+	SettingSiblingDiscountRules   = "potongan_saudara"
+)
+
+var (
+	ErrInvalidFamily         = errors.New("data keluarga tidak valid")
+	ErrStudentInOtherFamily  = errors.New("siswa sudah terdaftar di keluarga lain")
+	ErrInvalidSiblingSetting = errors.New("pengaturan potongan saudara tidak valid")
+)
+
+type FamilyService interface {
+	FindSuggestions() ([]dto.FamilySuggestion, error)
+	CreateFamily(input dto.FamilyInput) (*model.Keluarga, error)
+	FindAllFamilies(search string) ([]model.Keluarga, error)
+	FindFamilyByID(id uint) (*model.Keluarga, error)
+	UpdateFamily(id uint, input dto.FamilyInput) (*model.Keluarga, error)
+	DeleteFamily(id uint) error
+}
+
+type familyService struct {
+	repo repository.FamilyRepository
+	db   *gorm.DB
+}
+
+func NewFamilyService(repo repository.FamilyRepository, db *gorm.DB) FamilyService {
+	return &familyService{repo, db}
+}
+
+func (s *familyService) FindSuggestions() ([]dto.FamilySuggestion, error) {
+	students, err := s.repo.FindSuggestionCandidates()
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]*dto.FamilySuggestion)
+	var keys []string
+	for _, student := range students {
+		key := normalizeParentName(student.NamaOrangtua) + "|" + normalizePhone(student.TeleponOrangtua)
+		group, ok := groups[key]
+		if !ok {
+			group = &dto.FamilySuggestion{NamaOrangtua: student.NamaOrangtua, TeleponOrangtua: student.TeleponOrangtua}
+			groups[key] = group
+			keys = append(keys, key)
+		}
+		group.Siswa = append(group.Siswa, student)
+	}
+	sort.Strings(keys)
+
+	suggestions := []dto.FamilySuggestion{}
+	for _, key := range keys {
+		group := groups[key]
+		if len(group.Siswa) < 2 || sameFamily(group.Siswa) {
+			continue
+		}
+		suggestions = append(suggestions, *group)
+	}
+	return suggestions, nil
+}
+
+func (s *familyService) CreateFamily(input dto.FamilyInput) (*model.Keluarga, error) {
+	var family *model.Keluarga
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repoTx := repository.NewFamilyRepository(tx)
+		students, err := s.familyMembers(repoTx, 0, input.SiswaIDs)
+		if err != nil {
+			return err
+		}
+
+		family = &model.Keluarga{}
+		applyFamilyInput(family, input, students)
+		if err := repoTx.Create(family); err != nil {
+			return err
+		}
+		return repoTx.SetMembers(family.ID, input.SiswaIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(family.ID)
+}
+
+func (s *familyService) FindAllFamilies(search string) ([]model.Keluarga, error) {
+	return s.repo.FindAll(search)
+}
+
+func (s *familyService) FindFamilyByID(id uint) (*model.Keluarga, error) {
+	return s.repo.FindByID(id)
+}
+
+func (s *familyService) UpdateFamily(id uint, input dto.FamilyInput) (*model.Keluarga, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repoTx := repository.NewFamilyRepository(tx)
+		family, err := repoTx.FindByID(id)
+		if err != nil {
+			return err
+		}
+		students, err := s.familyMembers(repoTx, id, input.SiswaIDs)
+		if err != nil {
+			return err
+		}
+
+		applyFamilyInput(family, input, students)
+		if err := repoTx.Update(family); err != nil {
+			return err
+		}
+		return repoTx.SetMembers(id, input.SiswaIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(id)
+}
+
+func (s *familyService) DeleteFamily(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		repoTx := repository.NewFamilyRepository(tx)
+		if _, err := repoTx.FindByID(id); err != nil {
+			return err
+		}
+		return repoTx.Delete(id)
+	})
+}
+
+func (s *familyService) familyMembers(repo repository.FamilyRepository, familyID uint, siswaIDs []uint) ([]model.Siswa, error) {
+	if len(siswaIDs) == 0 {
+		return nil, fmt.Errorf("%w: minimal satu siswa harus dipilih", ErrInvalidFamily)
+	}
+	students, err := repo.FindStudentsByIDs(siswaIDs)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[uint]bool, len(students))
+	for _, student := range students {
+		found[student.ID] = true
+		if student.KeluargaID != nil && *student.KeluargaID != familyID {
+			return nil, fmt.Errorf("%w: %s", ErrStudentInOtherFamily, student.NamaLengkap)
+		}
+	}
+	for _, id := range siswaIDs {
+		if !found[id] {
+			return nil, fmt.Errorf("%w: siswa dengan ID %d tidak ditemukan", ErrInvalidFamily, id)
+		}
+	}
+	return students, nil
+}
+
+func applyFamilyInput(family *model.Keluarga, input dto.FamilyInput, students []model.Siswa) {
+	family.NamaOrangtua = strings.TrimSpace(input.NamaOrangtua)
+	family.TeleponOrangtua = strings.TrimSpace(input.TeleponOrangtua)
+	if family.NamaOrangtua == "" {
+		family.NamaOrangtua = students[0].NamaOrangtua
+	}
+	if family.TeleponOrangtua == "" {
+		family.TeleponOrangtua = students[0].TeleponOrangtua
+	}
+	family.NamaKeluarga = strings.TrimSpace(input.NamaKeluarga)
+	if family.NamaKeluarga == "" {
+		family.NamaKeluarga = "Keluarga " + family.NamaOrangtua
+	}
+	family.Keterangan = nil
+	if note := strings.TrimSpace(input.Keterangan); note != "" {
+		family.Keterangan = &note
+	}
+}
+
+func sameFamily(students []model.Siswa) bool {
+	first := students[0].KeluargaID
+	if first == nil {
+		return false
+	}
+	for _, student := range students[1:] {
+		if student.KeluargaID == nil || *student.KeluargaID != *first {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeParentName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+func normalizePhone(phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, phone)
+	if strings.HasPrefix(digits, "62") {
+		digits = "0" + digits[2:]
+	}
+	return digits
+}
+
+func loadSiblingDiscountRules(settingService SettingService) (map[int]uint, error) {
+	values, err := settingService.GetSettingValues()
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(values[SettingSiblingDiscountEnabled]) != "true" {
+		return nil, nil
+	}
+	raw := strings.TrimSpace(values[SettingSiblingDiscountRules])
+	if raw == "" {
+		return nil, nil
+	}
+
+	var configured map[string]uint
+	if err := json.Unmarshal([]byte(raw), &configured); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSiblingSetting, err)
+	}
+	rules := make(map[int]uint, len(configured))
+	for key, discountTypeID := range configured {
+		rank, err := strconv.Atoi(strings.TrimSpace(key))
+		if err != nil || rank < 2 {
+			return nil, fmt.Errorf("%w: urutan anak %q harus angka mulai dari 2", ErrInvalidSiblingSetting, key)
+		}
+		rules[rank] = discountTypeID
+	}
+	return rules, nil
+}
+
+func siblingDiscountFor(rules map[int]uint, rank int) (uint, bool) {
+	best := 0
+	for configured := range rules {
+		if configured <= rank && configured > best {
+			best = configured
+		}
+	}
+	if best == 0 {
+		return 0, false
+	}
+	return rules[best], true
+}
+
+func siblingRanks(tx *gorm.DB, siswaIDs []uint) (map[uint]int, error) {
+	siblings, err := repository.NewFamilyRepository(tx).FindActiveSiblings(siswaIDs)
+	if err != nil {
+		return nil, err
+	}
+	return rankSiblings(siblings), nil
+}
+
+func rankSiblings(siblings []model.Siswa) map[uint]int {
+	ordered := make([]model.Siswa, 0, len(siblings))
+	for _, sibling := range siblings {
+		if sibling.KeluargaID != nil && sibling.Status == "aktif" {
+			ordered = append(ordered, sibling)
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if before, ok := compareOptionalDates(ordered[i].TanggalLahir, ordered[j].TanggalLahir); ok {
+			return before
+		}
+		if before, ok := compareOptionalDates(ordered[i].TanggalMasuk, ordered[j].TanggalMasuk); ok {
+			return before
+		}
+		return ordered[i].ID < ordered[j].ID
+	})
+
+	ranks := make(map[uint]int, len(ordered))
+	counters := make(map[uint]int)
+	for _, sibling := range ordered {
+		counters[*sibling.KeluargaID]++
+		ranks[sibling.ID] = counters[*sibling.KeluargaID]
+	}
+	return ranks
+}
+
+func compareOptionalDates(a, b *time.Time) (bool, bool) {
+	switch {
+	case a == nil && b == nil:
+		return false, false
+	case a == nil || b == nil:
+		return b == nil, true
+	case a.Equal(*b):
+		return false, false
+	}
+	return a.Before(*b), true
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/model"
+)
+
+func TestRankSiblings(t *testing.T) {
+	family := func(id uint) *uint { return &id }
+	date := func(value string) *time.Time {
+		parsed := testDate(value)
+		return &parsed
+	}
+	student := func(id uint, keluargaID *uint, birth, entry *time.Time, status string) model.Siswa {
+		return model.Siswa{ID: id, KeluargaID: keluargaID, TanggalLahir: birth, TanggalMasuk: entry, Status: status}
+	}
+	tests := []struct {
+		name     string
+		siblings []model.Siswa
+		want     map[uint]int
+	}{
+		{
+			name: "oldest first",
+			siblings: []model.Siswa{
+				student(1, family(1), date("2016-03-01"), nil, "aktif"),
+				student(2, family(1), date("2012-08-15"), nil, "aktif"),
+				student(3, family(1), date("2014-01-20"), nil, "aktif"),
+			},
+			want: map[uint]int{2: 1, 3: 2, 1: 3},
+		},
+		{
+			name: "families ranked separately",
+			siblings: []model.Siswa{
+				student(1, family(1), date("2014-01-01"), nil, "aktif"),
+				student(2, family(2), date("2013-01-01"), nil, "aktif"),
+				student(3, family(1), date("2012-01-01"), nil, "aktif"),
+				student(4, family(2), date("2015-01-01"), nil, "aktif"),
+			},
+			want: map[uint]int{3: 1, 1: 2, 2: 1, 4: 2},
+		},
+		{
+			name: "same birth date ranked by enrolment date",
+			siblings: []model.Siswa{
+				student(1, family(1), date("2014-05-05"), date("2021-07-12"), "aktif"),
+				student(2, family(1), date("2014-05-05"), date("2020-07-13"), "aktif"),
+			},
+			want: map[uint]int{2: 1, 1: 2},
+		},
+		{
+			name: "same birth and enrolment date ranked by id",
+			siblings: []model.Siswa{
+				student(5, family(1), date("2014-05-05"), date("2020-07-13"), "aktif"),
+				student(4, family(1), date("2014-05-05"), date("2020-07-13"), "aktif"),
+			},
+			want: map[uint]int{4: 1, 5: 2},
+		},
+		{
+			name: "missing dates ranked last",
+			siblings: []model.Siswa{
+				student(1, family(1), nil, date("2019-07-15"), "aktif"),
+				student(2, family(1), date("2014-05-05"), nil, "aktif"),
+				student(3, family(1), date("2014-05-05"), date("2020-07-13"), "aktif"),
+			},
+			want: map[uint]int{3: 1, 2: 2, 1: 3},
+		},
+		{
+			name: "inactive siblings not counted",
+			siblings: []model.Siswa{
+				student(1, family(1), date("2010-02-02"), nil, "lulus"),
+				student(2, family(1), date("2012-02-02"), nil, "pindah"),
+				student(3, family(1), date("2014-02-02"), nil, "aktif"),
+				student(4, family(1), date("2016-02-02"), nil, "aktif"),
+			},
+			want: map[uint]int{3: 1, 4: 2},
+		},
+		{
+			name: "students without family skipped",
+			siblings: []model.Siswa{
+				student(1, nil, date("2010-02-02"), nil, "aktif"),
+				student(2, family(1), date("2014-02-02"), nil, "aktif"),
+			},
+			want: map[uint]int{2: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rankSiblings(tt.siblings); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("rankSiblings = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
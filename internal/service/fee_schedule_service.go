@@ -0,0 +1,376 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/dto"
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/repository"
+	"github.com/hiuncy/spp-payment-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrFeeScheduleExists  = errors.New("jadwal tarif dengan tanggal berlaku tersebut sudah ada")
+	ErrFeeScheduleInUse   = errors.New("jadwal tarif sudah dipakai tagihan dan tidak dapat diubah atau dihapus")
+	ErrInvalidFeeSchedule = errors.New("jadwal tarif tidak valid")
+)
+
+type FeeScheduleService interface {
+	CreateFeeSchedule(input dto.FeeScheduleInput) (*model.JadwalTarif, error)
+	FindAllFeeSchedules(input dto.FindAllFeeSchedulesInput) ([]model.JadwalTarif, error)
+	FindFeeScheduleByID(id uint) (*model.JadwalTarif, error)
+	UpdateFeeSchedule(id uint, input dto.FeeScheduleInput) (*model.JadwalTarif, error)
+	DeleteFeeSchedule(id uint) error
+	PreviewFeeIncrease(input dto.FeeIncreasePreviewInput) (*FeeIncreasePreview, error)
+}
+
+type FeeIncreasePreview struct {
+	TahunAjaranID         uint                      `json:"tahun_ajaran_id"`
+	NamaTahunAjaran       string                    `json:"nama_tahun_ajaran"`
+	JenisBiayaID          uint                      `json:"jenis_biaya_id"`
+	NamaBiaya             string                    `json:"nama_biaya"`
+	JumlahTagihanPerSiswa int                       `json:"jumlah_tagihan_per_siswa"`
+	JumlahSiswa           int64                     `json:"jumlah_siswa"`
+	TotalSaatIni          float64                   `json:"total_saat_ini"`
+	TotalProyeksi         float64                   `json:"total_proyeksi"`
+	Selisih               float64                   `json:"selisih"`
+	PersenSelisih         float64                   `json:"persen_selisih"`
+	PerTingkat            []FeeIncreasePreviewLevel `json:"per_tingkat"`
+}
+
+type FeeIncreasePreviewLevel struct {
+	TingkatID     uint    `json:"tingkat_id"`
+	NamaTingkat   string  `json:"nama_tingkat"`
+	JumlahSiswa   int64   `json:"jumlah_siswa"`
+	TarifSaatIni  float64 `json:"tarif_saat_ini"`
+	TarifBaru     float64 `json:"tarif_baru"`
+	TotalSaatIni  float64 `json:"total_saat_ini"`
+	TotalProyeksi float64 `json:"total_proyeksi"`
+	Selisih       float64 `json:"selisih"`
+}
+
+type feeScheduleService struct {
+	repo             repository.FeeScheduleRepository
+	feeTypeRepo      repository.FeeTypeRepository
+	academicYearRepo repository.AcademicYearRepository
+	classLevelRepo   repository.ClassLevelRepository
+	periodRepo       repository.PeriodRepository
+	settingService   SettingService
+}
+
+func NewFeeScheduleService(repo repository.FeeScheduleRepository, feeTypeRepo repository.FeeTypeRepository, academicYearRepo repository.AcademicYearRepository, classLevelRepo repository.ClassLevelRepository, periodRepo repository.PeriodRepository, settingService SettingService) FeeScheduleService {
+	return &feeScheduleService{repo, feeTypeRepo, academicYearRepo, classLevelRepo, periodRepo, settingService}
+}
+
+func (s *feeScheduleService) CreateFeeSchedule(input dto.FeeScheduleInput) (*model.JadwalTarif, error) {
+	schedule := &model.JadwalTarif{}
+	if err := s.applyInput(schedule, input); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(schedule); err != nil {
+		return nil, feeScheduleWriteError(err)
+	}
+	return s.repo.FindByID(schedule.ID)
+}
+
+func (s *feeScheduleService) FindAllFeeSchedules(input dto.FindAllFeeSchedulesInput) ([]model.JadwalTarif, error) {
+	return s.repo.FindAll(utils.FindAllFeeSchedulesParams{
+		TahunAjaranID: input.TahunAjaranID,
+		JenisBiayaID:  input.JenisBiayaID,
+		TingkatID:     input.TingkatID,
+	})
+}
+
+func (s *feeScheduleService) FindFeeScheduleByID(id uint) (*model.JadwalTarif, error) {
+	return s.repo.FindByID(id)
+}
+
+func (s *feeScheduleService) UpdateFeeSchedule(id uint, input dto.FeeScheduleInput) (*model.JadwalTarif, error) {
+	schedule, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureUnused(schedule); err != nil {
+		return nil, err
+	}
+	if err := s.applyInput(schedule, input); err != nil {
+		return nil, err
+	}
+	if err := s.ensureUnused(schedule); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(schedule); err != nil {
+		return nil, feeScheduleWriteError(err)
+	}
+	return s.repo.FindByID(id)
+}
+
+func (s *feeScheduleService) DeleteFeeSchedule(id uint) error {
+	schedule, err := s.repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.ensureUnused(schedule); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+func (s *feeScheduleService) PreviewFeeIncrease(input dto.FeeIncreasePreviewInput) (*FeeIncreasePreview, error) {
+	target, err := s.academicYearRepo.FindByID(input.TahunAjaranID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: tahun ajaran tidak ditemukan", ErrInvalidFeeSchedule)
+		}
+		return nil, err
+	}
+	feeType, err := findFeeType(s.feeTypeRepo, input.JenisBiayaID)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := feeRates(input.Tarif)
+	if err != nil {
+		return nil, err
+	}
+
+	var current map[uint]float64
+	active, err := s.academicYearRepo.FindActive()
+	switch {
+	case err == nil:
+		current, err = effectiveRates(s.repo, feeType, active.ID, active.TanggalSelesai)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		current, err = effectiveRates(s.repo, feeType, 0, time.Time{})
+	}
+	if err != nil {
+		return nil, err
+	}
+	proposed, err := effectiveRates(s.repo, feeType, target.ID, target.TanggalSelesai)
+	if err != nil {
+		return nil, err
+	}
+	overridden := make(map[uint]bool, len(overrides))
+	for _, rate := range overrides {
+		proposed[rate.TingkatID] = rate.Jumlah
+		overridden[rate.TingkatID] = true
+	}
+
+	billsPerStudent, err := s.billsPerYear(feeType, target)
+	if err != nil {
+		return nil, err
+	}
+	levels, err := s.classLevelRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	students, err := s.repo.CountActiveStudentsByLevel()
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &FeeIncreasePreview{
+		TahunAjaranID:         target.ID,
+		NamaTahunAjaran:       target.NamaTahunAjaran,
+		JenisBiayaID:          feeType.ID,
+		NamaBiaya:             feeType.NamaBiaya,
+		JumlahTagihanPerSiswa: billsPerStudent,
+		PerTingkat:            []FeeIncreasePreviewLevel{},
+	}
+	for _, level := range levels {
+		if level.Status != "aktif" {
+			continue
+		}
+		currentRate, ok := current[level.ID]
+		if !ok && feeType.KodeBiaya == FeeTypeCodeSPP {
+			currentRate = level.BiayaSPP
+		}
+		newRate, ok := proposed[level.ID]
+		if !ok && feeType.KodeBiaya == FeeTypeCodeSPP {
+			newRate = level.BiayaSPP
+		}
+		if input.KenaikanPersen != 0 && !overridden[level.ID] {
+			newRate = math.Round(currentRate * (100 + input.KenaikanPersen) / 100)
+		}
+
+		count := students[level.ID]
+		summary := FeeIncreasePreviewLevel{
+			TingkatID:     level.ID,
+			NamaTingkat:   level.NamaTingkat,
+			JumlahSiswa:   count,
+			TarifSaatIni:  currentRate,
+			TarifBaru:     newRate,
+			TotalSaatIni:  currentRate * float64(count) * float64(billsPerStudent),
+			TotalProyeksi: newRate * float64(count) * float64(billsPerStudent),
+		}
+		summary.Selisih = summary.TotalProyeksi - summary.TotalSaatIni
+		preview.PerTingkat = append(preview.PerTingkat, summary)
+		preview.JumlahSiswa += count
+		preview.TotalSaatIni += summary.TotalSaatIni
+		preview.TotalProyeksi += summary.TotalProyeksi
+	}
+	preview.Selisih = preview.TotalProyeksi - preview.TotalSaatIni
+	if preview.TotalSaatIni > 0 {
+		preview.PersenSelisih = math.Round(preview.Selisih/preview.TotalSaatIni*10000) / 100
+	}
+	return preview, nil
+}
+
+func (s *feeScheduleService) applyInput(schedule *model.JadwalTarif, input dto.FeeScheduleInput) error {
+	if input.Jumlah <= 0 {
+		return fmt.Errorf("%w: tarif harus lebih dari 0", ErrInvalidFeeSchedule)
+	}
+	academicYear, err := s.academicYearRepo.FindByID(input.TahunAjaranID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: tahun ajaran tidak ditemukan", ErrInvalidFeeSchedule)
+		}
+		return err
+	}
+	start := academicYear.TanggalMulai
+	if input.BerlakuMulai != "" {
+		if start, err = time.Parse("2006-01-02", input.BerlakuMulai); err != nil {
+			return fmt.Errorf("%w: format tanggal berlaku harus YYYY-MM-DD", ErrInvalidFeeSchedule)
+		}
+	}
+	if start.Before(academicYear.TanggalMulai) || start.After(academicYear.TanggalSelesai) {
+		return fmt.Errorf("%w: tanggal berlaku harus di dalam tahun ajaran %s", ErrInvalidFeeSchedule, academicYear.NamaTahunAjaran)
+	}
+
+	schedule.JenisBiayaID = input.JenisBiayaID
+	schedule.TingkatID = input.TingkatID
+	schedule.TahunAjaranID = academicYear.ID
+	schedule.Jumlah = input.Jumlah
+	schedule.BerlakuMulai = start
+	schedule.Keterangan = nil
+	if note := strings.TrimSpace(input.Keterangan); note != "" {
+		schedule.Keterangan = &note
+	}
+	return nil
+}
+
+func (s *feeScheduleService) ensureUnused(schedule *model.JadwalTarif) error {
+	count, err := s.repo.CountBills(schedule)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrFeeScheduleInUse
+	}
+	return nil
+}
+
+func (s *feeScheduleService) billsPerYear(feeType *model.JenisBiaya, academicYear *model.TahunAjaran) (int, error) {
+	switch feeType.Periodisitas {
+	case FeeRecurrenceSemester:
+		return 2, nil
+	case FeeRecurrenceOnce:
+		return 1, nil
+	}
+
+	periods, err := s.periodRepo.FindAll(academicYear.ID, "")
+	if err != nil {
+		return 0, err
+	}
+	if len(periods) > 0 {
+		return len(periods), nil
+	}
+	values, err := s.settingService.GetSettingValues()
+	if err != nil {
+		return 0, err
+	}
+	months, err := periodMonths(values)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	start := academicYear.TanggalMulai
+	for month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, start.Location()); !month.After(academicYear.TanggalSelesai); month = month.AddDate(0, 1, 0) {
+		if len(months) == 0 || slices.Contains(months, int(month.Month())) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func effectiveRates(repo repository.FeeScheduleRepository, feeType *model.JenisBiaya, tahunAjaranID uint, date time.Time) (map[uint]float64, error) {
+	rates := make(map[uint]float64, len(feeType.Tarif))
+	for _, rate := range feeType.Tarif {
+		rates[rate.TingkatID] = rate.Jumlah
+	}
+	if tahunAjaranID == 0 {
+		return rates, nil
+	}
+	schedules, err := repo.FindEffective(feeType.ID, tahunAjaranID, date)
+	if err != nil {
+		return nil, err
+	}
+	for _, schedule := range schedules {
+		rates[schedule.TingkatID] = schedule.Jumlah
+	}
+	return rates, nil
+}
+
+func keepClassLevelFee(tx *gorm.DB, classLevelID uint, previous float64) ([]string, error) {
+	feeType, err := findFeeType(repository.NewFeeTypeRepository(tx), 0)
+	if errors.Is(err, ErrInvalidFeeType) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for _, rate := range feeType.Tarif {
+		if rate.TingkatID == classLevelID {
+			return nil, nil
+		}
+	}
+	academicYears, err := repository.NewAcademicYearRepository(tx).FindWithPeriods()
+	if err != nil {
+		return nil, err
+	}
+
+	repo := repository.NewFeeScheduleRepository(tx)
+	note := "Tarif sebelum perubahan biaya SPP tingkat kelas"
+	var pinned []string
+	for _, academicYear := range academicYears {
+		schedules, err := repo.FindAll(utils.FindAllFeeSchedulesParams{TahunAjaranID: academicYear.ID, JenisBiayaID: feeType.ID, TingkatID: classLevelID})
+		if err != nil {
+			return nil, err
+		}
+		covered := false
+		for _, schedule := range schedules {
+			if !schedule.BerlakuMulai.After(academicYear.TanggalMulai) {
+				covered = true
+				break
+			}
+		}
+		if covered {
+			continue
+		}
+		if err := repo.Create(&model.JadwalTarif{
+			JenisBiayaID:  feeType.ID,
+			TingkatID:     classLevelID,
+			TahunAjaranID: academicYear.ID,
+			Jumlah:        previous,
+			BerlakuMulai:  academicYear.TanggalMulai,
+			Keterangan:    &note,
+		}); err != nil {
+			return nil, err
+		}
+		pinned = append(pinned, academicYear.NamaTahunAjaran)
+	}
+	return pinned, nil
+}
+
+func feeScheduleWriteError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrFeeScheduleExists
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return fmt.Errorf("%w: jenis biaya atau tingkat kelas tidak ditemukan", ErrInvalidFeeSchedule)
+	}
+	return err
+}
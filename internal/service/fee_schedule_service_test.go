@@ -0,0 +1,96 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/repository"
+)
+
+func TestKeepClassLevelFeeSnapshotsYearsWithPeriods(t *testing.T) {
+	db := openTestDB(t)
+	suffix := time.Now().UnixNano()
+	level := &model.TingkatKelas{Tingkat: int(suffix % 1000000000), NamaTingkat: "Tingkat E2E", BiayaSPP: 200000, Status: "aktif"}
+	if err := db.Create(level).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	years := map[string]*model.TahunAjaran{
+		"past":      {TanggalMulai: today.AddDate(-2, 0, 0), TanggalSelesai: today.AddDate(-1, 0, -1)},
+		"current":   {TanggalMulai: today.AddDate(0, -1, 0), TanggalSelesai: today.AddDate(0, 11, 0)},
+		"future":    {TanggalMulai: today.AddDate(1, 0, 0), TanggalSelesai: today.AddDate(2, 0, -1)},
+		"scheduled": {TanggalMulai: today.AddDate(2, 0, 0), TanggalSelesai: today.AddDate(3, 0, -1)},
+		"empty":     {TanggalMulai: today.AddDate(3, 0, 0), TanggalSelesai: today.AddDate(4, 0, -1)},
+	}
+	for name, year := range years {
+		year.NamaTahunAjaran = fmt.Sprintf("%s-%d", name, suffix%10000000000)
+		if err := db.Create(year).Error; err != nil {
+			t.Fatal(err)
+		}
+		if name != "empty" {
+			createTestPeriod(t, db, year, 1)
+		}
+	}
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM jadwal_tarif WHERE tingkat_id = ?", level.ID)
+		for _, year := range years {
+			db.Exec("DELETE FROM periode_spp WHERE tahun_ajaran_id = ?", year.ID)
+			db.Delete(year)
+		}
+		db.Delete(level)
+	})
+
+	feeType, err := findFeeType(repository.NewFeeTypeRepository(db), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&model.JadwalTarif{JenisBiayaID: feeType.ID, TingkatID: level.ID, TahunAjaranID: years["scheduled"].ID, Jumlah: 180000, BerlakuMulai: years["scheduled"].TanggalMulai}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	pinned, err := keepClassLevelFee(db, level.ID, 150000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{"past": true, "current": true, "future": true, "scheduled": false, "empty": false} {
+		if got := slices.Contains(pinned, years[name].NamaTahunAjaran); got != want {
+			t.Errorf("%s year pinned = %v, want %v (pinned %v)", name, got, want, pinned)
+		}
+	}
+	tests := []struct {
+		year string
+		date time.Time
+		want float64
+		ok   bool
+	}{
+		{year: "past", date: years["past"].TanggalSelesai, want: 150000, ok: true},
+		{year: "current", date: years["current"].TanggalMulai, want: 150000, ok: true},
+		{year: "current", date: today, want: 150000, ok: true},
+		{year: "current", date: years["current"].TanggalSelesai, want: 150000, ok: true},
+		{year: "future", date: years["future"].TanggalSelesai, want: 150000, ok: true},
+		{year: "scheduled", date: years["scheduled"].TanggalSelesai, want: 180000, ok: true},
+		{year: "empty", date: years["empty"].TanggalSelesai},
+	}
+	repo := repository.NewFeeScheduleRepository(db)
+	for _, tt := range tests {
+		schedules, err := repo.FindEffective(feeType.ID, years[tt.year].ID, tt.date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rate float64
+		var ok bool
+		for _, schedule := range schedules {
+			if schedule.TingkatID == level.ID {
+				rate, ok = schedule.Jumlah, true
+			}
+		}
+		if ok != tt.ok || rate != tt.want {
+			t.Errorf("%s year on %s: rate = %.0f (scheduled %v), want %.0f (scheduled %v)", tt.year, tt.date.Format("2006-01-02"), rate, ok, tt.want, tt.ok)
+		}
+	}
+}
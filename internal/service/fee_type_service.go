@@ -0,0 +1,183 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hiuncy/spp-payment-api/internal/dto"
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	FeeTypeCodeSPP = "SPP"
+
+	FeeRecurrenceMonthly  = "bulanan"
+	FeeRecurrenceSemester = "semester"
+	FeeRecurrenceOnce     = "sekali"
+)
+
+var (
+	ErrFeeTypeExists  = errors.New("kode jenis biaya sudah digunakan")
+	ErrFeeTypeInUse   = errors.New("jenis biaya masih digunakan dan tidak dapat dihapus")
+	ErrInvalidFeeType = errors.New("jenis biaya tidak valid")
+)
+
+type FeeTypeService interface {
+	CreateFeeType(input dto.FeeTypeInput) (*model.JenisBiaya, error)
+	FindAllFeeTypes() ([]model.JenisBiaya, error)
+	FindFeeTypeByID(id uint) (*model.JenisBiaya, error)
+	UpdateFeeType(id uint, input dto.FeeTypeInput) (*model.JenisBiaya, error)
+	DeleteFeeType(id uint) error
+}
+
+type feeTypeService struct {
+	repo repository.FeeTypeRepository
+	db   *gorm.DB
+}
+
+func NewFeeTypeService(repo repository.FeeTypeRepository, db *gorm.DB) FeeTypeService {
+	return &feeTypeService{repo, db}
+}
+
+func (s *feeTypeService) CreateFeeType(input dto.FeeTypeInput) (*model.JenisBiaya, error) {
+	rates, err := feeRates(input.Tarif)
+	if err != nil {
+		return nil, err
+	}
+	code := strings.ToUpper(strings.TrimSpace(input.KodeBiaya))
+	if _, err := s.repo.FindByKode(code); err == nil {
+		return nil, ErrFeeTypeExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	feeType := &model.JenisBiaya{KodeBiaya: code, Status: "aktif"}
+	applyFeeTypeInput(feeType, input)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repoTx := repository.NewFeeTypeRepository(tx)
+		if err := repoTx.Create(feeType); err != nil {
+			return err
+		}
+		return replaceFeeRates(repoTx, feeType.ID, rates)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(feeType.ID)
+}
+
+func (s *feeTypeService) FindAllFeeTypes() ([]model.JenisBiaya, error) {
+	return s.repo.FindAll()
+}
+
+func (s *feeTypeService) FindFeeTypeByID(id uint) (*model.JenisBiaya, error) {
+	return s.repo.FindByID(id)
+}
+
+func (s *feeTypeService) UpdateFeeType(id uint, input dto.FeeTypeInput) (*model.JenisBiaya, error) {
+	feeType, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	rates, err := feeRates(input.Tarif)
+	if err != nil {
+		return nil, err
+	}
+	code := strings.ToUpper(strings.TrimSpace(input.KodeBiaya))
+	if feeType.KodeBiaya == FeeTypeCodeSPP && code != FeeTypeCodeSPP {
+		return nil, fmt.Errorf("%w: kode jenis biaya SPP tidak dapat diubah", ErrInvalidFeeType)
+	}
+	if existing, err := s.repo.FindByKode(code); err == nil && existing.ID != feeType.ID {
+		return nil, ErrFeeTypeExists
+	}
+
+	feeType.KodeBiaya = code
+	applyFeeTypeInput(feeType, input)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repoTx := repository.NewFeeTypeRepository(tx)
+		if err := repoTx.Update(feeType); err != nil {
+			return err
+		}
+		return replaceFeeRates(repoTx, feeType.ID, rates)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(id)
+}
+
+func (s *feeTypeService) DeleteFeeType(id uint) error {
+	feeType, err := s.repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if feeType.KodeBiaya == FeeTypeCodeSPP {
+		return ErrFeeTypeInUse
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		return repository.NewFeeTypeRepository(tx).Delete(id)
+	})
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		return ErrFeeTypeInUse
+	}
+	return err
+}
+
+func findFeeType(repo repository.FeeTypeRepository, id uint) (*model.JenisBiaya, error) {
+	var feeType *model.JenisBiaya
+	var err error
+	if id == 0 {
+		feeType, err = repo.FindByKode(FeeTypeCodeSPP)
+	} else {
+		feeType, err = repo.FindByID(id)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: jenis biaya tidak ditemukan", ErrInvalidFeeType)
+	}
+	return feeType, err
+}
+
+func feeRates(input []dto.FeeRateInput) ([]model.TarifBiaya, error) {
+	seen := make(map[uint]bool, len(input))
+	rates := make([]model.TarifBiaya, 0, len(input))
+	for _, rate := range input {
+		if seen[rate.TingkatID] {
+			return nil, fmt.Errorf("%w: tarif untuk tingkat kelas %d diisi lebih dari sekali", ErrInvalidFeeType, rate.TingkatID)
+		}
+		if rate.Jumlah <= 0 {
+			return nil, fmt.Errorf("%w: tarif harus lebih dari 0", ErrInvalidFeeType)
+		}
+		seen[rate.TingkatID] = true
+		rates = append(rates, model.TarifBiaya{TingkatID: rate.TingkatID, Jumlah: rate.Jumlah})
+	}
+	return rates, nil
+}
+
+func replaceFeeRates(repo repository.FeeTypeRepository, feeTypeID uint, rates []model.TarifBiaya) error {
+	for i := range rates {
+		rates[i].JenisBiayaID = feeTypeID
+	}
+	if err := repo.ReplaceRates(feeTypeID, rates); err != nil {
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return fmt.Errorf("%w: tingkat kelas tidak ditemukan", ErrInvalidFeeType)
+		}
+		return err
+	}
+	return nil
+}
+
+func applyFeeTypeInput(feeType *model.JenisBiaya, input dto.FeeTypeInput) {
+	feeType.NamaBiaya = strings.TrimSpace(input.NamaBiaya)
+	feeType.Periodisitas = input.Periodisitas
+	feeType.Wajib = input.Wajib
+	feeType.Keterangan = nil
+	if note := strings.TrimSpace(input.Keterangan); note != "" {
+		feeType.Keterangan = &note
+	}
+	if input.Status != "" {
+		feeType.Status = input.Status
+	}
+}
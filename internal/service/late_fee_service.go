@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	LateFeeStatusActive = "aktif"
+	LateFeeStatusWaived = "dihapuskan"
+
+	LateFeeTypeFlat    = "nominal"
+	LateFeeTypePercent = "persen"
+
+	SettingLateFeeEnabled   = "denda_aktif"
+	SettingLateFeeType      = "denda_jenis"
+	SettingLateFeeValue     = "denda_nilai"
+	SettingLateFeeGraceDays = "denda_masa_tenggang_hari"
+	SettingLateFeeInterval  = "denda_interval_hari"
+	SettingLateFeeCap       = "denda_maksimal"
+)
+
+var (
+	ErrLateFeeRunning        = errors.New("perhitungan denda sedang berjalan")
+	ErrLateFeeReasonRequired = errors.New("alasan penghapusan denda wajib diisi")
+	ErrLateFeeNotActive      = errors.New("denda sudah dihapuskan")
+	ErrLateFeeAlreadyPaid    = errors.New("denda sudah dibayar, gunakan refund untuk mengembalikan dana")
+)
+
+type LateFeeService interface {
+	Start(ctx context.Context)
+	Run() (*LateFeeRunResult, error)
+	FindByBillID(billID uint) ([]model.DendaTagihan, error)
+	Waive(billID, feeID, userID uint, reason string) (*model.TagihanSPP, error)
+}
+
+type LateFeeRunResult struct {
+	JumlahDiperiksa int     `json:"jumlah_diperiksa"`
+	JumlahDenda     int     `json:"jumlah_denda"`
+	TotalDenda      float64 `json:"total_denda"`
+	JumlahGagal     int     `json:"jumlah_gagal"`
+}
+
+type lateFeePolicy struct {
+	feeType   string
+	value     float64
+	graceDays int
+	interval  int
+	cap       float64
+}
+
+type lateFeeService struct {
+	repo           repository.LateFeeRepository
+	billRepo       repository.BillRepository
+	settingService SettingService
+	interval       time.Duration
+	db             *gorm.DB
+	now            func() time.Time
+	mu             sync.Mutex
+}
+
+func NewLateFeeService(repo repository.LateFeeRepository, billRepo repository.BillRepository, settingService SettingService, interval time.Duration, db *gorm.DB) LateFeeService {
+	return &lateFeeService{
+		repo:           repo,
+		billRepo:       billRepo,
+		settingService: settingService,
+		interval:       interval,
+		db:             db,
+		now:            time.Now,
+	}
+}
+
+func (s *lateFeeService) Start(ctx context.Context) {
+	runPeriodically(ctx, "late fee run", s.interval, func() (string, error) {
+		result, err := s.Run()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("checked=%d added=%d failed=%d", result.JumlahDiperiksa, result.JumlahDenda, result.JumlahGagal), nil
+	})
+}
+
+func (s *lateFeeService) Run() (*LateFeeRunResult, error) {
+	if !s.mu.TryLock() {
+		return nil, ErrLateFeeRunning
+	}
+	defer s.mu.Unlock()
+
+	result := &LateFeeRunResult{}
+	policy, err := s.policy()
+	if err != nil || policy == nil {
+		return result, err
+	}
+
+	now := s.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	billIDs, err := s.repo.FindOverdueBillIDs(today.AddDate(0, 0, -policy.graceDays), []string{BillStatusBelumBayar, BillStatusSebagian})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, billID := range billIDs {
+		result.JumlahDiperiksa++
+		added, total, err := s.applyToBill(billID, policy, today)
+		if err != nil {
+			log.Printf("late fee for bill %d failed: %v", billID, err)
+			result.JumlahGagal++
+			continue
+		}
+		result.JumlahDenda += added
+		result.TotalDenda += total
+	}
+	return result, nil
+}
+
+func (s *lateFeeService) FindByBillID(billID uint) ([]model.DendaTagihan, error) {
+	if _, err := s.billRepo.FindByID(billID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByTagihanID(billID)
+}
+
+func (s *lateFeeService) Waive(billID, feeID, userID uint, reason string) (*model.TagihanSPP, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrLateFeeReasonRequired
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		billRepoTx := repository.NewBillRepository(tx)
+		repoTx := repository.NewLateFeeRepository(tx)
+
+		bill, err := billRepoTx.FindByIDForUpdate(billID)
+		if err != nil {
+			return err
+		}
+		fee, err := repoTx.FindByIDForUpdate(feeID)
+		if err != nil {
+			return err
+		}
+		if fee.TagihanID != bill.ID {
+			return gorm.ErrRecordNotFound
+		}
+		if fee.StatusDenda != LateFeeStatusActive {
+			return ErrLateFeeNotActive
+		}
+		if bill.StatusPembayaran == BillStatusPending {
+			return ErrPendingPaymentExists
+		}
+		if toCents(bill.JumlahTagihan+bill.JumlahDenda-fee.Jumlah) < toCents(bill.JumlahTerbayar) {
+			return ErrLateFeeAlreadyPaid
+		}
+
+		now := s.now()
+		fee.StatusDenda = LateFeeStatusWaived
+		fee.AlasanPenghapusan = &reason
+		fee.DihapuskanOleh = &userID
+		fee.TanggalPenghapusan = &now
+		if err := repoTx.Update(fee); err != nil {
+			return err
+		}
+
+		bill.JumlahDenda -= fee.Jumlah
+		bill.StatusPembayaran = billStatusFor(billTotal(bill), bill.JumlahTerbayar, false)
+		return billRepoTx.Update(bill)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.billRepo.FindByID(billID)
+}
+
+func (s *lateFeeService) applyToBill(billID uint, policy *lateFeePolicy, today time.Time) (int, float64, error) {
+	var added int
+	var addedTotal float64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		billRepoTx := repository.NewBillRepository(tx)
+		repoTx := repository.NewLateFeeRepository(tx)
+
+		bill, err := billRepoTx.FindByIDForUpdate(billID)
+		if err != nil {
+			return err
+		}
+		if bill.StatusPembayaran != BillStatusBelumBayar && bill.StatusPembayaran != BillStatusSebagian {
+			return nil
+		}
+		fees, err := repoTx.FindByTagihanID(bill.ID)
+		if err != nil {
+			return err
+		}
+
+		dueDate := lateFeeDueDate(bill)
+		expected := policy.feeCount(dueDate, today)
+		if expected == 0 {
+			return nil
+		}
+
+		var activeTotal float64
+		lastSequence := 0
+		for _, fee := range fees {
+			if fee.StatusDenda == LateFeeStatusActive {
+				activeTotal += fee.Jumlah
+			}
+			if fee.Urutan > lastSequence {
+				lastSequence = fee.Urutan
+			}
+		}
+
+		for sequence := lastSequence + 1; sequence <= expected; sequence++ {
+			amount := policy.nextAmount(bill, activeTotal)
+			if toCents(amount) <= 0 {
+				break
+			}
+
+			fee := &model.DendaTagihan{
+				TagihanID:    bill.ID,
+				Urutan:       sequence,
+				TanggalDenda: dueDate.AddDate(0, 0, policy.graceDays+1+(sequence-1)*policy.interval),
+				Jumlah:       amount,
+				Keterangan:   policy.describe(sequence),
+				StatusDenda:  LateFeeStatusActive,
+			}
+			if err := repoTx.Create(fee); err != nil {
+				return err
+			}
+			activeTotal += amount
+			added++
+			addedTotal += amount
+		}
+
+		if toCents(activeTotal) == toCents(bill.JumlahDenda) {
+			return nil
+		}
+		bill.JumlahDenda = activeTotal
+		bill.StatusPembayaran = billStatusFor(billTotal(bill), bill.JumlahTerbayar, false)
+		return billRepoTx.Update(bill)
+	})
+	return added, addedTotal, err
+}
+
+func (s *lateFeeService) policy() (*lateFeePolicy, error) {
+	values, err := s.settingService.GetSettingValues()
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(values[SettingLateFeeEnabled]) != "true" {
+		return nil, nil
+	}
+
+	policy := &lateFeePolicy{feeType: LateFeeTypeFlat}
+	if strings.TrimSpace(values[SettingLateFeeType]) == LateFeeTypePercent {
+		policy.feeType = LateFeeTypePercent
+	}
+	policy.value, err = strconv.ParseFloat(strings.TrimSpace(values[SettingLateFeeValue]), 64)
+	if err != nil || policy.value <= 0 {
+		return nil, fmt.Errorf("pengaturan %s tidak valid", SettingLateFeeValue)
+	}
+	policy.graceDays, _ = strconv.Atoi(strings.TrimSpace(values[SettingLateFeeGraceDays]))
+	policy.interval, _ = strconv.Atoi(strings.TrimSpace(values[SettingLateFeeInterval]))
+	policy.cap, _ = strconv.ParseFloat(strings.TrimSpace(values[SettingLateFeeCap]), 64)
+	policy.graceDays = max(policy.graceDays, 0)
+	policy.interval = max(policy.interval, 0)
+	policy.cap = max(policy.cap, 0)
+	return policy, nil
+}
+
+func (p *lateFeePolicy) feeCount(dueDate, today time.Time) int {
+	daysLate := int(today.Sub(dueDate).Hours()/24) - p.graceDays
+	if daysLate <= 0 {
+		return 0
+	}
+	if p.interval == 0 {
+		return 1
+	}
+	return (daysLate-1)/p.interval + 1
+}
+
+func (p *lateFeePolicy) nextAmount(bill *model.TagihanSPP, activeTotal float64) float64 {
+	amount := p.amountFor(bill)
+	if p.cap > 0 && toCents(activeTotal+amount) > toCents(p.cap) {
+		amount = p.cap - activeTotal
+	}
+	return amount
+}
+
+func (p *lateFeePolicy) amountFor(bill *model.TagihanSPP) float64 {
+	if p.feeType == LateFeeTypePercent {
+		return math.Round(bill.JumlahTagihan * p.value / 100)
+	}
+	return math.Round(p.value)
+}
+
+func lateFeeDueDate(bill *model.TagihanSPP) time.Time {
+	dueDate := bill.TanggalJatuhTempo
+	unpaid := false
+	for _, installment := range bill.Cicilan {
+		if installment.StatusPembayaran == BillStatusLunas {
+			continue
+		}
+		if !unpaid || installment.TanggalJatuhTempo.Before(dueDate) {
+			dueDate = installment.TanggalJatuhTempo
+		}
+		unpaid = true
+	}
+	return time.Date(dueDate.Year(), dueDate.Month(), dueDate.Day(), 0, 0, 0, 0, time.Local)
+}
+
+func (p *lateFeePolicy) describe(sequence int) string {
+	if p.feeType == LateFeeTypePercent {
+		return fmt.Sprintf("Denda keterlambatan ke-%d (%s%% dari tagihan)", sequence, strconv.FormatFloat(p.value, 'f', -1, 64))
+	}
+	return fmt.Sprintf("Denda keterlambatan ke-%d", sequence)
+}
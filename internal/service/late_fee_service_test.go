@@ -0,0 +1,96 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/model"
+)
+
+func testDate(value string) time.Time {
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return date
+}
+
+func TestLateFeeCount(t *testing.T) {
+	dueDate := testDate("2025-07-10")
+	tests := []struct {
+		name      string
+		graceDays int
+		interval  int
+		today     string
+		want      int
+	}{
+		{name: "before due date", graceDays: 3, today: "2025-07-05", want: 0},
+		{name: "on due date", graceDays: 0, today: "2025-07-10", want: 0},
+		{name: "day after due date", graceDays: 0, today: "2025-07-11", want: 1},
+		{name: "last grace day", graceDays: 3, today: "2025-07-13", want: 0},
+		{name: "first day after grace", graceDays: 3, today: "2025-07-14", want: 1},
+		{name: "one-off fee long overdue", graceDays: 3, today: "2025-09-30", want: 1},
+		{name: "within first interval", graceDays: 3, interval: 7, today: "2025-07-20", want: 1},
+		{name: "second interval starts", graceDays: 3, interval: 7, today: "2025-07-21", want: 2},
+		{name: "fourth interval", graceDays: 3, interval: 7, today: "2025-08-10", want: 4},
+		{name: "across month end", interval: 30, today: "2025-09-08", want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := &lateFeePolicy{graceDays: tt.graceDays, interval: tt.interval}
+			if got := policy.feeCount(dueDate, testDate(tt.today)); got != tt.want {
+				t.Fatalf("feeCount = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLateFeeNextAmount(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      lateFeePolicy
+		bill        float64
+		activeTotal float64
+		want        float64
+	}{
+		{name: "flat without cap", policy: lateFeePolicy{feeType: LateFeeTypeFlat, value: 25000}, bill: 150000, activeTotal: 100000, want: 25000},
+		{name: "flat below cap", policy: lateFeePolicy{feeType: LateFeeTypeFlat, value: 25000, cap: 60000}, bill: 150000, want: 25000},
+		{name: "flat capped", policy: lateFeePolicy{feeType: LateFeeTypeFlat, value: 25000, cap: 60000}, bill: 150000, activeTotal: 50000, want: 10000},
+		{name: "cap reached", policy: lateFeePolicy{feeType: LateFeeTypeFlat, value: 25000, cap: 60000}, bill: 150000, activeTotal: 60000, want: 0},
+		{name: "percent of bill", policy: lateFeePolicy{feeType: LateFeeTypePercent, value: 2}, bill: 150000, want: 3000},
+		{name: "percent rounded", policy: lateFeePolicy{feeType: LateFeeTypePercent, value: 2.5}, bill: 150001, want: 3750},
+		{name: "percent capped", policy: lateFeePolicy{feeType: LateFeeTypePercent, value: 10, cap: 20000}, bill: 150000, activeTotal: 15000, want: 5000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bill := &model.TagihanSPP{JumlahTagihan: tt.bill}
+			if got := tt.policy.nextAmount(bill, tt.activeTotal); toCents(got) != toCents(tt.want) {
+				t.Fatalf("nextAmount = %.2f, want %.2f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLateFeeDueDate(t *testing.T) {
+	installment := func(due, status string) model.CicilanTagihan {
+		return model.CicilanTagihan{TanggalJatuhTempo: testDate(due), StatusPembayaran: status}
+	}
+	tests := []struct {
+		name    string
+		cicilan []model.CicilanTagihan
+		want    string
+	}{
+		{name: "without installments", want: "2025-07-31"},
+		{name: "first installment unpaid", cicilan: []model.CicilanTagihan{installment("2025-07-10", BillStatusBelumBayar), installment("2025-07-25", BillStatusBelumBayar)}, want: "2025-07-10"},
+		{name: "first installment paid", cicilan: []model.CicilanTagihan{installment("2025-07-10", BillStatusLunas), installment("2025-07-25", BillStatusSebagian)}, want: "2025-07-25"},
+		{name: "all installments paid", cicilan: []model.CicilanTagihan{installment("2025-07-10", BillStatusLunas), installment("2025-07-25", BillStatusLunas)}, want: "2025-07-31"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bill := &model.TagihanSPP{TanggalJatuhTempo: testDate("2025-07-31"), Cicilan: tt.cicilan}
+			if got := lateFeeDueDate(bill).Format("2006-01-02"); got != tt.want {
+				t.Fatalf("lateFeeDueDate = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/repository"
+)
+
+type LogService interface {
+	RecordActivity(userID *uint, aktivitas, detail, ipAddress, userAgent string) error
+}
+
+type logService struct {
+	repo repository.LogRepository
+}
+
+func NewLogService(repo repository.LogRepository) LogService {
+	return &logService{repo}
+}
+
+func (s *logService) RecordActivity(userID *uint, aktivitas, detail, ipAddress, userAgent string) error {
+	log := &model.LogAktivitas{
+		UserID:    userID,
+		Aktivitas: aktivitas,
+		Detail:    &detail,
+		IPAddress: &ipAddress,
+		UserAgent: &userAgent,
+	}
+	return s.repo.Create(log)
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/config"
+	"github.com/hiuncy/spp-payment-api/internal/dto"
+
+	"github.com/midtrans/midtrans-go"
+	"github.com/midtrans/midtrans-go/coreapi"
+	"github.com/midtrans/midtrans-go/snap"
+)
+
+type midtransGateway struct {
+	snapClient snap.Client
+	coreClient coreapi.Client
+	serverKey  string
+}
+
+func NewMidtransGateway(cfg *config.Config) PaymentGateway {
+	var client snap.Client
+	var coreClient coreapi.Client
+	env := midtrans.Sandbox
+	if cfg.MidtransEnvironment == "production" {
+		env = midtrans.Production
+	}
+
+	client.New(cfg.MidtransServerKey, env)
+	coreClient.New(cfg.MidtransServerKey, env)
+	if cfg.MidtransBaseURL != "" {
+		httpClient := &midtransBaseURLClient{
+			next:     midtrans.GetHttpClient(env),
+			baseURL:  cfg.MidtransBaseURL,
+			prefixes: []string{env.SnapURL(), env.BaseUrl()},
+		}
+		client.HttpClient = httpClient
+		coreClient.HttpClient = httpClient
+	}
+	return &midtransGateway{snapClient: client, coreClient: coreClient, serverKey: cfg.MidtransServerKey}
+}
+
+func (s *midtransGateway) Name() string {
+	return GatewayMidtrans
+}
+
+func (s *midtransGateway) CreateCharge(transaction dto.TransactionRequest) (string, error) {
+	var items []midtrans.ItemDetails
+	for _, item := range transaction.Items {
+		items = append(items, midtrans.ItemDetails{
+			ID:    item.ID,
+			Name:  item.Name,
+			Price: item.Price,
+			Qty:   item.Quantity,
+		})
+	}
+
+	req := &snap.Request{
+		TransactionDetails: midtrans.TransactionDetails{
+			OrderID:  transaction.OrderID,
+			GrossAmt: transaction.GrossAmount,
+		},
+		Items: &items,
+		CreditCard: &snap.CreditCardDetails{
+			Secure: true,
+		},
+	}
+	if transaction.Customer != nil {
+		req.CustomerDetail = &midtrans.CustomerDetails{
+			FName: transaction.Customer.Name,
+			Email: transaction.Customer.Email,
+			Phone: transaction.Customer.Phone,
+		}
+	}
+	for _, payment := range transaction.EnabledPayments {
+		req.EnabledPayments = append(req.EnabledPayments, snap.SnapPaymentType(payment))
+	}
+	if transaction.Expiry > 0 {
+		req.Expiry = &snap.ExpiryDetails{
+			StartTime: time.Now().Format("2006-01-02 15:04:05 -0700"),
+			Unit:      "minute",
+			Duration:  int64(transaction.Expiry / time.Minute),
+		}
+	}
+
+	token, err := s.snapClient.CreateTransactionToken(req)
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (s *midtransGateway) CreateVirtualAccount(transaction dto.TransactionRequest, bank string) (*dto.VirtualAccount, error) {
+	var items []midtrans.ItemDetails
+	for _, item := range transaction.Items {
+		items = append(items, midtrans.ItemDetails{
+			ID:    item.ID,
+			Name:  item.Name,
+			Price: item.Price,
+			Qty:   item.Quantity,
+		})
+	}
+
+	req := &coreapi.ChargeReq{
+		PaymentType: coreapi.PaymentTypeBankTransfer,
+		TransactionDetails: midtrans.TransactionDetails{
+			OrderID:  transaction.OrderID,
+			GrossAmt: transaction.GrossAmount,
+		},
+		Items: &items,
+		BankTransfer: &coreapi.BankTransferDetails{
+			Bank:     midtrans.Bank(bank),
+			VaNumber: transaction.VANumber,
+		},
+	}
+	if transaction.Customer != nil {
+		req.CustomerDetails = &midtrans.CustomerDetails{
+			FName: transaction.Customer.Name,
+			Email: transaction.Customer.Email,
+			Phone: transaction.Customer.Phone,
+		}
+	}
+	if transaction.Expiry > 0 {
+		req.CustomExpiry = &coreapi.CustomExpiry{
+			OrderTime:      time.Now().Format("2006-01-02 15:04:05 -0700"),
+			ExpiryDuration: int(transaction.Expiry / time.Minute),
+			Unit:           "minute",
+		}
+	}
+
+	resp, err := s.coreClient.ChargeTransaction(req)
+	if err != nil {
+		return nil, err
+	}
+
+	number := resp.PermataVaNumber
+	for _, va := range resp.VaNumbers {
+		if va.Bank == bank || number == "" {
+			number = va.VANumber
+		}
+	}
+	if number == "" {
+		return nil, fmt.Errorf("nomor virtual account tidak diterima dari Midtrans: %s", resp.StatusMessage)
+	}
+
+	rawResponse, _ := json.Marshal(resp)
+	account := &dto.VirtualAccount{
+		Bank:          bank,
+		Number:        number,
+		TransactionID: resp.TransactionID,
+		RawResponse:   string(rawResponse),
+	}
+	if expiresAt, err := parseGatewayTime(resp.ExpiryTime); err == nil {
+		account.ExpiresAt = &expiresAt
+	}
+	return account, nil
+}
+
+func (s *midtransGateway) GetTransactionStatus(orderID string) (*dto.PaymentStatusUpdate, error) {
+	resp, err := s.coreClient.CheckTransaction(orderID)
+	if err != nil {
+		if err.StatusCode == http.StatusNotFound {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	rawResponse, _ := json.Marshal(resp)
+	return &dto.PaymentStatusUpdate{
+		Gateway:           GatewayMidtrans,
+		OrderID:           resp.OrderID,
+		TransactionID:     resp.TransactionID,
+		TransactionStatus: resp.TransactionStatus,
+		FraudStatus:       resp.FraudStatus,
+		PaymentType:       resp.PaymentType,
+		GrossAmount:       resp.GrossAmount,
+		TransactionTime:   resp.TransactionTime,
+		SettlementTime:    resp.SettlementTime,
+		RawResponse:       string(rawResponse),
+	}, nil
+}
+
+func (s *midtransGateway) CancelTransaction(orderID string) error {
+	_, err := s.coreClient.CancelTransaction(orderID)
+	if err != nil {
+		if err.StatusCode == http.StatusNotFound {
+			return ErrTransactionNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *midtransGateway) RefundTransaction(orderID, refundKey string, amount int64, reason string) (string, error) {
+	resp, err := s.coreClient.RefundTransaction(orderID, &coreapi.RefundReq{
+		RefundKey: refundKey,
+		Amount:    amount,
+		Reason:    reason,
+	})
+	if err != nil {
+		if err.StatusCode == http.StatusNotFound {
+			return "", ErrTransactionNotFound
+		}
+		return "", err
+	}
+
+	rawResponse, _ := json.Marshal(resp)
+	return string(rawResponse), nil
+}
+
+func (s *midtransGateway) ParseNotification(rawBody []byte, headers http.Header) (*dto.PaymentStatusUpdate, error) {
+	var payload map[string]interface{}
+	if err := json.Unmarshal(rawBody, &payload); err != nil {
+		return nil, ErrInvalidNotificationPayload
+	}
+
+	orderID, _ := payload["order_id"].(string)
+	if orderID == "" {
+		return nil, ErrInvalidNotificationPayload
+	}
+	statusCode, _ := payload["status_code"].(string)
+	grossAmount, _ := payload["gross_amount"].(string)
+	signatureKey, _ := payload["signature_key"].(string)
+	if !s.verifySignature(orderID, statusCode, grossAmount, signatureKey) {
+		return nil, ErrInvalidSignature
+	}
+	if grossAmount == "" {
+		return nil, ErrAmountMismatch
+	}
+
+	update := &dto.PaymentStatusUpdate{
+		Gateway:     GatewayMidtrans,
+		OrderID:     orderID,
+		GrossAmount: grossAmount,
+		RawResponse: string(rawBody),
+	}
+	update.TransactionStatus, _ = payload["transaction_status"].(string)
+	update.FraudStatus, _ = payload["fraud_status"].(string)
+	update.TransactionID, _ = payload["transaction_id"].(string)
+	update.PaymentType, _ = payload["payment_type"].(string)
+	update.TransactionTime, _ = payload["transaction_time"].(string)
+	update.SettlementTime, _ = payload["settlement_time"].(string)
+	return update, nil
+}
+
+func (s *midtransGateway) verifySignature(orderID, statusCode, grossAmount, signatureKey string) bool {
+	if signatureKey == "" || s.serverKey == "" {
+		return false
+	}
+
+	hash := sha512.Sum512([]byte(orderID + statusCode + grossAmount + s.serverKey))
+	expected := hex.EncodeToString(hash[:])
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(signatureKey)) == 1
+}
+
+type midtransBaseURLClient struct {
+	next     midtrans.HttpClient
+	baseURL  string
+	prefixes []string
+}
+
+func (c *midtransBaseURLClient) Call(method string, url string, apiKey *string, options *midtrans.ConfigOptions, body io.Reader, result interface{}) *midtrans.Error {
+	for _, prefix := range c.prefixes {
+		if strings.HasPrefix(url, prefix) {
+			url = c.baseURL + strings.TrimPrefix(url, prefix)
+			break
+		}
+	}
+	return c.next.Call(method, url, apiKey, options, body, result)
+}
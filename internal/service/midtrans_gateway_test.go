@@ -0,0 +1,140 @@
+package service
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/hiuncy/spp-payment-api/internal/dto"
+	"github.com/hiuncy/spp-payment-api/internal/model"
+)
+
+const testServerKey = "SB-Mid-server-test"
+
+func midtransSignature(orderID, statusCode, grossAmount, serverKey string) string {
+	hash := sha512.Sum512([]byte(orderID + statusCode + grossAmount + serverKey))
+	return hex.EncodeToString(hash[:])
+}
+
+func midtransNotification(t *testing.T, fields map[string]string) []byte {
+	t.Helper()
+	body, err := json.Marshal(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestMidtransParseNotification(t *testing.T) {
+	valid := map[string]string{
+		"order_id":           "SPP-1-1700000000",
+		"status_code":        "200",
+		"gross_amount":       "150000.00",
+		"transaction_status": "settlement",
+		"transaction_id":     "trx-1",
+		"payment_type":       "bank_transfer",
+		"signature_key":      midtransSignature("SPP-1-1700000000", "200", "150000.00", testServerKey),
+	}
+	with := func(key, value string) map[string]string {
+		fields := make(map[string]string, len(valid))
+		for k, v := range valid {
+			fields[k] = v
+		}
+		fields[key] = value
+		return fields
+	}
+
+	tests := []struct {
+		name      string
+		serverKey string
+		body      []byte
+		wantErr   error
+	}{
+		{name: "valid payload", serverKey: testServerKey, body: midtransNotification(t, valid)},
+		{name: "tampered signature", serverKey: testServerKey, body: midtransNotification(t, with("signature_key", midtransSignature("SPP-1-1700000000", "200", "150000.00", "other-key"))), wantErr: ErrInvalidSignature},
+		{name: "tampered gross amount", serverKey: testServerKey, body: midtransNotification(t, with("gross_amount", "1000.00")), wantErr: ErrInvalidSignature},
+		{name: "tampered status code", serverKey: testServerKey, body: midtransNotification(t, with("status_code", "201")), wantErr: ErrInvalidSignature},
+		{name: "tampered order id", serverKey: testServerKey, body: midtransNotification(t, with("order_id", "SPP-2-1700000000")), wantErr: ErrInvalidSignature},
+		{name: "missing signature", serverKey: testServerKey, body: midtransNotification(t, with("signature_key", "")), wantErr: ErrInvalidSignature},
+		{name: "server key not configured", serverKey: "", body: midtransNotification(t, valid), wantErr: ErrInvalidSignature},
+		{name: "missing order id", serverKey: testServerKey, body: midtransNotification(t, with("order_id", "")), wantErr: ErrInvalidNotificationPayload},
+		{name: "invalid json", serverKey: testServerKey, body: []byte("{"), wantErr: ErrInvalidNotificationPayload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := &midtransGateway{serverKey: tt.serverKey}
+			update, err := gateway.ParseNotification(tt.body, nil)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if update.OrderID != valid["order_id"] || update.GrossAmount != valid["gross_amount"] || update.TransactionStatus != valid["transaction_status"] {
+				t.Fatalf("unexpected update: %+v", update)
+			}
+			if update.Gateway != GatewayMidtrans {
+				t.Fatalf("gateway = %q, want %q", update.Gateway, GatewayMidtrans)
+			}
+		})
+	}
+}
+
+func TestMatchesPaymentAmount(t *testing.T) {
+	tests := []struct {
+		name        string
+		grossAmount string
+		jumlahBayar float64
+		want        bool
+	}{
+		{name: "exact with decimals", grossAmount: "150000.00", jumlahBayar: 150000, want: true},
+		{name: "exact without decimals", grossAmount: "150000", jumlahBayar: 150000, want: true},
+		{name: "includes admin fee", grossAmount: "154000.00", jumlahBayar: 154000, want: true},
+		{name: "lower amount", grossAmount: "1000.00", jumlahBayar: 150000, want: false},
+		{name: "higher amount", grossAmount: "150000.01", jumlahBayar: 150000, want: false},
+		{name: "not a number", grossAmount: "seratus", jumlahBayar: 150000, want: false},
+		{name: "empty", grossAmount: "", jumlahBayar: 150000, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesPaymentAmount(tt.grossAmount, tt.jumlahBayar); got != tt.want {
+				t.Fatalf("matchesPaymentAmount(%q, %v) = %v, want %v", tt.grossAmount, tt.jumlahBayar, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPaymentStatusRejectsWrongAmount(t *testing.T) {
+	gateway := GatewayMidtrans
+	repo := &stubPaymentRepository{payments: map[string]*model.Pembayaran{
+		"SPP-1-1700000000": {OrderID: "SPP-1-1700000000", Gateway: &gateway, JumlahBayar: 150000, BiayaAdmin: 4000, StatusPembayaran: PaymentStatusPending},
+	}}
+	service := &paymentService{paymentRepo: repo}
+
+	tests := []struct {
+		name        string
+		grossAmount string
+	}{
+		{name: "without admin fee", grossAmount: "150000.00"},
+		{name: "lower amount", grossAmount: "1000.00"},
+		{name: "higher amount", grossAmount: "200000.00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.ApplyPaymentStatus(dto.PaymentStatusUpdate{
+				Gateway:           GatewayMidtrans,
+				OrderID:           "SPP-1-1700000000",
+				TransactionStatus: PaymentStatusSettlement,
+				GrossAmount:       tt.grossAmount,
+			})
+			if !errors.Is(err, ErrAmountMismatch) {
+				t.Fatalf("err = %v, want %v", err, ErrAmountMismatch)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/dto"
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/repository"
+	"github.com/hiuncy/spp-payment-api/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	NotificationStatusDiterima = "diterima"
+	NotificationStatusBerhasil = "berhasil"
+	NotificationStatusGagal    = "gagal"
+	NotificationStatusDitolak  = "ditolak"
+	NotificationStatusDuplikat = "duplikat"
+)
+
+var (
+	ErrInvalidNotificationPayload = errors.New("payload notifikasi tidak valid")
+	ErrNotificationNotRetryable   = errors.New("hanya notifikasi yang gagal diproses yang dapat diproses ulang")
+)
+
+type NotificationService interface {
+	HandleNotification(gatewayName string, rawBody []byte, headers http.Header) error
+	FindAllNotifications(input dto.FindAllNotificationsInput) ([]model.NotifikasiMidtrans, int64, error)
+	FindNotificationByID(id uint) (*model.NotifikasiMidtrans, error)
+	ReprocessNotification(id uint) (*model.NotifikasiMidtrans, error)
+}
+
+type notificationService struct {
+	repo           repository.NotificationRepository
+	paymentService PaymentService
+	gateways       PaymentGatewayRegistry
+}
+
+func NewNotificationService(repo repository.NotificationRepository, paymentService PaymentService, gateways PaymentGatewayRegistry) NotificationService {
+	return &notificationService{repo, paymentService, gateways}
+}
+
+func (s *notificationService) HandleNotification(gatewayName string, rawBody []byte, headers http.Header) error {
+	gateway, err := s.gateways.Get(gatewayName)
+	if err != nil {
+		return err
+	}
+
+	notification := &model.NotifikasiMidtrans{
+		Gateway:         gateway.Name(),
+		RawBody:         string(rawBody),
+		StatusProses:    NotificationStatusDiterima,
+		TanggalDiterima: time.Now(),
+	}
+	if headerBytes, err := json.Marshal(headers); err == nil {
+		headerJSON := string(headerBytes)
+		notification.Headers = &headerJSON
+	}
+
+	update, parseErr := gateway.ParseNotification(rawBody, headers)
+	if parseErr == nil {
+		notification.OrderID = update.OrderID
+		notification.TransactionStatus = update.TransactionStatus
+		if update.TransactionID != "" {
+			notification.TransactionID = &update.TransactionID
+		}
+	} else {
+		var payload map[string]interface{}
+		if json.Unmarshal(rawBody, &payload) == nil {
+			notification.OrderID, _ = payload["order_id"].(string)
+			notification.TransactionStatus, _ = payload["transaction_status"].(string)
+		}
+	}
+
+	if err := s.repo.Create(notification); err != nil {
+		return err
+	}
+
+	if parseErr != nil {
+		s.finish(notification, NotificationStatusDitolak, parseErr)
+		return parseErr
+	}
+
+	key := notification.OrderID + "|" + update.TransactionID + "|" + notification.TransactionStatus
+
+	existing, err := s.repo.FindByDedupKey(key)
+	if err == nil {
+		s.finish(notification, NotificationStatusDuplikat, nil)
+		if existing.StatusProses == NotificationStatusGagal {
+			return s.process(existing)
+		}
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	notification.KunciDeduplikasi = &key
+	if err := s.repo.Update(notification); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			notification.KunciDeduplikasi = nil
+			s.finish(notification, NotificationStatusDuplikat, nil)
+			return nil
+		}
+		return err
+	}
+
+	return s.process(notification)
+}
+
+func (s *notificationService) FindAllNotifications(input dto.FindAllNotificationsInput) ([]model.NotifikasiMidtrans, int64, error) {
+	if input.Page <= 0 {
+		input.Page = 1
+	}
+	if input.Limit <= 0 {
+		input.Limit = 10
+	}
+	params := utils.FindAllNotificationsParams{
+		Page:         input.Page,
+		Limit:        input.Limit,
+		OrderID:      input.OrderID,
+		StatusProses: input.StatusProses,
+	}
+	return s.repo.FindAll(params)
+}
+
+func (s *notificationService) FindNotificationByID(id uint) (*model.NotifikasiMidtrans, error) {
+	return s.repo.FindByID(id)
+}
+
+func (s *notificationService) ReprocessNotification(id uint) (*model.NotifikasiMidtrans, error) {
+	notification, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if notification.StatusProses != NotificationStatusGagal {
+		return nil, ErrNotificationNotRetryable
+	}
+
+	if err := s.process(notification); err != nil {
+		return notification, err
+	}
+	return notification, nil
+}
+
+func (s *notificationService) process(notification *model.NotifikasiMidtrans) error {
+	gateway, err := s.gateways.Get(notification.Gateway)
+	if err != nil {
+		s.finish(notification, NotificationStatusGagal, err)
+		return err
+	}
+
+	headers := http.Header{}
+	if notification.Headers != nil {
+		_ = json.Unmarshal([]byte(*notification.Headers), &headers)
+	}
+	update, err := gateway.ParseNotification([]byte(notification.RawBody), headers)
+	if err != nil {
+		s.finish(notification, NotificationStatusDitolak, err)
+		return err
+	}
+
+	notification.JumlahPercobaan++
+	err = s.paymentService.ApplyPaymentStatus(*update)
+	switch {
+	case err == nil:
+		s.finish(notification, NotificationStatusBerhasil, nil)
+	case errors.Is(err, ErrInvalidSignature), errors.Is(err, ErrAmountMismatch), errors.Is(err, ErrGatewayMismatch):
+		s.finish(notification, NotificationStatusDitolak, err)
+	default:
+		s.finish(notification, NotificationStatusGagal, err)
+	}
+	return err
+}
+
+func (s *notificationService) finish(notification *model.NotifikasiMidtrans, status string, reason error) {
+	now := time.Now()
+	notification.StatusProses = status
+	notification.TanggalDiproses = &now
+	notification.Keterangan = nil
+	if reason != nil {
+		message := reason.Error()
+		notification.Keterangan = &message
+	}
+	_ = s.repo.Update(notification)
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/repository"
+	"gorm.io/gorm"
+)
+
+type racingNotificationRepository struct {
+	repository.NotificationRepository
+}
+
+func (r *racingNotificationRepository) FindByDedupKey(key string) (*model.NotifikasiMidtrans, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func TestHandleNotificationAcknowledgesDuplicates(t *testing.T) {
+	db := openTestDB(t)
+
+	tests := []struct {
+		name string
+		repo repository.NotificationRepository
+	}{
+		{name: "dedup key lookup", repo: repository.NewNotificationRepository(db)},
+		{name: "unique key violation", repo: &racingNotificationRepository{repository.NewNotificationRepository(db)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orderID := fmt.Sprintf("DUP-%d", time.Now().UnixNano())
+			t.Cleanup(func() {
+				db.Exec("DELETE FROM notifikasi_midtrans WHERE order_id = ?", orderID)
+			})
+
+			payments := &stubPaymentService{}
+			gateways := NewPaymentGatewayRegistry(&stubSettingService{}, NewFakeGateway(testFakeGatewaySecret))
+			notificationService := NewNotificationService(tt.repo, payments, gateways)
+
+			body, err := json.Marshal(map[string]string{
+				"order_id":           orderID,
+				"transaction_id":     "trx-" + orderID,
+				"transaction_status": "settlement",
+				"gross_amount":       "150000.00",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			headers := http.Header{}
+			headers.Set(FakeGatewaySignatureHeader, fakeGatewaySignature(body, testFakeGatewaySecret))
+
+			for i := 0; i < 2; i++ {
+				if err := notificationService.HandleNotification(GatewayFake, body, headers); err != nil {
+					t.Fatalf("notification %d: %v", i+1, err)
+				}
+			}
+			if len(payments.applied) != 1 {
+				t.Fatalf("payment status applied %d times, want 1", len(payments.applied))
+			}
+
+			var notifications []model.NotifikasiMidtrans
+			if err := db.Where("order_id = ?", orderID).Order("id asc").Find(&notifications).Error; err != nil {
+				t.Fatal(err)
+			}
+			if len(notifications) != 2 {
+				t.Fatalf("got %d notifications, want 2", len(notifications))
+			}
+			if notifications[0].StatusProses != NotificationStatusBerhasil {
+				t.Fatalf("first notification status = %s, want %s", notifications[0].StatusProses, NotificationStatusBerhasil)
+			}
+			if notifications[1].StatusProses != NotificationStatusDuplikat || notifications[1].KunciDeduplikasi != nil {
+				t.Fatalf("second notification status = %s key = %v, want %s without key", notifications[1].StatusProses, notifications[1].KunciDeduplikasi, NotificationStatusDuplikat)
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/config"
+	"github.com/hiuncy/spp-payment-api/internal/midtranssim"
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/repository"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN belum diatur")
+	}
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{
+			SingularTable: true,
+		},
+		TranslateError: true,
+		Logger:         logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+	return db
+}
+
+func createTestStudent(t *testing.T, db *gorm.DB) (*model.Siswa, *model.TahunAjaran) {
+	t.Helper()
+	suffix := time.Now().UnixNano()
+
+	user := &model.Users{
+		Email:       fmt.Sprintf("siswa-%d@e2e.test", suffix),
+		Password:    "-",
+		RoleID:      3,
+		NamaLengkap: "Siswa E2E",
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	student := &model.Siswa{
+		UserID:       user.ID,
+		NISN:         fmt.Sprintf("%010d", suffix%10000000000),
+		KelasID:      1,
+		NamaLengkap:  "Siswa E2E",
+		JenisKelamin: "L",
+		TahunMasuk:   time.Now().Year(),
+	}
+	if err := db.Create(student).Error; err != nil {
+		t.Fatal(err)
+	}
+	year := &model.TahunAjaran{
+		NamaTahunAjaran: fmt.Sprintf("E2E-%d", suffix%100000000000),
+		TanggalMulai:    time.Now().AddDate(0, -1, 0),
+		TanggalSelesai:  time.Now().AddDate(0, 11, 0),
+	}
+	if err := db.Create(year).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM notifikasi_midtrans WHERE order_id IN (SELECT order_id FROM pembayaran WHERE siswa_id = ?)", student.ID)
+		db.Exec("DELETE FROM pembayaran WHERE siswa_id = ?", student.ID)
+		db.Exec("DELETE FROM potongan_tagihan WHERE tagihan_id IN (SELECT id FROM tagihan_spp WHERE siswa_id = ?)", student.ID)
+		db.Exec("DELETE FROM tagihan_spp WHERE siswa_id = ?", student.ID)
+		db.Exec("DELETE FROM potongan_siswa WHERE siswa_id = ?", student.ID)
+		db.Exec("DELETE FROM periode_spp WHERE tahun_ajaran_id = ?", year.ID)
+		db.Delete(year)
+		db.Delete(student)
+		db.Delete(user)
+	})
+	return student, year
+}
+
+func createTestPeriod(t *testing.T, db *gorm.DB, year *model.TahunAjaran, month int) *model.PeriodeSPP {
+	t.Helper()
+	period := &model.PeriodeSPP{
+		TahunAjaranID:     year.ID,
+		TahunAjaran:       year.NamaTahunAjaran,
+		Bulan:             month,
+		NamaBulan:         fmt.Sprintf("Bulan E2E %d", month),
+		TanggalMulai:      time.Now().AddDate(0, 0, -1),
+		TanggalSelesai:    time.Now().AddDate(0, 1, 0),
+		TanggalJatuhTempo: time.Now().AddDate(0, 0, 10),
+		Status:            PeriodStatusAktif,
+	}
+	if err := db.Create(period).Error; err != nil {
+		t.Fatal(err)
+	}
+	return period
+}
+
+func createTestBills(t *testing.T, db *gorm.DB, amounts ...float64) (*model.Siswa, []model.TagihanSPP) {
+	t.Helper()
+	student, year := createTestStudent(t, db)
+
+	var bills []model.TagihanSPP
+	for i, amount := range amounts {
+		period := createTestPeriod(t, db, year, i+1)
+		bill := model.TagihanSPP{
+			SiswaID:           student.ID,
+			PeriodeID:         period.ID,
+			JenisBiayaID:      1,
+			JumlahAwal:        amount,
+			JumlahTagihan:     amount,
+			StatusPembayaran:  BillStatusBelumBayar,
+			TanggalJatuhTempo: period.TanggalJatuhTempo,
+		}
+		if err := db.Create(&bill).Error; err != nil {
+			t.Fatal(err)
+		}
+		bills = append(bills, bill)
+	}
+	return student, bills
+}
+
+func createTestPayment(t *testing.T, db *gorm.DB, student *model.Siswa, status string, bills ...model.TagihanSPP) *model.Pembayaran {
+	t.Helper()
+	payment := &model.Pembayaran{
+		TagihanID:        bills[0].ID,
+		SiswaID:          student.ID,
+		OrderID:          fmt.Sprintf("TEST-%d", time.Now().UnixNano()),
+		StatusPembayaran: status,
+	}
+	for _, bill := range bills {
+		amount := billTotal(&bill)
+		payment.JumlahBayar += amount
+		payment.DetailTagihan = append(payment.DetailTagihan, model.PembayaranTagihan{TagihanID: bill.ID, Jumlah: amount})
+	}
+	if err := db.Create(payment).Error; err != nil {
+		t.Fatal(err)
+	}
+	return payment
+}
+
+func setTestSettings(t *testing.T, db *gorm.DB, values map[string]string) {
+	t.Helper()
+	for key, value := range values {
+		var setting model.Pengaturan
+		if err := db.Where("key_setting = ?", key).First(&setting).Error; err != nil {
+			t.Fatal(err)
+		}
+		previous := setting.ValueSetting
+		if err := db.Model(&setting).Update("value_setting", value).Error; err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			db.Model(&setting).Update("value_setting", previous)
+		})
+	}
+}
+
+type midtransTestEnv struct {
+	simulator      *midtranssim.Server
+	paymentRepo    repository.PaymentRepository
+	paymentService PaymentService
+}
+
+func newMidtransTestEnv(t *testing.T, db *gorm.DB) *midtransTestEnv {
+	t.Helper()
+	settingService := NewSettingService(repository.NewSettingRepository(db), db)
+
+	var notificationService NotificationService
+	notifications := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := notificationService.HandleNotification(GatewayMidtrans, body, r.Header); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(notifications.Close)
+
+	const serverKey = "SB-Mid-server-e2e"
+	simulator := midtranssim.NewServer(midtranssim.Options{ServerKey: serverKey, NotificationURL: notifications.URL})
+	t.Cleanup(simulator.Close)
+
+	gateways := NewPaymentGatewayRegistry(settingService, NewMidtransGateway(&config.Config{
+		MidtransServerKey: serverKey,
+		MidtransBaseURL:   simulator.URL,
+	}))
+	paymentRepo := repository.NewPaymentRepository(db)
+	paymentService := NewPaymentService(repository.NewBillRepository(db), repository.NewStudentRepository(db), paymentRepo, settingService, gateways, db)
+	notificationService = NewNotificationService(repository.NewNotificationRepository(db), paymentService, gateways)
+	return &midtransTestEnv{simulator: simulator, paymentRepo: paymentRepo, paymentService: paymentService}
+}
+
+func TestMidtransCheckoutSettlesBillEndToEnd(t *testing.T) {
+	db := openTestDB(t)
+	setTestSettings(t, db, map[string]string{
+		SettingPaymentGateway: GatewayMidtrans,
+		SettingPaymentMode:    PaymentModeSnap,
+	})
+	student, bills := createTestBills(t, db, 150000)
+	env := newMidtransTestEnv(t, db)
+
+	instruction, err := env.paymentService.Checkout([]uint{bills[0].ID}, student.UserID, "")
+	if err != nil {
+		t.Fatalf("checkout failed: %v", err)
+	}
+	if instruction.SnapToken == "" {
+		t.Fatal("checkout did not return a snap token")
+	}
+
+	orders := env.simulator.Orders()
+	if len(orders) != 1 || orders[0].GrossAmount != 150000 {
+		t.Fatalf("unexpected simulator orders: %+v", orders)
+	}
+	order, err := env.simulator.Pay(orders[0].OrderID, "bank_transfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(order.Notifications) != 1 || order.Notifications[0].StatusCode != http.StatusOK {
+		t.Fatalf("notification was not accepted: %+v", order.Notifications)
+	}
+
+	var settledBill model.TagihanSPP
+	if err := db.First(&settledBill, bills[0].ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if settledBill.StatusPembayaran != BillStatusLunas || toCents(settledBill.JumlahTerbayar) != toCents(150000) {
+		t.Fatalf("bill status = %s paid = %.2f, want %s 150000", settledBill.StatusPembayaran, settledBill.JumlahTerbayar, BillStatusLunas)
+	}
+	payment, err := env.paymentRepo.FindByOrderID(orders[0].OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payment.StatusPembayaran != PaymentStatusSettlement || payment.NomorKuitansi == nil {
+		t.Fatalf("payment status = %s receipt = %v, want settlement with receipt", payment.StatusPembayaran, payment.NomorKuitansi)
+	}
+}
+
+func TestFixedVirtualAccountCancelsPreviousOrderEndToEnd(t *testing.T) {
+	db := openTestDB(t)
+	setTestSettings(t, db, map[string]string{
+		SettingPaymentGateway: GatewayMidtrans,
+		SettingPaymentMode:    PaymentModeVirtualAccount,
+		SettingFixedVANumber:  "true",
+	})
+	student, bills := createTestBills(t, db, 150000, 150000)
+	env := newMidtransTestEnv(t, db)
+
+	first, err := env.paymentService.Checkout([]uint{bills[0].ID}, student.UserID, "bca_va")
+	if err != nil {
+		t.Fatalf("first checkout failed: %v", err)
+	}
+	second, err := env.paymentService.Checkout([]uint{bills[1].ID}, student.UserID, "bca_va")
+	if err != nil {
+		t.Fatalf("second checkout failed: %v", err)
+	}
+	if first.NomorVA == "" || first.NomorVA != second.NomorVA {
+		t.Fatalf("virtual account numbers = %q and %q, want the same fixed number", first.NomorVA, second.NomorVA)
+	}
+
+	orders := env.simulator.Orders()
+	if len(orders) != 2 {
+		t.Fatalf("unexpected simulator orders: %+v", orders)
+	}
+	statuses := make(map[string]string)
+	for _, order := range orders {
+		statuses[order.OrderID] = order.TransactionStatus
+	}
+
+	var payments []model.Pembayaran
+	if err := db.Where("siswa_id = ?", student.ID).Order("id asc").Find(&payments).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(payments) != 2 {
+		t.Fatalf("got %d payments, want 2", len(payments))
+	}
+	if payments[0].StatusPembayaran != PaymentStatusCancel || statuses[payments[0].OrderID] != PaymentStatusCancel {
+		t.Fatalf("previous order status = %s at gateway %s, want cancel", payments[0].StatusPembayaran, statuses[payments[0].OrderID])
+	}
+	if payments[1].StatusPembayaran != PaymentStatusPending || statuses[payments[1].OrderID] != PaymentStatusPending {
+		t.Fatalf("new order status = %s at gateway %s, want pending", payments[1].StatusPembayaran, statuses[payments[1].OrderID])
+	}
+
+	var firstBill model.TagihanSPP
+	if err := db.First(&firstBill, bills[0].ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if firstBill.StatusPembayaran != BillStatusBelumBayar {
+		t.Fatalf("first bill status = %s, want %s", firstBill.StatusPembayaran, BillStatusBelumBayar)
+	}
+}
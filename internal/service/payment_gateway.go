@@ -0,0 +1,79 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hiuncy/spp-payment-api/internal/dto"
+	"github.com/hiuncy/spp-payment-api/internal/model"
+)
+
+const (
+	GatewayMidtrans = "midtrans"
+	GatewayFake     = "fake"
+
+	SettingPaymentGateway = "payment_gateway"
+)
+
+var (
+	ErrTransactionNotFound = errors.New("transaksi tidak ditemukan di payment gateway")
+	ErrGatewayNotFound     = errors.New("payment gateway tidak dikenal atau tidak aktif")
+	ErrGatewayMismatch     = errors.New("notifikasi berasal dari payment gateway yang berbeda dengan pembayaran")
+)
+
+type PaymentGateway interface {
+	Name() string
+	CreateCharge(req dto.TransactionRequest) (string, error)
+	CreateVirtualAccount(req dto.TransactionRequest, bank string) (*dto.VirtualAccount, error)
+	GetTransactionStatus(orderID string) (*dto.PaymentStatusUpdate, error)
+	CancelTransaction(orderID string) error
+	RefundTransaction(orderID, refundKey string, amount int64, reason string) (string, error)
+	ParseNotification(rawBody []byte, headers http.Header) (*dto.PaymentStatusUpdate, error)
+}
+
+type PaymentGatewayRegistry interface {
+	Active() (PaymentGateway, error)
+	Get(name string) (PaymentGateway, error)
+}
+
+type paymentGatewayRegistry struct {
+	settingService SettingService
+	gateways       map[string]PaymentGateway
+}
+
+func NewPaymentGatewayRegistry(settingService SettingService, gateways ...PaymentGateway) PaymentGatewayRegistry {
+	registry := &paymentGatewayRegistry{settingService: settingService, gateways: make(map[string]PaymentGateway)}
+	for _, gateway := range gateways {
+		registry.gateways[gateway.Name()] = gateway
+	}
+	return registry
+}
+
+func (r *paymentGatewayRegistry) Active() (PaymentGateway, error) {
+	values, err := r.settingService.GetSettingValues()
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(values[SettingPaymentGateway])
+	if name == "" {
+		name = GatewayMidtrans
+	}
+	return r.Get(name)
+}
+
+func (r *paymentGatewayRegistry) Get(name string) (PaymentGateway, error) {
+	gateway, ok := r.gateways[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrGatewayNotFound, name)
+	}
+	return gateway, nil
+}
+
+func paymentGatewayName(payment *model.Pembayaran) string {
+	if payment.Gateway == nil || *payment.Gateway == "" {
+		return GatewayMidtrans
+	}
+	return *payment.Gateway
+}
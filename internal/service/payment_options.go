@@ -0,0 +1,120 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SettingEnabledPayments    = "metode_pembayaran_aktif"
+	SettingPaymentExpiry      = "batas_waktu_pembayaran_menit"
+	SettingPaymentAdminFee    = "biaya_admin_pembayaran"
+	SettingPaymentMode        = "mode_pembayaran_online"
+	SettingFixedVANumber      = "va_nomor_tetap"
+	PaymentModeSnap           = "snap"
+	PaymentModeVirtualAccount = "virtual_account"
+	adminFeeDefaultChannel    = "default"
+	adminFeeItemID            = "BIAYA-ADMIN"
+	defaultPaymentExpiryMins  = 24 * 60
+)
+
+var (
+	ErrPaymentChannelUnavailable  = errors.New("metode pembayaran tidak tersedia")
+	ErrVirtualAccountBankRequired = errors.New("pilih bank virtual account: bca_va, bni_va, bri_va, atau permata_va")
+)
+
+var virtualAccountBanks = map[string]string{
+	"bca_va":     "bca",
+	"bni_va":     "bni",
+	"bri_va":     "bri",
+	"permata_va": "permata",
+}
+
+var paymentChannels = []string{
+	"credit_card", "bca_va", "bni_va", "bri_va", "permata_va", "echannel", "other_va",
+	"gopay", "shopeepay", "qris", "indomaret", "alfamart", "akulaku",
+	"bca_klikpay", "bca_klikbca", "bri_epay", "cimb_clicks", "danamon_online",
+}
+
+type paymentOptions struct {
+	mode            string
+	enabledPayments []string
+	expiry          time.Duration
+	adminFees       map[string]float64
+	fixedVANumber   bool
+}
+
+func loadPaymentOptions(settingService SettingService) (*paymentOptions, error) {
+	values, err := settingService.GetSettingValues()
+	if err != nil {
+		return nil, err
+	}
+
+	options := &paymentOptions{
+		mode:          PaymentModeSnap,
+		expiry:        defaultPaymentExpiryMins * time.Minute,
+		adminFees:     make(map[string]float64),
+		fixedVANumber: strings.TrimSpace(values[SettingFixedVANumber]) == "true",
+	}
+	if strings.TrimSpace(values[SettingPaymentMode]) == PaymentModeVirtualAccount {
+		options.mode = PaymentModeVirtualAccount
+	}
+	for _, channel := range strings.Split(values[SettingEnabledPayments], ",") {
+		channel = strings.ToLower(strings.TrimSpace(channel))
+		if channel != "" && slices.Contains(paymentChannels, channel) {
+			options.enabledPayments = append(options.enabledPayments, channel)
+		}
+	}
+	if minutes, err := strconv.Atoi(strings.TrimSpace(values[SettingPaymentExpiry])); err == nil && minutes > 0 {
+		options.expiry = time.Duration(minutes) * time.Minute
+	}
+	if raw := strings.TrimSpace(values[SettingPaymentAdminFee]); raw != "" {
+		var fees map[string]float64
+		if err := json.Unmarshal([]byte(raw), &fees); err != nil {
+			return nil, fmt.Errorf("pengaturan %s tidak valid: %w", SettingPaymentAdminFee, err)
+		}
+		for channel, fee := range fees {
+			if fee > 0 {
+				options.adminFees[strings.ToLower(channel)] = math.Round(fee)
+			}
+		}
+	}
+	return options, nil
+}
+
+func (o *paymentOptions) channelsFor(channel string) ([]string, error) {
+	if channel == "" {
+		return o.enabledPayments, nil
+	}
+	if !slices.Contains(paymentChannels, channel) {
+		return nil, ErrPaymentChannelUnavailable
+	}
+	if len(o.enabledPayments) > 0 && !slices.Contains(o.enabledPayments, channel) {
+		return nil, ErrPaymentChannelUnavailable
+	}
+	return []string{channel}, nil
+}
+
+func (o *paymentOptions) virtualAccountBank(channel string) (string, error) {
+	bank, ok := virtualAccountBanks[channel]
+	if !ok {
+		return "", ErrVirtualAccountBankRequired
+	}
+	if _, err := o.channelsFor(channel); err != nil {
+		return "", err
+	}
+	return bank, nil
+}
+
+func (o *paymentOptions) adminFeeFor(channel string) float64 {
+	if fee, ok := o.adminFees[channel]; ok && channel != "" {
+		return fee
+	}
+	return o.adminFees[adminFeeDefaultChannel]
+}
@@ -1,73 +1,454 @@
 package service
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/hiuncy/spp-payment-api/internal/dto"
 	"github.com/hiuncy/spp-payment-api/internal/model"
 	"github.com/hiuncy/spp-payment-api/internal/repository"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrInvalidSignature     = errors.New("signature notifikasi tidak valid")
+	ErrAmountMismatch       = errors.New("jumlah pembayaran pada notifikasi tidak sesuai dengan tagihan")
+	ErrNoPendingPayment     = errors.New("tidak ada pembayaran yang sedang diproses untuk tagihan ini")
+	ErrPendingPaymentExists = errors.New("sebagian tagihan masih memiliki pembayaran yang sedang diproses, batalkan terlebih dahulu")
+	ErrInvalidManualPayment = errors.New("data pembayaran manual tidak valid")
+	ErrManualPaymentPending = errors.New("tagihan memiliki bukti transfer yang sedang diverifikasi bendahara")
+	ErrVirtualAccountInUse  = errors.New("nomor virtual account tetap sedang dipakai pembayaran lain yang masih diproses, coba beberapa saat lagi")
+)
+
+const (
+	PaymentMethodCash           = "tunai"
+	PaymentMethodManualTransfer = "transfer_manual"
+
+	maxCheckoutBills = 12
+)
+
 type PaymentService interface {
-	InitiatePayment(billID, userID uint) (string, error)
+	InitiatePayment(billID, userID uint, amount float64, channel string) (*dto.PaymentInstruction, error)
+	Checkout(billIDs []uint, userID uint, channel string) (*dto.PaymentInstruction, error)
+	CancelPendingPayment(billID, userID uint) error
 	GetPaymentHistory(userID uint) ([]model.Pembayaran, error)
-	ProcessMidtransNotification(notificationPayload map[string]any) error
+	ApplyPaymentStatus(update dto.PaymentStatusUpdate) error
+	RecordManualPayment(input dto.ManualPaymentInput) (*model.Pembayaran, error)
 }
 
 type paymentService struct {
-	billRepo    repository.BillRepository
-	studentRepo repository.StudentRepository
-	paymentRepo repository.PaymentRepository
-	midtransSvc MidtransService
-	db          *gorm.DB
+	billRepo       repository.BillRepository
+	studentRepo    repository.StudentRepository
+	paymentRepo    repository.PaymentRepository
+	settingService SettingService
+	gateways       PaymentGatewayRegistry
+	db             *gorm.DB
 }
 
-func NewPaymentService(billRepo repository.BillRepository, studentRepo repository.StudentRepository, paymentRepo repository.PaymentRepository, midtransSvc MidtransService, db *gorm.DB) PaymentService {
-	return &paymentService{billRepo, studentRepo, paymentRepo, midtransSvc, db}
+func NewPaymentService(billRepo repository.BillRepository, studentRepo repository.StudentRepository, paymentRepo repository.PaymentRepository, settingService SettingService, gateways PaymentGatewayRegistry, db *gorm.DB) PaymentService {
+	return &paymentService{billRepo, studentRepo, paymentRepo, settingService, gateways, db}
 }
 
-func (s *paymentService) InitiatePayment(billID, userID uint) (string, error) {
-	bill, err := s.billRepo.FindByID(billID)
-	if err != nil {
-		return "", errors.New("tagihan tidak ditemukan")
+func (s *paymentService) InitiatePayment(billID, userID uint, amount float64, channel string) (*dto.PaymentInstruction, error) {
+	return s.startPayment([]uint{billID}, userID, amount, channel)
+}
+
+func (s *paymentService) Checkout(billIDs []uint, userID uint, channel string) (*dto.PaymentInstruction, error) {
+	return s.startPayment(billIDs, userID, 0, channel)
+}
+
+func (s *paymentService) startPayment(billIDs []uint, userID uint, amount float64, channel string) (*dto.PaymentInstruction, error) {
+	billIDs = uniqueSortedIDs(billIDs)
+	if len(billIDs) == 0 {
+		return nil, errors.New("pilih minimal satu tagihan")
+	}
+	if len(billIDs) > maxCheckoutBills {
+		return nil, fmt.Errorf("maksimal %d tagihan dalam satu pembayaran", maxCheckoutBills)
+	}
+	if amount < 0 || amount != math.Trunc(amount) {
+		return nil, errors.New("jumlah pembayaran harus berupa bilangan bulat positif")
+	}
+	if amount > 0 && len(billIDs) > 1 {
+		return nil, errors.New("jumlah pembayaran sebagian hanya dapat digunakan untuk satu tagihan")
 	}
 
 	student, err := s.studentRepo.FindByUserID(userID)
 	if err != nil {
-		return "", errors.New("profil siswa tidak ditemukan")
+		return nil, errors.New("profil siswa tidak ditemukan")
+	}
+	gateway, err := s.gateways.Active()
+	if err != nil {
+		return nil, err
 	}
+	gatewayName := gateway.Name()
 
-	if bill.SiswaID != student.ID {
-		return "", errors.New("tagihan ini bukan milik Anda")
+	options, err := loadPaymentOptions(s.settingService)
+	if err != nil {
+		return nil, err
+	}
+	channel = strings.ToLower(strings.TrimSpace(channel))
+	enabledPayments, err := options.channelsFor(channel)
+	if err != nil {
+		return nil, err
 	}
+	var bank string
+	if options.mode == PaymentModeVirtualAccount {
+		if bank, err = options.virtualAccountBank(channel); err != nil {
+			return nil, err
+		}
+	}
+	adminFee := options.adminFeeFor(channel)
 
-	if bill.StatusPembayaran != "belum_bayar" {
-		return "", errors.New("tagihan ini sudah dibayar atau sedang diproses")
+	fixedVA := bank != "" && options.fixedVANumber
+	if err := s.closeStalePendings(billIDs); err != nil {
+		return nil, err
+	}
+	if fixedVA {
+		if err := s.closeOtherVirtualAccounts(student.ID, billIDs); err != nil {
+			return nil, err
+		}
 	}
 
-	orderID := fmt.Sprintf("SPP-%d-%d", bill.ID, time.Now().Unix())
-	newPayment := &model.Pembayaran{
-		TagihanID:        bill.ID,
-		SiswaID:          student.ID,
-		OrderID:          orderID,
-		JumlahBayar:      bill.JumlahTagihan,
-		StatusPembayaran: "pending",
+	var instruction *dto.PaymentInstruction
+	var newPayment *model.Pembayaran
+	var transaction dto.TransactionRequest
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		billRepoTx := repository.NewBillRepository(tx)
+		paymentRepoTx := repository.NewPaymentRepository(tx)
+
+		if fixedVA {
+			if _, err := repository.NewStudentRepository(tx).FindByIDForUpdate(student.ID); err != nil {
+				return err
+			}
+			others, err := paymentRepoTx.FindPendingBySiswaID(student.ID)
+			if err != nil {
+				return err
+			}
+			for _, other := range others {
+				if !isManualPayment(&other) && other.SnapToken == nil && !sameBillSet(&other, billIDs) {
+					return ErrVirtualAccountInUse
+				}
+			}
+		}
+
+		bills, err := billRepoTx.FindByIDsForUpdate(billIDs)
+		if err != nil {
+			return err
+		}
+		if len(bills) != len(billIDs) {
+			return errors.New("tagihan tidak ditemukan")
+		}
+
+		amounts := make(map[uint]float64)
+		for _, bill := range bills {
+			if bill.SiswaID != student.ID {
+				return errors.New("tagihan ini bukan milik Anda")
+			}
+			if bill.StatusPembayaran == BillStatusLunas {
+				return fmt.Errorf("tagihan %s %s sudah dibayar", bill.PeriodeSPP.NamaBulan, bill.PeriodeSPP.TahunAjaran)
+			}
+			if bill.StatusPembayaran == BillStatusDibebaskan {
+				return fmt.Errorf("tagihan %s %s sudah dibebaskan", bill.PeriodeSPP.NamaBulan, bill.PeriodeSPP.TahunAjaran)
+			}
+
+			outstanding := billTotal(&bill) - bill.JumlahTerbayar
+			switch {
+			case amount > 0:
+				if toCents(amount) > toCents(outstanding) {
+					return fmt.Errorf("jumlah pembayaran melebihi sisa tagihan (%.0f)", outstanding)
+				}
+				amounts[bill.ID] = amount
+			case len(bills) == 1:
+				amounts[bill.ID] = nextDueAmount(&bill)
+			default:
+				amounts[bill.ID] = outstanding
+			}
+		}
+
+		pendings, err := paymentRepoTx.FindPendingByTagihanIDs(billIDs)
+		if err != nil {
+			return err
+		}
+		for _, pending := range pendings {
+			if isManualPayment(&pending) || !isPendingActive(&pending) {
+				return ErrPendingPaymentExists
+			}
+			if (pending.SnapToken != nil || pending.NomorVA != nil) &&
+				paymentGatewayName(&pending) == gatewayName && sameBillSet(&pending, billIDs) && sameAmounts(&pending, amounts) &&
+				toCents(pending.BiayaAdmin) == toCents(adminFee) && paymentChannel(&pending) == channel &&
+				(pending.NomorVA != nil) == (bank != "") {
+				instruction = paymentInstruction(&pending)
+				return nil
+			}
+			return ErrPendingPaymentExists
+		}
+
+		orderID := fmt.Sprintf("SPP-%d-%d", bills[0].ID, time.Now().UnixNano())
+		expiresAt := time.Now().Add(options.expiry)
+		newPayment = &model.Pembayaran{
+			TagihanID:          bills[0].ID,
+			SiswaID:            student.ID,
+			OrderID:            orderID,
+			Gateway:            &gatewayName,
+			BiayaAdmin:         adminFee,
+			StatusPembayaran:   PaymentStatusPending,
+			TanggalKedaluwarsa: &expiresAt,
+		}
+		if channel != "" {
+			newPayment.KanalPembayaran = &channel
+		}
+		transaction = dto.TransactionRequest{
+			OrderID:         orderID,
+			Customer:        transactionCustomer(student),
+			EnabledPayments: enabledPayments,
+			Expiry:          options.expiry,
+		}
+		if fixedVA {
+			transaction.VANumber = student.NISN
+		}
+		for _, bill := range bills {
+			label := billLabel(&bill)
+			sppAmount := math.Min(amounts[bill.ID], math.Max(bill.JumlahTagihan-bill.JumlahTerbayar, 0))
+			lateFeeAmount := amounts[bill.ID] - sppAmount
+			newPayment.JumlahBayar += amounts[bill.ID]
+			newPayment.DetailTagihan = append(newPayment.DetailTagihan, model.PembayaranTagihan{
+				TagihanID: bill.ID,
+				Jumlah:    amounts[bill.ID],
+			})
+			if toCents(sppAmount) > 0 {
+				name := label
+				if toCents(sppAmount) < toCents(bill.JumlahTagihan) {
+					name = "Cicilan " + name
+				}
+				transaction.Items = append(transaction.Items, dto.TransactionItem{
+					ID:       fmt.Sprintf("TAGIHAN-%d", bill.ID),
+					Name:     name,
+					Price:    int64(math.Round(sppAmount)),
+					Quantity: 1,
+				})
+			}
+			if toCents(lateFeeAmount) > 0 {
+				transaction.Items = append(transaction.Items, dto.TransactionItem{
+					ID:       fmt.Sprintf("DENDA-%d", bill.ID),
+					Name:     "Denda " + label,
+					Price:    int64(math.Round(lateFeeAmount)),
+					Quantity: 1,
+				})
+			}
+		}
+		if adminFee > 0 {
+			name := "Biaya Admin"
+			if channel != "" {
+				name += " " + channel
+			}
+			transaction.Items = append(transaction.Items, dto.TransactionItem{
+				ID:       adminFeeItemID,
+				Name:     name,
+				Price:    int64(math.Round(adminFee)),
+				Quantity: 1,
+			})
+		}
+		transaction.GrossAmount = int64(math.Round(newPayment.JumlahBayar + newPayment.BiayaAdmin))
+
+		return paymentRepoTx.Create(newPayment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if instruction != nil {
+		return instruction, nil
 	}
 
-	if err := s.paymentRepo.Create(newPayment); err != nil {
-		return "", err
+	payment, err := s.openAtGateway(gateway, newPayment.OrderID, transaction, bank)
+	if err != nil {
+		failure := dto.PaymentStatusUpdate{OrderID: newPayment.OrderID, TransactionStatus: PaymentStatusFailure}
+		if applyErr := s.ApplyPaymentStatus(failure); applyErr != nil {
+			return nil, errors.Join(err, applyErr)
+		}
+		return nil, err
 	}
 
-	snapToken, err := s.midtransSvc.CreateTransaction(orderID, int64(bill.JumlahTagihan))
+	return paymentInstruction(payment), nil
+}
+
+func (s *paymentService) closeStalePendings(billIDs []uint) error {
+	pendings, err := s.paymentRepo.FindPendingByTagihanIDs(billIDs)
 	if err != nil {
-		_ = s.paymentRepo.Delete(newPayment.ID)
-		return "", err
+		return err
+	}
+	for _, pending := range pendings {
+		if isManualPayment(&pending) || isPendingActive(&pending) {
+			continue
+		}
+		update, err := s.closeAtGateway(&pending, PaymentStatusExpire)
+		if err != nil {
+			return err
+		}
+		if err := s.ApplyPaymentStatus(*update); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	return snapToken, nil
+func (s *paymentService) closeOtherVirtualAccounts(studentID uint, billIDs []uint) error {
+	pendings, err := s.paymentRepo.FindPendingBySiswaID(studentID)
+	if err != nil {
+		return err
+	}
+	for _, pending := range pendings {
+		if pending.SnapToken != nil || isManualPayment(&pending) || sameBillSet(&pending, billIDs) {
+			continue
+		}
+		if pending.NomorVA == nil && isPendingActive(&pending) {
+			continue
+		}
+		update, err := s.closeAtGateway(&pending, PaymentStatusCancel)
+		if err != nil {
+			return err
+		}
+		if err := s.ApplyPaymentStatus(*update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *paymentService) closeAtGateway(pending *model.Pembayaran, closedStatus string) (*dto.PaymentStatusUpdate, error) {
+	gateway, err := s.gateways.Get(paymentGatewayName(pending))
+	if err != nil {
+		return nil, err
+	}
+	closed := &dto.PaymentStatusUpdate{OrderID: pending.OrderID, TransactionStatus: closedStatus}
+
+	remote, err := gateway.GetTransactionStatus(pending.OrderID)
+	if errors.Is(err, ErrTransactionNotFound) {
+		return closed, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal memeriksa transaksi di payment gateway: %w", err)
+	}
+	if status, ok := normalizePaymentStatus(remote.TransactionStatus, remote.FraudStatus); ok && status != PaymentStatusPending {
+		remote.OrderID = pending.OrderID
+		return remote, nil
+	}
+
+	if err := gateway.CancelTransaction(pending.OrderID); err != nil && !errors.Is(err, ErrTransactionNotFound) {
+		return nil, fmt.Errorf("gagal membatalkan transaksi di payment gateway: %w", err)
+	}
+	return closed, nil
+}
+
+func (s *paymentService) openAtGateway(gateway PaymentGateway, orderID string, transaction dto.TransactionRequest, bank string) (*model.Pembayaran, error) {
+	var account *dto.VirtualAccount
+	var token string
+	var err error
+	if bank != "" {
+		account, err = gateway.CreateVirtualAccount(transaction, bank)
+	} else {
+		token, err = gateway.CreateCharge(transaction)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var payment *model.Pembayaran
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		paymentRepoTx := repository.NewPaymentRepository(tx)
+
+		payment, err = paymentRepoTx.FindByOrderIDForUpdate(orderID)
+		if err != nil {
+			return err
+		}
+		if account != nil {
+			payment.BankVA = &account.Bank
+			payment.NomorVA = &account.Number
+			if account.TransactionID != "" {
+				payment.TransactionID = &account.TransactionID
+			}
+			if account.ExpiresAt != nil {
+				payment.TanggalKedaluwarsa = account.ExpiresAt
+			}
+			if account.RawResponse != "" {
+				payment.MidtransResponse = &account.RawResponse
+			}
+		} else {
+			payment.SnapToken = &token
+		}
+		return paymentRepoTx.Update(payment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+func (s *paymentService) CancelPendingPayment(billID, userID uint) error {
+	student, err := s.studentRepo.FindByUserID(userID)
+	if err != nil {
+		return errors.New("profil siswa tidak ditemukan")
+	}
+
+	bill, err := s.billRepo.FindByID(billID)
+	if err != nil {
+		return errors.New("tagihan tidak ditemukan")
+	}
+	if bill.SiswaID != student.ID {
+		return errors.New("tagihan ini bukan milik Anda")
+	}
+
+	pendings, err := s.paymentRepo.FindPendingByTagihanIDs([]uint{bill.ID})
+	if err != nil {
+		return err
+	}
+	if len(pendings) == 0 {
+		return ErrNoPendingPayment
+	}
+
+	for _, pending := range pendings {
+		if isManualPayment(&pending) {
+			return ErrManualPaymentPending
+		}
+	}
+
+	var billIDs []uint
+	updates := make([]*dto.PaymentStatusUpdate, len(pendings))
+	for i := range pendings {
+		update, err := s.closeAtGateway(&pendings[i], PaymentStatusCancel)
+		if err != nil {
+			return err
+		}
+		if update.GrossAmount != "" && !matchesPaymentAmount(update.GrossAmount, pendings[i].JumlahBayar+pendings[i].BiayaAdmin) {
+			return ErrAmountMismatch
+		}
+		updates[i] = update
+		billIDs = append(billIDs, paymentBillIDs(&pendings[i])...)
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		billRepoTx := repository.NewBillRepository(tx)
+		paymentRepoTx := repository.NewPaymentRepository(tx)
+
+		bills, err := billRepoTx.FindByIDsForUpdate(uniqueSortedIDs(billIDs))
+		if err != nil {
+			return err
+		}
+		for i, pending := range pendings {
+			payment, err := paymentRepoTx.FindByOrderIDForUpdate(pending.OrderID)
+			if err != nil {
+				return err
+			}
+			if err := transitionPaymentBills(tx, bills, payment, *updates[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func (s *paymentService) GetPaymentHistory(userID uint) ([]model.Pembayaran, error) {
@@ -79,35 +460,367 @@ func (s *paymentService) GetPaymentHistory(userID uint) ([]model.Pembayaran, err
 	return s.paymentRepo.FindAllBySiswaID(student.ID)
 }
 
-func (s *paymentService) ProcessMidtransNotification(notificationPayload map[string]interface{}) error {
-	orderID, exists := notificationPayload["order_id"].(string)
-	if !exists {
-		return errors.New("invalid notification payload: missing order_id")
+func (s *paymentService) ApplyPaymentStatus(update dto.PaymentStatusUpdate) error {
+	current, err := s.paymentRepo.FindByOrderID(update.OrderID)
+	if err != nil {
+		return err
+	}
+	if update.Gateway != "" && update.Gateway != paymentGatewayName(current) {
+		return ErrGatewayMismatch
+	}
+	if update.GrossAmount != "" && !matchesPaymentAmount(update.GrossAmount, current.JumlahBayar+current.BiayaAdmin) {
+		return ErrAmountMismatch
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		billRepoTx := repository.NewBillRepository(tx)
+		paymentRepoTx := repository.NewPaymentRepository(tx)
+
+		bills, err := billRepoTx.FindByIDsForUpdate(paymentBillIDs(current))
+		if err != nil {
+			return err
+		}
+		payment, err := paymentRepoTx.FindByOrderIDForUpdate(update.OrderID)
+		if err != nil {
+			return err
+		}
+
+		return transitionPayment(tx, bills, payment, update)
+	})
+}
+
+func (s *paymentService) RecordManualPayment(input dto.ManualPaymentInput) (*model.Pembayaran, error) {
+	if input.MetodePembayaran != PaymentMethodCash && input.MetodePembayaran != PaymentMethodManualTransfer {
+		return nil, fmt.Errorf("%w: metode pembayaran harus tunai atau transfer_manual", ErrInvalidManualPayment)
+	}
+	if input.MetodePembayaran == PaymentMethodManualTransfer && input.NomorReferensi == "" {
+		return nil, fmt.Errorf("%w: nomor referensi wajib diisi untuk transfer manual", ErrInvalidManualPayment)
+	}
+	if input.Jumlah <= 0 {
+		return nil, fmt.Errorf("%w: jumlah pembayaran harus lebih dari 0", ErrInvalidManualPayment)
+	}
+
+	receivedAt := time.Now()
+	if input.TanggalDiterima != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", input.TanggalDiterima, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%w: format tanggal diterima harus YYYY-MM-DD", ErrInvalidManualPayment)
+		}
+		if parsed.After(receivedAt) {
+			return nil, fmt.Errorf("%w: tanggal diterima tidak boleh di masa depan", ErrInvalidManualPayment)
+		}
+		receivedAt = parsed
 	}
 
-	transactionStatus, _ := notificationPayload["transaction_status"].(string)
-	transactionID, _ := notificationPayload["transaction_id"].(string)
-	paymentType, _ := notificationPayload["payment_type"].(string)
-	settlementTime, _ := notificationPayload["settlement_time"].(string)
+	var payment *model.Pembayaran
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		billRepoTx := repository.NewBillRepository(tx)
+		paymentRepoTx := repository.NewPaymentRepository(tx)
 
-	responseBytes, err := json.Marshal(notificationPayload)
+		bills, err := billRepoTx.FindByIDsForUpdate([]uint{input.TagihanID})
+		if err != nil {
+			return err
+		}
+		if len(bills) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		bill := bills[0]
+		if bill.StatusPembayaran == BillStatusLunas {
+			return ErrBillAlreadyPaid
+		}
+		if bill.StatusPembayaran == BillStatusDibebaskan {
+			return ErrBillWaived
+		}
+		if toCents(input.Jumlah) > toCents(billTotal(&bill)-bill.JumlahTerbayar) {
+			return fmt.Errorf("%w: jumlah pembayaran melebihi sisa tagihan (%.0f)", ErrInvalidManualPayment, billTotal(&bill)-bill.JumlahTerbayar)
+		}
+
+		pendings, err := paymentRepoTx.FindPendingByTagihanIDs([]uint{bill.ID})
+		if err != nil {
+			return err
+		}
+		if len(pendings) > 0 {
+			return ErrPendingPaymentExists
+		}
+
+		payment = &model.Pembayaran{
+			TagihanID:         bill.ID,
+			SiswaID:           bill.SiswaID,
+			OrderID:           fmt.Sprintf("MANUAL-%d-%d", bill.ID, time.Now().UnixNano()),
+			JumlahBayar:       input.Jumlah,
+			StatusPembayaran:  PaymentStatusPending,
+			TanggalPembayaran: &receivedAt,
+			TanggalSettlement: &receivedAt,
+			DiterimaOleh:      &input.DiterimaOleh,
+			DetailTagihan: []model.PembayaranTagihan{
+				{TagihanID: bill.ID, Jumlah: input.Jumlah},
+			},
+		}
+		if input.NomorReferensi != "" {
+			payment.NomorReferensi = &input.NomorReferensi
+		}
+		if input.Keterangan != "" {
+			payment.Keterangan = &input.Keterangan
+		}
+		if err := paymentRepoTx.Create(payment); err != nil {
+			return err
+		}
+
+		update := dto.PaymentStatusUpdate{
+			OrderID:           payment.OrderID,
+			TransactionStatus: PaymentStatusSettlement,
+			PaymentType:       input.MetodePembayaran,
+		}
+		return transitionPayment(tx, bills, payment, update)
+	})
 	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+func transitionPayment(tx *gorm.DB, bills []model.TagihanSPP, payment *model.Pembayaran, update dto.PaymentStatusUpdate) error {
+	billRepoTx := repository.NewBillRepository(tx)
+	paymentRepoTx := repository.NewPaymentRepository(tx)
+
+	newStatus, ok := normalizePaymentStatus(update.TransactionStatus, update.FraudStatus)
+	if !ok {
+		return fmt.Errorf("status transaksi tidak dikenal: %s", update.TransactionStatus)
+	}
+	if !canTransitionPayment(payment.StatusPembayaran, newStatus) {
+		return nil
+	}
+
+	previousStatus := payment.StatusPembayaran
+	previousRefund := payment.JumlahRefund
+	payment.StatusPembayaran = newStatus
+	if newStatus == PaymentStatusRefund {
+		payment.JumlahRefund = payment.JumlahBayar
+	}
+	if update.TransactionID != "" {
+		payment.TransactionID = &update.TransactionID
+	}
+	if update.PaymentType != "" {
+		payment.MetodePembayaran = &update.PaymentType
+	}
+	if transactionTime, err := parseGatewayTime(update.TransactionTime); err == nil {
+		payment.TanggalPembayaran = &transactionTime
+	}
+	if settlementTime, err := parseGatewayTime(update.SettlementTime); err == nil {
+		payment.TanggalSettlement = &settlementTime
+	}
+	if update.RawResponse != "" {
+		payment.MidtransResponse = &update.RawResponse
+	}
+	if isPaymentFinal(newStatus) && payment.NomorKuitansi == nil {
+		if err := assignReceiptNumber(tx, payment); err != nil {
+			return err
+		}
+	}
+	if err := paymentRepoTx.Update(payment); err != nil {
 		return err
 	}
-	midtransResponse := string(responseBytes)
 
-	err = s.db.Exec("CALL UpdateStatusPembayaran(?, ?, ?, ?, ?, ?)",
-		orderID,
-		transactionStatus,
-		transactionID,
-		paymentType,
-		settlementTime,
-		midtransResponse,
-	).Error
+	for i := range bills {
+		bill := &bills[i]
+		for j := range payment.DetailTagihan {
+			detail := &payment.DetailTagihan[j]
+			if detail.TagihanID != bill.ID {
+				continue
+			}
+			bill.JumlahTerbayar += settledAmountDelta(previousStatus, newStatus, detail.Jumlah-detail.JumlahRefund)
+			if newStatus == PaymentStatusRefund && toCents(detail.JumlahRefund) != toCents(detail.Jumlah) {
+				detail.JumlahRefund = detail.Jumlah
+				if err := paymentRepoTx.UpdateDetail(detail); err != nil {
+					return err
+				}
+			}
+		}
+		if len(payment.DetailTagihan) == 0 && payment.TagihanID == bill.ID {
+			bill.JumlahTerbayar += settledAmountDelta(previousStatus, newStatus, payment.JumlahBayar-previousRefund)
+		}
+		if bill.JumlahTerbayar < 0 {
+			bill.JumlahTerbayar = 0
+		}
 
-	if err != nil {
-		return fmt.Errorf("gagal menjalankan stored procedure: %w", err)
+		others, err := paymentRepoTx.FindOtherByTagihanID(bill.ID, payment.ID)
+		if err != nil {
+			return err
+		}
+		hasPending := newStatus == PaymentStatusPending
+		for _, other := range others {
+			if other.StatusPembayaran == PaymentStatusPending {
+				hasPending = true
+			}
+		}
+
+		bill.StatusPembayaran = billStatusFor(billTotal(bill), bill.JumlahTerbayar, hasPending)
+		if err := billRepoTx.Update(bill); err != nil {
+			return err
+		}
+		if err := allocateInstallments(billRepoTx, bill); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func transitionPaymentBills(tx *gorm.DB, bills []model.TagihanSPP, payment *model.Pembayaran, update dto.PaymentStatusUpdate) error {
+	ids := make(map[uint]bool)
+	for _, id := range paymentBillIDs(payment) {
+		ids[id] = true
+	}
+	var paymentBills []model.TagihanSPP
+	for _, bill := range bills {
+		if ids[bill.ID] {
+			paymentBills = append(paymentBills, bill)
+		}
+	}
+	if err := transitionPayment(tx, paymentBills, payment, update); err != nil {
+		return err
+	}
+	for _, updated := range paymentBills {
+		for i := range bills {
+			if bills[i].ID == updated.ID {
+				bills[i] = updated
+			}
+		}
+	}
+	return nil
+}
+
+func assignReceiptNumber(tx *gorm.DB, payment *model.Pembayaran) error {
+	settledAt := time.Now()
+	if payment.TanggalSettlement != nil {
+		settledAt = *payment.TanggalSettlement
+	} else if payment.TanggalPembayaran != nil {
+		settledAt = *payment.TanggalPembayaran
 	}
 
+	number, err := repository.NewReceiptRepository(tx).NextNumber(settledAt.Year())
+	if err != nil {
+		return err
+	}
+	receiptNumber := fmt.Sprintf("KW/%d/%06d", settledAt.Year(), number)
+	payment.NomorKuitansi = &receiptNumber
 	return nil
 }
+
+func isPendingActive(payment *model.Pembayaran) bool {
+	return payment.TanggalKedaluwarsa != nil && time.Now().Before(*payment.TanggalKedaluwarsa)
+}
+
+func isManualPayment(payment *model.Pembayaran) bool {
+	if payment.MetodePembayaran == nil {
+		return false
+	}
+	return *payment.MetodePembayaran == PaymentMethodCash || *payment.MetodePembayaran == PaymentMethodManualTransfer
+}
+
+func nextDueAmount(bill *model.TagihanSPP) float64 {
+	for _, installment := range bill.Cicilan {
+		if toCents(installment.JumlahTerbayar) < toCents(installment.Jumlah) {
+			return installment.Jumlah - installment.JumlahTerbayar
+		}
+	}
+	return billTotal(bill) - bill.JumlahTerbayar
+}
+
+func uniqueSortedIDs(ids []uint) []uint {
+	seen := make(map[uint]bool)
+	var result []uint
+	for _, id := range ids {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+func detailBillIDs(details []model.PembayaranTagihan) []uint {
+	var ids []uint
+	for _, detail := range details {
+		ids = append(ids, detail.TagihanID)
+	}
+	return uniqueSortedIDs(ids)
+}
+
+func paymentBillIDs(payment *model.Pembayaran) []uint {
+	if len(payment.DetailTagihan) == 0 {
+		return []uint{payment.TagihanID}
+	}
+	return detailBillIDs(payment.DetailTagihan)
+}
+
+func sameBillSet(payment *model.Pembayaran, billIDs []uint) bool {
+	ids := paymentBillIDs(payment)
+	if len(ids) != len(billIDs) {
+		return false
+	}
+	for i := range ids {
+		if ids[i] != billIDs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sameAmounts(payment *model.Pembayaran, amounts map[uint]float64) bool {
+	for _, detail := range payment.DetailTagihan {
+		if toCents(detail.Jumlah) != toCents(amounts[detail.TagihanID]) {
+			return false
+		}
+	}
+	if len(payment.DetailTagihan) == 0 {
+		return toCents(payment.JumlahBayar) == toCents(amounts[payment.TagihanID])
+	}
+	return true
+}
+
+func paymentChannel(payment *model.Pembayaran) string {
+	if payment.KanalPembayaran == nil {
+		return ""
+	}
+	return *payment.KanalPembayaran
+}
+
+func paymentInstruction(payment *model.Pembayaran) *dto.PaymentInstruction {
+	instruction := &dto.PaymentInstruction{TanggalKedaluwarsa: payment.TanggalKedaluwarsa}
+	if payment.SnapToken != nil {
+		instruction.SnapToken = *payment.SnapToken
+	}
+	if payment.NomorVA != nil {
+		instruction.NomorVA = *payment.NomorVA
+	}
+	if payment.BankVA != nil {
+		instruction.Bank = *payment.BankVA
+	}
+	return instruction
+}
+
+func transactionCustomer(student *model.Siswa) *dto.TransactionCustomer {
+	customer := &dto.TransactionCustomer{
+		Name:  student.NamaOrangtua,
+		Email: student.User.Email,
+		Phone: student.TeleponOrangtua,
+	}
+	if customer.Name == "" {
+		customer.Name = student.NamaLengkap
+	}
+	return customer
+}
+
+func matchesPaymentAmount(grossAmount string, jumlahBayar float64) bool {
+	amount, err := strconv.ParseFloat(grossAmount, 64)
+	if err != nil {
+		return false
+	}
+	return math.Round(amount*100) == math.Round(jumlahBayar*100)
+}
+
+func parseGatewayTime(value string) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02 15:04:05", value, time.Local)
+}
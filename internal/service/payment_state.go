@@ -0,0 +1,133 @@
+package service
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hiuncy/spp-payment-api/internal/model"
+)
+
+const (
+	PaymentStatusPending       = "pending"
+	PaymentStatusCapture       = "capture"
+	PaymentStatusSettlement    = "settlement"
+	PaymentStatusDeny          = "deny"
+	PaymentStatusCancel        = "cancel"
+	PaymentStatusExpire        = "expire"
+	PaymentStatusFailure       = "failure"
+	PaymentStatusRefund        = "refund"
+	PaymentStatusPartialRefund = "partial_refund"
+
+	BillStatusBelumBayar = "belum_bayar"
+	BillStatusPending    = "pending"
+	BillStatusSebagian   = "sebagian"
+	BillStatusLunas      = "lunas"
+	BillStatusDibebaskan = "dibebaskan"
+
+	fraudStatusChallenge = "challenge"
+)
+
+var paymentTransitions = map[string][]string{
+	PaymentStatusPending: {
+		PaymentStatusCapture,
+		PaymentStatusSettlement,
+		PaymentStatusDeny,
+		PaymentStatusCancel,
+		PaymentStatusExpire,
+		PaymentStatusFailure,
+	},
+	PaymentStatusCapture: {
+		PaymentStatusSettlement,
+		PaymentStatusDeny,
+		PaymentStatusCancel,
+		PaymentStatusRefund,
+		PaymentStatusPartialRefund,
+	},
+	PaymentStatusSettlement: {
+		PaymentStatusRefund,
+		PaymentStatusPartialRefund,
+	},
+	PaymentStatusPartialRefund: {
+		PaymentStatusPartialRefund,
+		PaymentStatusRefund,
+	},
+}
+
+func normalizePaymentStatus(transactionStatus, fraudStatus string) (string, bool) {
+	switch transactionStatus {
+	case fraudStatusChallenge:
+		return PaymentStatusPending, true
+	case PaymentStatusCapture:
+		if fraudStatus == fraudStatusChallenge {
+			return PaymentStatusPending, true
+		}
+		return transactionStatus, true
+	case PaymentStatusPending, PaymentStatusSettlement, PaymentStatusDeny,
+		PaymentStatusCancel, PaymentStatusExpire, PaymentStatusFailure, PaymentStatusRefund, PaymentStatusPartialRefund:
+		return transactionStatus, true
+	}
+	return "", false
+}
+
+func canTransitionPayment(from, to string) bool {
+	for _, next := range paymentTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+func isPaymentSettled(status string) bool {
+	switch status {
+	case PaymentStatusCapture, PaymentStatusSettlement, PaymentStatusPartialRefund:
+		return true
+	}
+	return false
+}
+
+func isPaymentFinal(status string) bool {
+	switch status {
+	case PaymentStatusSettlement, PaymentStatusPartialRefund:
+		return true
+	}
+	return false
+}
+
+func settledAmountDelta(from, to string, amount float64) float64 {
+	switch {
+	case !isPaymentSettled(from) && isPaymentSettled(to):
+		return amount
+	case isPaymentSettled(from) && !isPaymentSettled(to):
+		return -amount
+	}
+	return 0
+}
+
+func billStatusFor(jumlahTagihan, jumlahTerbayar float64, hasPending bool) string {
+	switch {
+	case toCents(jumlahTerbayar) >= toCents(jumlahTagihan):
+		return BillStatusLunas
+	case hasPending:
+		return BillStatusPending
+	case toCents(jumlahTerbayar) > 0:
+		return BillStatusSebagian
+	}
+	return BillStatusBelumBayar
+}
+
+func billTotal(bill *model.TagihanSPP) float64 {
+	return bill.JumlahTagihan + bill.JumlahDenda
+}
+
+func billLabel(bill *model.TagihanSPP) string {
+	name := bill.JenisBiaya.NamaBiaya
+	if name == "" {
+		name = FeeTypeCodeSPP
+	}
+	return fmt.Sprintf("%s %s %s", name, bill.PeriodeSPP.NamaBulan, bill.PeriodeSPP.TahunAjaran)
+}
+
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
@@ -0,0 +1,123 @@
+package service
+
+import "testing"
+
+func TestCanTransitionPayment(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{PaymentStatusPending, PaymentStatusCapture, true},
+		{PaymentStatusPending, PaymentStatusSettlement, true},
+		{PaymentStatusPending, PaymentStatusDeny, true},
+		{PaymentStatusPending, PaymentStatusCancel, true},
+		{PaymentStatusPending, PaymentStatusExpire, true},
+		{PaymentStatusPending, PaymentStatusFailure, true},
+		{PaymentStatusPending, PaymentStatusPending, false},
+		{PaymentStatusPending, PaymentStatusRefund, false},
+		{PaymentStatusCapture, PaymentStatusSettlement, true},
+		{PaymentStatusCapture, PaymentStatusRefund, true},
+		{PaymentStatusCapture, PaymentStatusCancel, true},
+		{PaymentStatusCapture, PaymentStatusDeny, true},
+		{PaymentStatusCapture, PaymentStatusPending, false},
+		{PaymentStatusCapture, PaymentStatusExpire, false},
+		{PaymentStatusSettlement, PaymentStatusRefund, true},
+		{PaymentStatusSettlement, PaymentStatusPartialRefund, true},
+		{PaymentStatusSettlement, PaymentStatusPending, false},
+		{PaymentStatusSettlement, PaymentStatusCapture, false},
+		{PaymentStatusSettlement, PaymentStatusCancel, false},
+		{PaymentStatusSettlement, PaymentStatusExpire, false},
+		{PaymentStatusSettlement, PaymentStatusSettlement, false},
+		{PaymentStatusPartialRefund, PaymentStatusPartialRefund, true},
+		{PaymentStatusPartialRefund, PaymentStatusRefund, true},
+		{PaymentStatusPartialRefund, PaymentStatusSettlement, false},
+		{PaymentStatusRefund, PaymentStatusSettlement, false},
+		{PaymentStatusRefund, PaymentStatusPartialRefund, false},
+		{PaymentStatusRefund, PaymentStatusPending, false},
+		{PaymentStatusDeny, PaymentStatusSettlement, false},
+		{PaymentStatusCancel, PaymentStatusSettlement, false},
+		{PaymentStatusCancel, PaymentStatusPending, false},
+		{PaymentStatusExpire, PaymentStatusSettlement, false},
+		{PaymentStatusExpire, PaymentStatusPending, false},
+		{PaymentStatusFailure, PaymentStatusSettlement, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			if got := canTransitionPayment(tt.from, tt.to); got != tt.want {
+				t.Fatalf("canTransitionPayment(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizePaymentStatus(t *testing.T) {
+	tests := []struct {
+		transactionStatus, fraudStatus string
+		want                           string
+		wantOK                         bool
+	}{
+		{"capture", "accept", PaymentStatusCapture, true},
+		{"capture", "challenge", PaymentStatusPending, true},
+		{"challenge", "", PaymentStatusPending, true},
+		{"settlement", "accept", PaymentStatusSettlement, true},
+		{"expire", "", PaymentStatusExpire, true},
+		{"partial_refund", "", PaymentStatusPartialRefund, true},
+		{"authorize", "", "", false},
+		{"", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.transactionStatus+"/"+tt.fraudStatus, func(t *testing.T) {
+			got, ok := normalizePaymentStatus(tt.transactionStatus, tt.fraudStatus)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("normalizePaymentStatus(%q, %q) = (%q, %v), want (%q, %v)", tt.transactionStatus, tt.fraudStatus, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSettledAmountDelta(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     float64
+	}{
+		{PaymentStatusPending, PaymentStatusSettlement, 150000},
+		{PaymentStatusPending, PaymentStatusCapture, 150000},
+		{PaymentStatusCapture, PaymentStatusSettlement, 0},
+		{PaymentStatusSettlement, PaymentStatusRefund, -150000},
+		{PaymentStatusSettlement, PaymentStatusPartialRefund, 0},
+		{PaymentStatusCapture, PaymentStatusCancel, -150000},
+		{PaymentStatusCapture, PaymentStatusDeny, -150000},
+		{PaymentStatusCapture, PaymentStatusRefund, -150000},
+		{PaymentStatusPartialRefund, PaymentStatusRefund, -150000},
+		{PaymentStatusPending, PaymentStatusExpire, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			if got := settledAmountDelta(tt.from, tt.to, 150000); got != tt.want {
+				t.Fatalf("settledAmountDelta(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPaymentFinal(t *testing.T) {
+	tests := []struct {
+		status string
+		want   bool
+	}{
+		{PaymentStatusSettlement, true},
+		{PaymentStatusPartialRefund, true},
+		{PaymentStatusCapture, false},
+		{PaymentStatusPending, false},
+		{PaymentStatusCancel, false},
+		{PaymentStatusDeny, false},
+		{PaymentStatusRefund, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			if got := isPaymentFinal(tt.status); got != tt.want {
+				t.Fatalf("isPaymentFinal(%q) = %v, want %v", tt.status, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,46 +1,101 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/hiuncy/spp-payment-api/internal/model"
 	"github.com/hiuncy/spp-payment-api/internal/repository"
 	"github.com/hiuncy/spp-payment-api/internal/dto"
+	"gorm.io/gorm"
 )
 
+const (
+	PeriodStatusBelumAktif = "belum_aktif"
+	PeriodStatusAktif      = "aktif"
+	PeriodStatusSelesai    = "selesai"
+
+	SettingPeriodAutoGenerateBills = "periode_generate_tagihan_otomatis"
+)
+
+var (
+	ErrPeriodExists           = errors.New("periode untuk tahun ajaran dan bulan tersebut sudah ada")
+	ErrInvalidPeriod          = errors.New("periode tidak valid")
+	ErrPeriodClosed           = errors.New("periode sudah selesai, tagihan tidak dapat di-generate")
+	ErrPeriodInUse            = errors.New("periode sudah memiliki tagihan dan tidak dapat dihapus")
+	ErrPeriodLifecycleRunning = errors.New("pembaruan status periode sedang berjalan")
+)
+
+var monthNames = [...]string{"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"}
+
 type PeriodService interface {
 	CreatePeriod(input dto.CreatePeriodInput) (*model.PeriodeSPP, error)
-	FindAllPeriods(tahunAjaran string) ([]model.PeriodeSPP, error)
+	FindAllPeriods(tahunAjaranID uint, tahunAjaran string) ([]model.PeriodeSPP, error)
 	FindPeriodByID(id uint) (*model.PeriodeSPP, error)
 	UpdatePeriod(id uint, input dto.UpdatePeriodInput) (*model.PeriodeSPP, error)
 	DeletePeriod(id uint) error
+	Start(ctx context.Context)
+	RunLifecycle() (*PeriodLifecycleResult, error)
+}
+
+type PeriodLifecycleResult struct {
+	JumlahDiaktifkan    int `json:"jumlah_diaktifkan"`
+	JumlahDiselesaikan  int `json:"jumlah_diselesaikan"`
+	JumlahTagihanDibuat int `json:"jumlah_tagihan_dibuat"`
+	JumlahGagal         int `json:"jumlah_gagal"`
 }
 
 type periodService struct {
-	repo repository.PeriodRepository
+	repo             repository.PeriodRepository
+	academicYearRepo repository.AcademicYearRepository
+	billService      BillService
+	settingService   SettingService
+	interval         time.Duration
+	now              func() time.Time
+	mu               sync.Mutex
 }
 
-func NewPeriodService(repo repository.PeriodRepository) PeriodService {
-	return &periodService{repo}
+func NewPeriodService(repo repository.PeriodRepository, academicYearRepo repository.AcademicYearRepository, billService BillService, settingService SettingService, interval time.Duration) PeriodService {
+	return &periodService{
+		repo:             repo,
+		academicYearRepo: academicYearRepo,
+		billService:      billService,
+		settingService:   settingService,
+		interval:         interval,
+		now:              time.Now,
+	}
 }
 
 func (s *periodService) CreatePeriod(input dto.CreatePeriodInput) (*model.PeriodeSPP, error) {
-	_, err := s.repo.FindByTahunAjaranAndBulan(input.TahunAjaran, input.Bulan)
+	academicYear, err := s.academicYear(input.TahunAjaranID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.repo.FindByTahunAjaranAndBulan(academicYear.NamaTahunAjaran, input.Bulan)
 	if err == nil {
-		return nil, errors.New("periode untuk tahun ajaran dan bulan tersebut sudah ada")
+		return nil, ErrPeriodExists
 	}
 
-	tglMulai, _ := time.Parse("2006-01-02", input.TanggalMulai)
-	tglSelesai, _ := time.Parse("2006-01-02", input.TanggalSelesai)
+	tglMulai, tglSelesai, tglJatuhTempo, err := periodDates(input.TanggalMulai, input.TanggalSelesai, input.TanggalJatuhTempo)
+	if err != nil {
+		return nil, err
+	}
 
 	newPeriod := &model.PeriodeSPP{
-		TahunAjaran:    input.TahunAjaran,
-		Bulan:          input.Bulan,
-		NamaBulan:      input.NamaBulan,
-		TanggalMulai:   tglMulai,
-		TanggalSelesai: tglSelesai,
-		Status:         "belum_aktif",
+		TahunAjaranID:     academicYear.ID,
+		TahunAjaran:       academicYear.NamaTahunAjaran,
+		Bulan:             input.Bulan,
+		NamaBulan:         monthName(input.Bulan),
+		TanggalMulai:      tglMulai,
+		TanggalSelesai:    tglSelesai,
+		TanggalJatuhTempo: tglJatuhTempo,
+		Status:            PeriodStatusBelumAktif,
 	}
 
 	if err := s.repo.Create(newPeriod); err != nil {
@@ -49,8 +104,8 @@ func (s *periodService) CreatePeriod(input dto.CreatePeriodInput) (*model.Period
 	return newPeriod, nil
 }
 
-func (s *periodService) FindAllPeriods(tahunAjaran string) ([]model.PeriodeSPP, error) {
-	return s.repo.FindAll(tahunAjaran)
+func (s *periodService) FindAllPeriods(tahunAjaranID uint, tahunAjaran string) ([]model.PeriodeSPP, error) {
+	return s.repo.FindAll(tahunAjaranID, tahunAjaran)
 }
 
 func (s *periodService) FindPeriodByID(id uint) (*model.PeriodeSPP, error) {
@@ -62,14 +117,25 @@ func (s *periodService) UpdatePeriod(id uint, input dto.UpdatePeriodInput) (*mod
 	if err != nil {
 		return nil, err
 	}
-	tglMulai, _ := time.Parse("2006-01-02", input.TanggalMulai)
-	tglSelesai, _ := time.Parse("2006-01-02", input.TanggalSelesai)
+	academicYear, err := s.academicYear(input.TahunAjaranID)
+	if err != nil {
+		return nil, err
+	}
+	if existing, err := s.repo.FindByTahunAjaranAndBulan(academicYear.NamaTahunAjaran, input.Bulan); err == nil && existing.ID != period.ID {
+		return nil, ErrPeriodExists
+	}
+	tglMulai, tglSelesai, tglJatuhTempo, err := periodDates(input.TanggalMulai, input.TanggalSelesai, input.TanggalJatuhTempo)
+	if err != nil {
+		return nil, err
+	}
 
-	period.TahunAjaran = input.TahunAjaran
+	period.TahunAjaranID = academicYear.ID
+	period.TahunAjaran = academicYear.NamaTahunAjaran
 	period.Bulan = input.Bulan
-	period.NamaBulan = input.NamaBulan
+	period.NamaBulan = monthName(input.Bulan)
 	period.TanggalMulai = tglMulai
 	period.TanggalSelesai = tglSelesai
+	period.TanggalJatuhTempo = tglJatuhTempo
 	period.Status = input.Status
 
 	if err := s.repo.Update(period); err != nil {
@@ -83,5 +149,126 @@ func (s *periodService) DeletePeriod(id uint) error {
 	if err != nil {
 		return err
 	}
-	return s.repo.Delete(id)
+	count, err := s.repo.CountBills(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrPeriodInUse
+	}
+	if err := s.repo.Delete(id); err != nil {
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return ErrPeriodInUse
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *periodService) Start(ctx context.Context) {
+	runPeriodically(ctx, "period lifecycle run", s.interval, func() (string, error) {
+		result, err := s.RunLifecycle()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("activated=%d closed=%d bills=%d failed=%d", result.JumlahDiaktifkan, result.JumlahDiselesaikan, result.JumlahTagihanDibuat, result.JumlahGagal), nil
+	})
+}
+
+func (s *periodService) RunLifecycle() (*PeriodLifecycleResult, error) {
+	if !s.mu.TryLock() {
+		return nil, ErrPeriodLifecycleRunning
+	}
+	defer s.mu.Unlock()
+
+	values, err := s.settingService.GetSettingValues()
+	if err != nil {
+		return nil, err
+	}
+	autoGenerate, _ := strconv.ParseBool(strings.TrimSpace(values[SettingPeriodAutoGenerateBills]))
+
+	now := s.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	result := &PeriodLifecycleResult{}
+
+	closing, err := s.repo.FindDueForClosing(today)
+	if err != nil {
+		return nil, err
+	}
+	for _, period := range closing {
+		if err := s.repo.UpdateStatus(period.ID, PeriodStatusSelesai); err != nil {
+			log.Printf("closing period %d failed: %v", period.ID, err)
+			result.JumlahGagal++
+			continue
+		}
+		result.JumlahDiselesaikan++
+	}
+
+	activating, err := s.repo.FindDueForActivation(today)
+	if err != nil {
+		return nil, err
+	}
+	for _, period := range activating {
+		if err := s.repo.UpdateStatus(period.ID, PeriodStatusAktif); err != nil {
+			log.Printf("activating period %d failed: %v", period.ID, err)
+			result.JumlahGagal++
+			continue
+		}
+		result.JumlahDiaktifkan++
+
+		if !autoGenerate {
+			continue
+		}
+		generation, err := s.billService.GenerateBillsForPeriod(period.ID, dto.GenerateBillsInput{})
+		if err != nil {
+			log.Printf("generating bills for period %d failed: %v", period.ID, err)
+			result.JumlahGagal++
+			continue
+		}
+		result.JumlahTagihanDibuat += generation.JumlahDibuat
+		result.JumlahGagal += generation.JumlahGagal
+	}
+	return result, nil
+}
+
+func (s *periodService) academicYear(id uint) (*model.TahunAjaran, error) {
+	academicYear, err := s.academicYearRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: tahun ajaran tidak ditemukan", ErrInvalidPeriod)
+		}
+		return nil, err
+	}
+	return academicYear, nil
+}
+
+func periodDates(start, end, due string) (time.Time, time.Time, time.Time, error) {
+	tglMulai, err := time.Parse("2006-01-02", start)
+	if err != nil {
+		return time.Time{}, time.Time{}, time.Time{}, fmt.Errorf("%w: format tanggal mulai harus YYYY-MM-DD", ErrInvalidPeriod)
+	}
+	tglSelesai, err := time.Parse("2006-01-02", end)
+	if err != nil {
+		return time.Time{}, time.Time{}, time.Time{}, fmt.Errorf("%w: format tanggal selesai harus YYYY-MM-DD", ErrInvalidPeriod)
+	}
+	if tglSelesai.Before(tglMulai) {
+		return time.Time{}, time.Time{}, time.Time{}, fmt.Errorf("%w: tanggal selesai tidak boleh sebelum tanggal mulai", ErrInvalidPeriod)
+	}
+	tglJatuhTempo := tglSelesai
+	if due != "" {
+		if tglJatuhTempo, err = time.Parse("2006-01-02", due); err != nil {
+			return time.Time{}, time.Time{}, time.Time{}, fmt.Errorf("%w: format tanggal jatuh tempo harus YYYY-MM-DD", ErrInvalidPeriod)
+		}
+		if tglJatuhTempo.Before(tglMulai) || tglJatuhTempo.After(tglSelesai) {
+			return time.Time{}, time.Time{}, time.Time{}, fmt.Errorf("%w: tanggal jatuh tempo harus berada di antara tanggal mulai dan tanggal selesai", ErrInvalidPeriod)
+		}
+	}
+	return tglMulai, tglSelesai, tglJatuhTempo, nil
+}
+
+func monthName(bulan int) string {
+	if bulan < 1 || bulan > 12 {
+		return ""
+	}
+	return monthNames[bulan-1]
 }
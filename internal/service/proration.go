@@ -0,0 +1,76 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/model"
+)
+
+const (
+	SettingProrationPolicy = "prorata_tagihan"
+
+	ProrationNone      = "tidak"
+	ProrationDaily     = "harian"
+	ProrationHalfMonth = "setengah_bulan"
+)
+
+var ErrInvalidProrationSetting = errors.New("pengaturan prorata tagihan tidak valid")
+
+func loadProrationPolicy(settingService SettingService) (string, error) {
+	values, err := settingService.GetSettingValues()
+	if err != nil {
+		return "", err
+	}
+	policy := strings.TrimSpace(values[SettingProrationPolicy])
+	switch policy {
+	case "", ProrationNone:
+		return ProrationNone, nil
+	case ProrationDaily, ProrationHalfMonth:
+		return policy, nil
+	}
+	return "", fmt.Errorf("%w: %q harus %s, %s, atau %s", ErrInvalidProrationSetting, policy, ProrationNone, ProrationDaily, ProrationHalfMonth)
+}
+
+func enrolledAfter(student *model.Siswa, period *model.PeriodeSPP) bool {
+	if student.TanggalMasuk == nil {
+		return false
+	}
+	enrolled := time.Date(student.TanggalMasuk.Year(), student.TanggalMasuk.Month(), student.TanggalMasuk.Day(), 0, 0, 0, 0, time.Local)
+	end := time.Date(period.TanggalSelesai.Year(), period.TanggalSelesai.Month(), period.TanggalSelesai.Day(), 0, 0, 0, 0, time.Local)
+	return enrolled.After(end)
+}
+
+func prorate(amount float64, student *model.Siswa, period *model.PeriodeSPP, policy string) (float64, *string) {
+	if policy == ProrationNone || student.TanggalMasuk == nil {
+		return amount, nil
+	}
+	start := time.Date(period.TanggalMulai.Year(), period.TanggalMulai.Month(), 1, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(0, 1, -1)
+	enrolled := time.Date(student.TanggalMasuk.Year(), student.TanggalMasuk.Month(), student.TanggalMasuk.Day(), 0, 0, 0, 0, time.Local)
+	if !enrolled.After(start) || enrolled.After(end) {
+		return amount, nil
+	}
+
+	enrolledOn := fmt.Sprintf("%d %s %d", enrolled.Day(), monthName(int(enrolled.Month())), enrolled.Year())
+	var prorated float64
+	var note string
+	switch policy {
+	case ProrationDaily:
+		days := end.Day() - enrolled.Day() + 1
+		prorated = math.Round(amount * float64(days) / float64(end.Day()))
+		note = fmt.Sprintf("Prorata harian: masuk %s, %d dari %d hari", enrolledOn, days, end.Day())
+	case ProrationHalfMonth:
+		if enrolled.Day() <= 15 {
+			return amount, nil
+		}
+		prorated = math.Round(amount / 2)
+		note = fmt.Sprintf("Prorata setengah bulan: masuk %s", enrolledOn)
+	default:
+		return amount, nil
+	}
+	return prorated, &note
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/model"
+)
+
+func TestProrate(t *testing.T) {
+	period := &model.PeriodeSPP{TanggalMulai: testDate("2025-07-01"), TanggalSelesai: testDate("2025-07-31")}
+	tests := []struct {
+		name     string
+		policy   string
+		entry    string
+		amount   float64
+		want     float64
+		prorated bool
+	}{
+		{name: "without entry date", policy: ProrationDaily, amount: 150000, want: 150000},
+		{name: "proration disabled", policy: ProrationNone, entry: "2025-07-15", amount: 150000, want: 150000},
+		{name: "entry before period", policy: ProrationDaily, entry: "2025-06-20", amount: 150000, want: 150000},
+		{name: "entry on first day", policy: ProrationDaily, entry: "2025-07-01", amount: 150000, want: 150000},
+		{name: "entry after period", policy: ProrationDaily, entry: "2025-08-05", amount: 150000, want: 150000},
+		{name: "daily mid-month", policy: ProrationDaily, entry: "2025-07-15", amount: 150000, want: 82258, prorated: true},
+		{name: "daily rounded up", policy: ProrationDaily, entry: "2025-07-10", amount: 150000, want: 106452, prorated: true},
+		{name: "daily last day", policy: ProrationDaily, entry: "2025-07-31", amount: 150000, want: 4839, prorated: true},
+		{name: "half month on the 15th", policy: ProrationHalfMonth, entry: "2025-07-15", amount: 150000, want: 150000},
+		{name: "half month after the 15th", policy: ProrationHalfMonth, entry: "2025-07-16", amount: 150000, want: 75000, prorated: true},
+		{name: "half month rounded", policy: ProrationHalfMonth, entry: "2025-07-20", amount: 150001, want: 75001, prorated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			student := &model.Siswa{}
+			if tt.entry != "" {
+				entry := testDate(tt.entry)
+				student.TanggalMasuk = &entry
+			}
+			got, note := prorate(tt.amount, student, period, tt.policy)
+			if toCents(got) != toCents(tt.want) {
+				t.Fatalf("prorate = %.2f, want %.2f", got, tt.want)
+			}
+			if (note != nil) != tt.prorated {
+				t.Fatalf("note = %v, want prorated %v", note, tt.prorated)
+			}
+		})
+	}
+}
+
+func TestEnrolledAfter(t *testing.T) {
+	period := &model.PeriodeSPP{TanggalMulai: testDate("2025-07-01"), TanggalSelesai: testDate("2025-07-31").Add(23 * time.Hour)}
+	tests := []struct {
+		name  string
+		entry string
+		want  bool
+	}{
+		{name: "without entry date"},
+		{name: "entry before period", entry: "2025-06-20"},
+		{name: "entry mid-month", entry: "2025-07-15"},
+		{name: "entry on last day", entry: "2025-07-31"},
+		{name: "entry after period", entry: "2025-08-01", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			student := &model.Siswa{}
+			if tt.entry != "" {
+				entry := testDate(tt.entry)
+				student.TanggalMasuk = &entry
+			}
+			if got := enrolledAfter(student, period); got != tt.want {
+				t.Fatalf("enrolledAfter = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
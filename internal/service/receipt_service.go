@@ -0,0 +1,205 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/repository"
+	"github.com/hiuncy/spp-payment-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+var ErrReceiptNotAvailable = errors.New("kuitansi hanya tersedia untuk pembayaran yang sudah lunas")
+
+type ReceiptService interface {
+	GetReceipt(orderID string) ([]byte, string, error)
+	GetStudentReceipt(orderID string, userID uint) ([]byte, string, error)
+}
+
+type receiptService struct {
+	paymentRepo    repository.PaymentRepository
+	studentRepo    repository.StudentRepository
+	settingService SettingService
+	db             *gorm.DB
+}
+
+func NewReceiptService(paymentRepo repository.PaymentRepository, studentRepo repository.StudentRepository, settingService SettingService, db *gorm.DB) ReceiptService {
+	return &receiptService{paymentRepo, studentRepo, settingService, db}
+}
+
+func (s *receiptService) GetReceipt(orderID string) ([]byte, string, error) {
+	payment, err := s.paymentRepo.FindByOrderIDWithDetails(orderID)
+	if err != nil {
+		return nil, "", err
+	}
+	return s.render(payment)
+}
+
+func (s *receiptService) GetStudentReceipt(orderID string, userID uint) ([]byte, string, error) {
+	student, err := s.studentRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, "", errors.New("profil siswa tidak ditemukan")
+	}
+
+	payment, err := s.paymentRepo.FindByOrderIDWithDetails(orderID)
+	if err != nil {
+		return nil, "", err
+	}
+	if payment.SiswaID != student.ID {
+		return nil, "", gorm.ErrRecordNotFound
+	}
+	return s.render(payment)
+}
+
+func (s *receiptService) render(payment *model.Pembayaran) ([]byte, string, error) {
+	if !isPaymentFinal(payment.StatusPembayaran) {
+		return nil, "", ErrReceiptNotAvailable
+	}
+	if payment.NomorKuitansi == nil {
+		if err := s.ensureReceiptNumber(payment); err != nil {
+			return nil, "", err
+		}
+	}
+
+	settings, err := s.settingService.GetSettingValues()
+	if err != nil {
+		return nil, "", err
+	}
+
+	settledAt := payment.UpdatedAt
+	if payment.TanggalSettlement != nil {
+		settledAt = *payment.TanggalSettlement
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(20, 20, 20)
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(0, 7, tr(settings["nama_sekolah"]), "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 5, tr(settings["alamat_sekolah"]), "", 1, "C", false, 0, "")
+	pdf.CellFormat(0, 5, tr("Telp. "+settings["telepon_sekolah"]), "", 1, "C", false, 0, "")
+	pdf.Ln(2)
+	pdf.Line(20, pdf.GetY(), 190, pdf.GetY())
+	pdf.Ln(6)
+
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.CellFormat(0, 7, "KUITANSI PEMBAYARAN", "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 5, tr("No. "+*payment.NomorKuitansi), "", 1, "C", false, 0, "")
+	pdf.Ln(6)
+
+	method := "-"
+	if payment.MetodePembayaran != nil {
+		method = *payment.MetodePembayaran
+	}
+	rows := [][2]string{
+		{"Telah terima dari", payment.Siswa.NamaLengkap},
+		{"NISN", payment.Siswa.NISN},
+		{"Kelas", payment.Siswa.Kelas.NamaKelas},
+		{"Tanggal Pembayaran", utils.FormatTanggal(settledAt)},
+		{"Metode Pembayaran", method},
+		{"Order ID", payment.OrderID},
+	}
+	for _, row := range rows {
+		pdf.CellFormat(50, 7, row[0], "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 7, tr(": "+row[1]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(12, 8, "No", "1", 0, "C", false, 0, "")
+	pdf.CellFormat(108, 8, "Keterangan", "1", 0, "L", false, 0, "")
+	pdf.CellFormat(50, 8, "Jumlah", "1", 1, "R", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	for i, line := range receiptLines(payment) {
+		pdf.CellFormat(12, 8, fmt.Sprintf("%d", i+1), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(108, 8, tr(line.description), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(50, 8, utils.FormatRupiah(line.amount), "1", 1, "R", false, 0, "")
+	}
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(120, 8, "Total", "1", 0, "R", false, 0, "")
+	pdf.CellFormat(50, 8, utils.FormatRupiah(payment.JumlahBayar+payment.BiayaAdmin), "1", 1, "R", false, 0, "")
+	pdf.Ln(4)
+
+	pdf.SetFont("Helvetica", "I", 10)
+	pdf.MultiCell(0, 6, tr("Terbilang: "+capitalize(utils.Terbilang(int64(math.Round(payment.JumlahBayar+payment.BiayaAdmin))))+" rupiah"), "", "L", false)
+	pdf.Ln(12)
+
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetX(130)
+	pdf.CellFormat(60, 6, utils.FormatTanggal(time.Now()), "", 1, "C", false, 0, "")
+	pdf.SetX(130)
+	pdf.CellFormat(60, 6, "Bendahara", "", 1, "C", false, 0, "")
+	pdf.Ln(20)
+	pdf.SetX(130)
+	pdf.CellFormat(60, 6, "(....................................)", "", 1, "C", false, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, "", err
+	}
+
+	filename := "kuitansi-" + strings.ReplaceAll(*payment.NomorKuitansi, "/", "-") + ".pdf"
+	return buf.Bytes(), filename, nil
+}
+
+func (s *receiptService) ensureReceiptNumber(payment *model.Pembayaran) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		paymentRepoTx := repository.NewPaymentRepository(tx)
+		locked, err := paymentRepoTx.FindByOrderIDForUpdate(payment.OrderID)
+		if err != nil {
+			return err
+		}
+		if locked.NomorKuitansi == nil {
+			if err := assignReceiptNumber(tx, locked); err != nil {
+				return err
+			}
+			if err := paymentRepoTx.Update(locked); err != nil {
+				return err
+			}
+		}
+		payment.NomorKuitansi = locked.NomorKuitansi
+		return nil
+	})
+}
+
+type receiptLine struct {
+	description string
+	amount      float64
+}
+
+func receiptLines(payment *model.Pembayaran) []receiptLine {
+	if len(payment.DetailTagihan) == 0 {
+		return []receiptLine{{billLabel(&payment.TagihanSPP), payment.JumlahBayar}}
+	}
+
+	var lines []receiptLine
+	for _, detail := range payment.DetailTagihan {
+		bill := detail.TagihanSPP
+		description := billLabel(&bill)
+		if toCents(detail.Jumlah) < toCents(billTotal(&bill)) {
+			description = "Cicilan " + description
+		}
+		lines = append(lines, receiptLine{description, detail.Jumlah})
+	}
+	if payment.BiayaAdmin > 0 {
+		lines = append(lines, receiptLine{"Biaya Admin", payment.BiayaAdmin})
+	}
+	return lines
+}
+
+func capitalize(text string) string {
+	if text == "" {
+		return text
+	}
+	return strings.ToUpper(text[:1]) + text[1:]
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/dto"
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/repository"
+)
+
+const (
+	ReconciliationTriggerScheduled = "terjadwal"
+	ReconciliationTriggerManual    = "manual"
+)
+
+var ErrReconciliationRunning = errors.New("rekonsiliasi pembayaran sedang berjalan")
+
+type ReconciliationService interface {
+	Start(ctx context.Context)
+	Run(trigger string) (*model.RekonsiliasiPembayaran, error)
+	FindAllRuns(page, limit int) ([]model.RekonsiliasiPembayaran, int64, error)
+	FindRunByID(id uint) (*model.RekonsiliasiPembayaran, error)
+}
+
+type ReconciliationOptions struct {
+	Interval   time.Duration
+	PendingAge time.Duration
+	ExpireAge  time.Duration
+}
+
+type reconciliationResult struct {
+	OrderID    string `json:"order_id,omitempty"`
+	StatusLama string `json:"status_lama,omitempty"`
+	StatusBaru string `json:"status_baru,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type reconciliationService struct {
+	repo           repository.ReconciliationRepository
+	paymentRepo    repository.PaymentRepository
+	paymentService PaymentService
+	gateways       PaymentGatewayRegistry
+	options        ReconciliationOptions
+	now            func() time.Time
+	mu             sync.Mutex
+}
+
+func NewReconciliationService(repo repository.ReconciliationRepository, paymentRepo repository.PaymentRepository, paymentService PaymentService, gateways PaymentGatewayRegistry, options ReconciliationOptions) ReconciliationService {
+	return &reconciliationService{
+		repo:           repo,
+		paymentRepo:    paymentRepo,
+		paymentService: paymentService,
+		gateways:       gateways,
+		options:        options,
+		now:            time.Now,
+	}
+}
+
+func (s *reconciliationService) Start(ctx context.Context) {
+	runPeriodically(ctx, "reconciliation", s.options.Interval, func() (string, error) {
+		run, err := s.Run(ReconciliationTriggerScheduled)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("run=%d checked=%d updated=%d failed=%d", run.ID, run.JumlahDiperiksa, run.JumlahDiperbarui, run.JumlahGagal), nil
+	})
+}
+
+func (s *reconciliationService) Run(trigger string) (*model.RekonsiliasiPembayaran, error) {
+	if !s.mu.TryLock() {
+		return nil, ErrReconciliationRunning
+	}
+	defer s.mu.Unlock()
+
+	startedAt := s.now()
+	run := &model.RekonsiliasiPembayaran{
+		Pemicu:       trigger,
+		TanggalMulai: startedAt,
+	}
+	if err := s.repo.Create(run); err != nil {
+		return nil, err
+	}
+
+	results := []reconciliationResult{}
+	payments, err := s.paymentRepo.FindPendingCreatedBefore(startedAt.Add(-s.options.PendingAge), []string{PaymentMethodCash, PaymentMethodManualTransfer})
+	if err != nil {
+		results = append(results, reconciliationResult{Error: err.Error()})
+	}
+	for _, payment := range payments {
+		result := s.reconcilePayment(&payment, startedAt)
+		run.JumlahDiperiksa++
+		if result.Error != "" {
+			run.JumlahGagal++
+		} else if result.StatusBaru != "" {
+			run.JumlahDiperbarui++
+		}
+		results = append(results, result)
+	}
+
+	detailBytes, _ := json.Marshal(results)
+	detail := string(detailBytes)
+	finishedAt := s.now()
+	run.Detail = &detail
+	run.TanggalSelesai = &finishedAt
+	if updateErr := s.repo.Update(run); updateErr != nil {
+		return nil, errors.Join(err, updateErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+func (s *reconciliationService) FindAllRuns(page, limit int) ([]model.RekonsiliasiPembayaran, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	return s.repo.FindAll(page, limit)
+}
+
+func (s *reconciliationService) FindRunByID(id uint) (*model.RekonsiliasiPembayaran, error) {
+	return s.repo.FindByID(id)
+}
+
+func (s *reconciliationService) reconcilePayment(payment *model.Pembayaran, now time.Time) reconciliationResult {
+	result := reconciliationResult{
+		OrderID:    payment.OrderID,
+		StatusLama: payment.StatusPembayaran,
+	}
+
+	gateway, err := s.gateways.Get(paymentGatewayName(payment))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	update, err := gateway.GetTransactionStatus(payment.OrderID)
+	if errors.Is(err, ErrTransactionNotFound) {
+		if payment.CreatedAt.After(now.Add(-s.options.ExpireAge)) {
+			return result
+		}
+		update = &dto.PaymentStatusUpdate{
+			OrderID:           payment.OrderID,
+			TransactionStatus: PaymentStatusExpire,
+		}
+	} else if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	newStatus, ok := normalizePaymentStatus(update.TransactionStatus, update.FraudStatus)
+	if !ok {
+		result.Error = "status transaksi tidak dikenal: " + update.TransactionStatus
+		return result
+	}
+	if !canTransitionPayment(payment.StatusPembayaran, newStatus) {
+		return result
+	}
+
+	update.OrderID = payment.OrderID
+	if err := s.paymentService.ApplyPaymentStatus(*update); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.StatusBaru = newStatus
+	return result
+}
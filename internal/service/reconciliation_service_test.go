@@ -0,0 +1,179 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/repository"
+)
+
+type stubReconciliationRepository struct {
+	repository.ReconciliationRepository
+	created int
+	updated []model.RekonsiliasiPembayaran
+}
+
+func (r *stubReconciliationRepository) Create(run *model.RekonsiliasiPembayaran) error {
+	r.created++
+	run.ID = uint(r.created)
+	return nil
+}
+
+func (r *stubReconciliationRepository) Update(run *model.RekonsiliasiPembayaran) error {
+	r.updated = append(r.updated, *run)
+	return nil
+}
+
+func pendingPayment(orderID, gateway string, createdAt time.Time) model.Pembayaran {
+	return model.Pembayaran{
+		OrderID:          orderID,
+		Gateway:          &gateway,
+		JumlahBayar:      150000,
+		StatusPembayaran: PaymentStatusPending,
+		CreatedAt:        createdAt,
+	}
+}
+
+func TestReconciliationRun(t *testing.T) {
+	now := time.Date(2025, 1, 20, 10, 0, 0, 0, time.Local)
+	options := ReconciliationOptions{PendingAge: 30 * time.Minute, ExpireAge: 24 * time.Hour}
+
+	tests := []struct {
+		name        string
+		setup       func(t *testing.T, gateway PaymentGateway) []model.Pembayaran
+		applyErr    error
+		wantApplied map[string]string
+		wantUpdated int
+		wantFailed  int
+	}{
+		{
+			name: "settled at gateway",
+			setup: func(t *testing.T, gateway PaymentGateway) []model.Pembayaran {
+				fakeGatewayCharge(t, gateway, "SPP-1", 150000)
+				fakeGatewayNotify(t, gateway, map[string]string{"order_id": "SPP-1", "transaction_status": "settlement", "gross_amount": "150000.00"})
+				return []model.Pembayaran{pendingPayment("SPP-1", GatewayFake, now.Add(-time.Hour))}
+			},
+			wantApplied: map[string]string{"SPP-1": PaymentStatusSettlement},
+			wantUpdated: 1,
+		},
+		{
+			name: "still pending at gateway",
+			setup: func(t *testing.T, gateway PaymentGateway) []model.Pembayaran {
+				fakeGatewayCharge(t, gateway, "SPP-2", 150000)
+				return []model.Pembayaran{pendingPayment("SPP-2", GatewayFake, now.Add(-time.Hour))}
+			},
+			wantApplied: map[string]string{},
+		},
+		{
+			name: "expired at gateway",
+			setup: func(t *testing.T, gateway PaymentGateway) []model.Pembayaran {
+				fakeGatewayCharge(t, gateway, "SPP-3", 150000)
+				fakeGatewayNotify(t, gateway, map[string]string{"order_id": "SPP-3", "transaction_status": "expire", "gross_amount": "150000.00"})
+				return []model.Pembayaran{pendingPayment("SPP-3", GatewayFake, now.Add(-time.Hour))}
+			},
+			wantApplied: map[string]string{"SPP-3": PaymentStatusExpire},
+			wantUpdated: 1,
+		},
+		{
+			name: "unknown at gateway and older than expire age",
+			setup: func(t *testing.T, gateway PaymentGateway) []model.Pembayaran {
+				return []model.Pembayaran{pendingPayment("SPP-4", GatewayFake, now.Add(-48*time.Hour))}
+			},
+			wantApplied: map[string]string{"SPP-4": PaymentStatusExpire},
+			wantUpdated: 1,
+		},
+		{
+			name: "unknown at gateway but still recent",
+			setup: func(t *testing.T, gateway PaymentGateway) []model.Pembayaran {
+				return []model.Pembayaran{pendingPayment("SPP-5", GatewayFake, now.Add(-time.Hour))}
+			},
+			wantApplied: map[string]string{},
+		},
+		{
+			name: "gateway not registered",
+			setup: func(t *testing.T, gateway PaymentGateway) []model.Pembayaran {
+				return []model.Pembayaran{pendingPayment("SPP-6", "lainnya", now.Add(-time.Hour))}
+			},
+			wantApplied: map[string]string{},
+			wantFailed:  1,
+		},
+		{
+			name: "apply status fails",
+			setup: func(t *testing.T, gateway PaymentGateway) []model.Pembayaran {
+				fakeGatewayCharge(t, gateway, "SPP-7", 150000)
+				fakeGatewayNotify(t, gateway, map[string]string{"order_id": "SPP-7", "transaction_status": "settlement", "gross_amount": "150000.00"})
+				return []model.Pembayaran{pendingPayment("SPP-7", GatewayFake, now.Add(-time.Hour))}
+			},
+			applyErr:    errors.New("database down"),
+			wantApplied: map[string]string{},
+			wantFailed:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := NewFakeGateway(testFakeGatewaySecret)
+			repo := &stubReconciliationRepository{}
+			paymentRepo := &stubPaymentRepository{pending: tt.setup(t, gateway)}
+			paymentService := &stubPaymentService{applyErr: tt.applyErr}
+			service := NewReconciliationService(repo, paymentRepo, paymentService, NewPaymentGatewayRegistry(nil, gateway), options).(*reconciliationService)
+			service.now = func() time.Time { return now }
+
+			run, err := service.Run(ReconciliationTriggerManual)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if run.JumlahDiperiksa != len(paymentRepo.pending) || run.JumlahDiperbarui != tt.wantUpdated || run.JumlahGagal != tt.wantFailed {
+				t.Fatalf("checked=%d updated=%d failed=%d, want %d/%d/%d", run.JumlahDiperiksa, run.JumlahDiperbarui, run.JumlahGagal, len(paymentRepo.pending), tt.wantUpdated, tt.wantFailed)
+			}
+			if run.TanggalSelesai == nil || run.Detail == nil {
+				t.Fatal("run is not finished")
+			}
+
+			applied := make(map[string]string)
+			for _, update := range paymentService.applied {
+				applied[update.OrderID] = update.TransactionStatus
+			}
+			if len(applied) != len(tt.wantApplied) {
+				t.Fatalf("applied = %v, want %v", applied, tt.wantApplied)
+			}
+			for orderID, status := range tt.wantApplied {
+				if applied[orderID] != status {
+					t.Fatalf("applied = %v, want %v", applied, tt.wantApplied)
+				}
+			}
+		})
+	}
+}
+
+func TestReconciliationRunClosesRunOnLookupError(t *testing.T) {
+	repo := &stubReconciliationRepository{}
+	paymentRepo := &stubPaymentRepository{pendingErr: errors.New("database down")}
+	service := NewReconciliationService(repo, paymentRepo, &stubPaymentService{}, NewPaymentGatewayRegistry(nil), ReconciliationOptions{})
+
+	if _, err := service.Run(ReconciliationTriggerScheduled); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(repo.updated) != 1 {
+		t.Fatalf("run updated %d times, want 1", len(repo.updated))
+	}
+	run := repo.updated[0]
+	if run.TanggalSelesai == nil {
+		t.Fatal("run is not finished")
+	}
+	if run.Detail == nil || !strings.Contains(*run.Detail, "database down") {
+		t.Fatalf("detail = %v, want lookup error", run.Detail)
+	}
+}
+
+func TestReconciliationRunRejectsConcurrentRun(t *testing.T) {
+	service := NewReconciliationService(&stubReconciliationRepository{}, &stubPaymentRepository{}, &stubPaymentService{}, NewPaymentGatewayRegistry(nil), ReconciliationOptions{}).(*reconciliationService)
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	if _, err := service.Run(ReconciliationTriggerManual); !errors.Is(err, ErrReconciliationRunning) {
+		t.Fatalf("err = %v, want %v", err, ErrReconciliationRunning)
+	}
+}
@@ -0,0 +1,346 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/dto"
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/repository"
+	"github.com/hiuncy/spp-payment-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	RefundStatusMenungguPersetujuan = "menunggu_persetujuan"
+	RefundStatusDiproses            = "diproses"
+	RefundStatusBerhasil            = "berhasil"
+	RefundStatusDitolak             = "ditolak"
+	RefundStatusGagal               = "gagal"
+
+	RefundMethodCash = "tunai"
+
+	SettingRefundApprovalThreshold = "batas_refund_tanpa_persetujuan"
+)
+
+var (
+	ErrRefundReasonRequired = errors.New("alasan refund wajib diisi")
+	ErrRefundRejectEmpty    = errors.New("alasan penolakan wajib diisi")
+	ErrInvalidRefundAmount  = errors.New("jumlah refund tidak valid")
+	ErrPaymentNotRefundable = errors.New("pembayaran belum lunas atau sudah direfund penuh")
+	ErrRefundNotWaiting     = errors.New("refund tidak sedang menunggu persetujuan")
+	ErrRefundSelfApproval   = errors.New("refund tidak dapat disetujui oleh pengaju yang sama")
+	ErrRefundFailed         = errors.New("refund gagal diproses")
+)
+
+type RefundService interface {
+	RequestRefund(input dto.RefundInput) (*model.RefundPembayaran, error)
+	FindAll(input dto.FindAllRefundsInput) ([]model.RefundPembayaran, int64, error)
+	FindByID(id uint) (*model.RefundPembayaran, error)
+	Approve(id, approverID uint) (*model.RefundPembayaran, error)
+	Reject(id, approverID uint, reason string) (*model.RefundPembayaran, error)
+}
+
+type refundService struct {
+	repo           repository.RefundRepository
+	paymentRepo    repository.PaymentRepository
+	settingService SettingService
+	gateways       PaymentGatewayRegistry
+	db             *gorm.DB
+}
+
+func NewRefundService(repo repository.RefundRepository, paymentRepo repository.PaymentRepository, settingService SettingService, gateways PaymentGatewayRegistry, db *gorm.DB) RefundService {
+	return &refundService{repo, paymentRepo, settingService, gateways, db}
+}
+
+func (s *refundService) RequestRefund(input dto.RefundInput) (*model.RefundPembayaran, error) {
+	reason := strings.TrimSpace(input.Alasan)
+	if reason == "" {
+		return nil, ErrRefundReasonRequired
+	}
+	if input.Jumlah < 0 || input.Jumlah != math.Trunc(input.Jumlah) {
+		return nil, fmt.Errorf("%w: jumlah refund harus berupa bilangan bulat rupiah", ErrInvalidRefundAmount)
+	}
+
+	threshold, err := s.approvalThreshold()
+	if err != nil {
+		return nil, err
+	}
+
+	var refund *model.RefundPembayaran
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		paymentRepoTx := repository.NewPaymentRepository(tx)
+		repoTx := repository.NewRefundRepository(tx)
+
+		payment, err := paymentRepoTx.FindByOrderIDForUpdate(input.OrderID)
+		if err != nil {
+			return err
+		}
+		if !isPaymentSettled(payment.StatusPembayaran) {
+			return ErrPaymentNotRefundable
+		}
+
+		reserved, err := repoTx.SumByPembayaranID(payment.ID, []string{RefundStatusMenungguPersetujuan, RefundStatusDiproses})
+		if err != nil {
+			return err
+		}
+		available := payment.JumlahBayar - payment.JumlahRefund - reserved
+		amount := input.Jumlah
+		if amount == 0 {
+			amount = available
+		}
+		if toCents(amount) <= 0 || toCents(amount) > toCents(available) {
+			return fmt.Errorf("%w: sisa yang dapat direfund %.0f", ErrInvalidRefundAmount, math.Max(available, 0))
+		}
+
+		method := paymentGatewayName(payment)
+		if isManualPayment(payment) {
+			method = RefundMethodCash
+		}
+		status := RefundStatusDiproses
+		if threshold > 0 && toCents(amount) > toCents(threshold) {
+			status = RefundStatusMenungguPersetujuan
+		}
+
+		refund = &model.RefundPembayaran{
+			PembayaranID: payment.ID,
+			RefundKey:    fmt.Sprintf("RF-%d-%d", payment.ID, time.Now().UnixNano()),
+			Jumlah:       amount,
+			Alasan:       reason,
+			MetodeRefund: method,
+			StatusRefund: status,
+			DiajukanOleh: input.DiajukanOleh,
+		}
+		return repoTx.Create(refund)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if refund.StatusRefund == RefundStatusDiproses {
+		if err := s.execute(refund.ID); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.FindByID(refund.ID)
+}
+
+func (s *refundService) FindAll(input dto.FindAllRefundsInput) ([]model.RefundPembayaran, int64, error) {
+	if input.Page <= 0 {
+		input.Page = 1
+	}
+	if input.Limit <= 0 {
+		input.Limit = 10
+	}
+	params := utils.FindAllRefundsParams{
+		Page:         input.Page,
+		Limit:        input.Limit,
+		OrderID:      input.OrderID,
+		StatusRefund: input.StatusRefund,
+	}
+	return s.repo.FindAll(params)
+}
+
+func (s *refundService) FindByID(id uint) (*model.RefundPembayaran, error) {
+	return s.repo.FindByID(id)
+}
+
+func (s *refundService) Approve(id, approverID uint) (*model.RefundPembayaran, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repoTx := repository.NewRefundRepository(tx)
+		refund, err := repoTx.FindByIDForUpdate(id)
+		if err != nil {
+			return err
+		}
+		if refund.StatusRefund != RefundStatusMenungguPersetujuan {
+			return ErrRefundNotWaiting
+		}
+		if refund.DiajukanOleh == approverID {
+			return ErrRefundSelfApproval
+		}
+
+		now := time.Now()
+		refund.StatusRefund = RefundStatusDiproses
+		refund.DisetujuiOleh = &approverID
+		refund.TanggalDisetujui = &now
+		return repoTx.Update(refund)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.execute(id); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(id)
+}
+
+func (s *refundService) Reject(id, approverID uint, reason string) (*model.RefundPembayaran, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrRefundRejectEmpty
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repoTx := repository.NewRefundRepository(tx)
+		refund, err := repoTx.FindByIDForUpdate(id)
+		if err != nil {
+			return err
+		}
+		if refund.StatusRefund != RefundStatusMenungguPersetujuan {
+			return ErrRefundNotWaiting
+		}
+
+		now := time.Now()
+		refund.StatusRefund = RefundStatusDitolak
+		refund.DisetujuiOleh = &approverID
+		refund.TanggalDisetujui = &now
+		refund.AlasanPenolakan = &reason
+		return repoTx.Update(refund)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(id)
+}
+
+func (s *refundService) execute(id uint) error {
+	refund, err := s.repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	current, err := s.paymentRepo.FindByOrderID(refund.Pembayaran.OrderID)
+	if err != nil {
+		return err
+	}
+
+	var rawResponse string
+	if refund.MetodeRefund != RefundMethodCash {
+		gateway, err := s.gateways.Get(refund.MetodeRefund)
+		if err != nil {
+			return err
+		}
+		rawResponse, err = gateway.RefundTransaction(current.OrderID, refund.RefundKey, int64(math.Round(refund.Jumlah)), refund.Alasan)
+		if err != nil {
+			if markErr := s.markFailed(id, err.Error()); markErr != nil {
+				return markErr
+			}
+			return fmt.Errorf("%w: %v", ErrRefundFailed, err)
+		}
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		billRepoTx := repository.NewBillRepository(tx)
+		paymentRepoTx := repository.NewPaymentRepository(tx)
+		repoTx := repository.NewRefundRepository(tx)
+
+		bills, err := billRepoTx.FindByIDsForUpdate(paymentBillIDs(current))
+		if err != nil {
+			return err
+		}
+		payment, err := paymentRepoTx.FindByOrderIDForUpdate(current.OrderID)
+		if err != nil {
+			return err
+		}
+		locked, err := repoTx.FindByIDForUpdate(id)
+		if err != nil {
+			return err
+		}
+		if locked.StatusRefund != RefundStatusDiproses {
+			return nil
+		}
+
+		if err := refundPayment(tx, bills, payment, locked.Jumlah); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		locked.StatusRefund = RefundStatusBerhasil
+		locked.TanggalRefund = &now
+		if rawResponse != "" {
+			locked.MidtransResponse = &rawResponse
+		}
+		return repoTx.Update(locked)
+	})
+}
+
+func (s *refundService) markFailed(id uint, note string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		repoTx := repository.NewRefundRepository(tx)
+		refund, err := repoTx.FindByIDForUpdate(id)
+		if err != nil {
+			return err
+		}
+		refund.StatusRefund = RefundStatusGagal
+		refund.Keterangan = &note
+		return repoTx.Update(refund)
+	})
+}
+
+func (s *refundService) approvalThreshold() (float64, error) {
+	values, err := s.settingService.GetSettingValues()
+	if err != nil {
+		return 0, err
+	}
+	threshold, err := strconv.ParseFloat(strings.TrimSpace(values[SettingRefundApprovalThreshold]), 64)
+	if err != nil || threshold < 0 {
+		return 0, nil
+	}
+	return threshold, nil
+}
+
+func refundPayment(tx *gorm.DB, bills []model.TagihanSPP, payment *model.Pembayaran, amount float64) error {
+	if payment.StatusPembayaran == PaymentStatusRefund {
+		return nil
+	}
+	if !isPaymentSettled(payment.StatusPembayaran) {
+		return ErrPaymentNotRefundable
+	}
+
+	paymentRepoTx := repository.NewPaymentRepository(tx)
+	details := make([]*model.PembayaranTagihan, 0, len(payment.DetailTagihan))
+	for i := range payment.DetailTagihan {
+		details = append(details, &payment.DetailTagihan[i])
+	}
+	sort.Slice(details, func(i, j int) bool { return details[i].TagihanID > details[j].TagihanID })
+
+	refunded := make(map[uint]float64)
+	remaining := amount
+	for _, detail := range details {
+		portion := detail.Jumlah - detail.JumlahRefund
+		if toCents(portion) > toCents(remaining) {
+			portion = remaining
+		}
+		if toCents(portion) <= 0 {
+			continue
+		}
+		detail.JumlahRefund += portion
+		remaining -= portion
+		refunded[detail.TagihanID] += portion
+		if err := paymentRepoTx.UpdateDetail(detail); err != nil {
+			return err
+		}
+	}
+	if len(details) == 0 {
+		refunded[payment.TagihanID] = amount
+	}
+	for i := range bills {
+		bills[i].JumlahTerbayar -= refunded[bills[i].ID]
+	}
+
+	payment.JumlahRefund += amount
+	status := PaymentStatusPartialRefund
+	if toCents(payment.JumlahRefund) >= toCents(payment.JumlahBayar) {
+		status = PaymentStatusRefund
+	}
+	update := dto.PaymentStatusUpdate{
+		OrderID:           payment.OrderID,
+		TransactionStatus: status,
+	}
+	return transitionPayment(tx, bills, payment, update)
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/repository"
+	"gorm.io/gorm"
+)
+
+func TestRefundPaymentAllocatesAcrossBills(t *testing.T) {
+	db := openTestDB(t)
+	student, bills := createTestBills(t, db, 100000, 50000)
+	for i := range bills {
+		bills[i].JumlahTerbayar = bills[i].JumlahTagihan
+		bills[i].StatusPembayaran = BillStatusLunas
+		if err := db.Save(&bills[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, amount := range []float64{50000, 50000} {
+		installment := &model.CicilanTagihan{
+			TagihanID:         bills[0].ID,
+			Urutan:            i + 1,
+			Jumlah:            amount,
+			JumlahTerbayar:    amount,
+			StatusPembayaran:  BillStatusLunas,
+			TanggalJatuhTempo: time.Now().AddDate(0, i, 0),
+		}
+		if err := db.Create(installment).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	payment := createTestPayment(t, db, student, PaymentStatusSettlement, bills...)
+
+	refund := func(amount float64) {
+		t.Helper()
+		err := db.Transaction(func(tx *gorm.DB) error {
+			locked, err := repository.NewBillRepository(tx).FindByIDsForUpdate([]uint{bills[0].ID, bills[1].ID})
+			if err != nil {
+				return err
+			}
+			lockedPayment, err := repository.NewPaymentRepository(tx).FindByOrderIDForUpdate(payment.OrderID)
+			if err != nil {
+				return err
+			}
+			return refundPayment(tx, locked, lockedPayment, amount)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func(wantPayment string, wantRefunds map[uint]float64, wantBills map[uint]string, wantInstallments []string) {
+		t.Helper()
+		var current model.Pembayaran
+		if err := db.Preload("DetailTagihan").First(&current, payment.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if current.StatusPembayaran != wantPayment {
+			t.Fatalf("payment status = %s, want %s", current.StatusPembayaran, wantPayment)
+		}
+		for _, detail := range current.DetailTagihan {
+			if toCents(detail.JumlahRefund) != toCents(wantRefunds[detail.TagihanID]) {
+				t.Fatalf("bill %d refunded %.2f, want %.2f", detail.TagihanID, detail.JumlahRefund, wantRefunds[detail.TagihanID])
+			}
+		}
+		for id, want := range wantBills {
+			var bill model.TagihanSPP
+			if err := db.Preload("Cicilan", func(db *gorm.DB) *gorm.DB { return db.Order("urutan asc") }).First(&bill, id).Error; err != nil {
+				t.Fatal(err)
+			}
+			if bill.StatusPembayaran != want {
+				t.Fatalf("bill %d status = %s paid = %.2f, want %s", id, bill.StatusPembayaran, bill.JumlahTerbayar, want)
+			}
+			if id != bills[0].ID {
+				continue
+			}
+			for i, installment := range bill.Cicilan {
+				if installment.StatusPembayaran != wantInstallments[i] {
+					t.Fatalf("installment %d status = %s, want %s", installment.Urutan, installment.StatusPembayaran, wantInstallments[i])
+				}
+			}
+		}
+	}
+
+	refund(70000)
+	check(PaymentStatusPartialRefund,
+		map[uint]float64{bills[0].ID: 20000, bills[1].ID: 50000},
+		map[uint]string{bills[0].ID: BillStatusSebagian, bills[1].ID: BillStatusBelumBayar},
+		[]string{BillStatusLunas, BillStatusSebagian})
+
+	refund(80000)
+	check(PaymentStatusRefund,
+		map[uint]float64{bills[0].ID: 100000, bills[1].ID: 50000},
+		map[uint]string{bills[0].ID: BillStatusBelumBayar, bills[1].ID: BillStatusBelumBayar},
+		[]string{BillStatusBelumBayar, BillStatusBelumBayar})
+}
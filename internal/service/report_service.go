@@ -6,9 +6,10 @@ import (
 )
 
 type ReportService interface {
-	GetLaporanSiswa(tahunAjaran, nisn string) ([]model.LaporanSiswa, error)
-	GetLaporanKelas(tahunAjaran, namaBulan string) ([]model.LaporanKelas, error)
-	GetLaporanKeseluruhan(tahunAjaran string) ([]model.LaporanKeseluruhan, error)
+	GetLaporanSiswa(tahunAjaran, nisn, kodeBiaya string) ([]model.LaporanSiswa, error)
+	GetLaporanKelas(tahunAjaran, namaBulan, kodeBiaya string) ([]model.LaporanKelas, error)
+	GetLaporanKeseluruhan(tahunAjaran, kodeBiaya string) ([]model.LaporanKeseluruhan, error)
+	GetLaporanPotongan(tahunAjaran, namaBulan, kodeBiaya string) ([]model.LaporanPotongan, error)
 }
 
 type reportService struct {
@@ -19,14 +20,18 @@ func NewReportService(repo repository.ReportRepository) ReportService {
 	return &reportService{repo}
 }
 
-func (s *reportService) GetLaporanSiswa(tahunAjaran, nisn string) ([]model.LaporanSiswa, error) {
-	return s.repo.GetLaporanSiswa(tahunAjaran, nisn)
+func (s *reportService) GetLaporanSiswa(tahunAjaran, nisn, kodeBiaya string) ([]model.LaporanSiswa, error) {
+	return s.repo.GetLaporanSiswa(tahunAjaran, nisn, kodeBiaya)
 }
 
-func (s *reportService) GetLaporanKelas(tahunAjaran, namaBulan string) ([]model.LaporanKelas, error) {
-	return s.repo.GetLaporanKelas(tahunAjaran, namaBulan)
+func (s *reportService) GetLaporanKelas(tahunAjaran, namaBulan, kodeBiaya string) ([]model.LaporanKelas, error) {
+	return s.repo.GetLaporanKelas(tahunAjaran, namaBulan, kodeBiaya)
 }
 
-func (s *reportService) GetLaporanKeseluruhan(tahunAjaran string) ([]model.LaporanKeseluruhan, error) {
-	return s.repo.GetLaporanKeseluruhan(tahunAjaran)
+func (s *reportService) GetLaporanKeseluruhan(tahunAjaran, kodeBiaya string) ([]model.LaporanKeseluruhan, error) {
+	return s.repo.GetLaporanKeseluruhan(tahunAjaran, kodeBiaya)
+}
+
+func (s *reportService) GetLaporanPotongan(tahunAjaran, namaBulan, kodeBiaya string) ([]model.LaporanPotongan, error) {
+	return s.repo.GetLaporanPotongan(tahunAjaran, namaBulan, kodeBiaya)
 }
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+func runPeriodically(ctx context.Context, name string, interval time.Duration, run func() (string, error)) {
+	runOnce := func() {
+		summary, err := run()
+		if err != nil {
+			log.Printf("%s failed: %v", name, err)
+			return
+		}
+		log.Printf("%s: %s", name, summary)
+	}
+
+	runOnce()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			runOnce()
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunPeriodicallyRunsAtStartup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := make(chan struct{}, 1)
+	done := make(chan struct{})
+	go func() {
+		runPeriodically(ctx, "test", time.Hour, func() (string, error) {
+			calls <- struct{}{}
+			return "ok", nil
+		})
+		close(done)
+	}()
+
+	select {
+	case <-calls:
+	case <-time.After(time.Second):
+		t.Fatal("run was not called at startup")
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("runner did not stop after the context was cancelled")
+	}
+}
+
+func TestRunPeriodicallyKeepsRunningAfterError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := make(chan struct{}, 3)
+	go runPeriodically(ctx, "test", time.Millisecond, func() (string, error) {
+		select {
+		case calls <- struct{}{}:
+		default:
+		}
+		return "", errors.New("gagal")
+	})
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-calls:
+		case <-time.After(time.Second):
+			t.Fatalf("run was called %d times, want 3", i)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 type SettingService interface {
 	FindAllSettings() ([]model.Pengaturan, error)
+	GetSettingValues() (map[string]string, error)
 	UpdateSettings(input map[string]string) error
 }
 
@@ -24,6 +25,19 @@ func (s *settingService) FindAllSettings() ([]model.Pengaturan, error) {
 	return s.repo.FindAll()
 }
 
+func (s *settingService) GetSettingValues() (map[string]string, error) {
+	settings, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(settings))
+	for _, setting := range settings {
+		values[setting.KeySetting] = setting.ValueSetting
+	}
+	return values, nil
+}
+
 func (s *settingService) UpdateSettings(input map[string]string) error {
 	tx := s.db.Begin()
 	if tx.Error != nil {
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/dto"
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/repository"
+)
+
+type stubPaymentRepository struct {
+	repository.PaymentRepository
+	payments   map[string]*model.Pembayaran
+	pending    []model.Pembayaran
+	pendingErr error
+}
+
+func (r *stubPaymentRepository) FindByOrderID(orderID string) (*model.Pembayaran, error) {
+	payment, ok := r.payments[orderID]
+	if !ok {
+		return nil, errors.New("payment not found")
+	}
+	return payment, nil
+}
+
+func (r *stubPaymentRepository) FindPendingCreatedBefore(cutoff time.Time, excludedMethods []string) ([]model.Pembayaran, error) {
+	return r.pending, r.pendingErr
+}
+
+type stubPaymentService struct {
+	PaymentService
+	applied  []dto.PaymentStatusUpdate
+	applyErr error
+}
+
+func (s *stubPaymentService) ApplyPaymentStatus(update dto.PaymentStatusUpdate) error {
+	if s.applyErr != nil {
+		return s.applyErr
+	}
+	s.applied = append(s.applied, update)
+	return nil
+}
+
+type stubSettingService struct {
+	SettingService
+	values map[string]string
+}
+
+func (s *stubSettingService) GetSettingValues() (map[string]string, error) {
+	return s.values, nil
+}
@@ -74,6 +74,11 @@ func (s *studentService) CreateStudent(input dto.CreateStudentInput) (*model.Sis
 	}
 
 	tglLahir, _ := time.Parse("2006-01-02", input.TanggalLahir)
+	var tglMasuk *time.Time
+	if input.TanggalMasuk != "" {
+		parsed, _ := time.Parse("2006-01-02", input.TanggalMasuk)
+		tglMasuk = &parsed
+	}
 	newStudent := &model.Siswa{
 		UserID:          newUser.ID,
 		NISN:            input.NISN,
@@ -86,6 +91,7 @@ func (s *studentService) CreateStudent(input dto.CreateStudentInput) (*model.Sis
 		NamaOrangtua:    input.NamaOrangTua,
 		TeleponOrangtua: input.TeleponOrangTua,
 		TahunMasuk:      input.TahunMasuk,
+		TanggalMasuk:    tglMasuk,
 		Status:          "aktif",
 	}
 	if err := studentRepoTx.Create(newStudent); err != nil {
@@ -151,6 +157,13 @@ func (s *studentService) UpdateStudent(id uint, input dto.UpdateStudentInput) (*
 	student.NamaOrangtua = input.NamaOrangTua
 	student.TeleponOrangtua = input.TeleponOrangTua
 	student.TahunMasuk = input.TahunMasuk
+	if input.TanggalMasuk != nil {
+		student.TanggalMasuk = nil
+		if *input.TanggalMasuk != "" {
+			tglMasuk, _ := time.Parse("2006-01-02", *input.TanggalMasuk)
+			student.TanggalMasuk = &tglMasuk
+		}
+	}
 	student.Status = input.Status
 
 	student.User.Email = input.EmailUser
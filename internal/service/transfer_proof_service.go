@@ -0,0 +1,326 @@
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/hiuncy/spp-payment-api/internal/dto"
+	"github.com/hiuncy/spp-payment-api/internal/model"
+	"github.com/hiuncy/spp-payment-api/internal/repository"
+	"github.com/hiuncy/spp-payment-api/internal/storage"
+	"github.com/hiuncy/spp-payment-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	TransferProofStatusMenunggu  = "menunggu"
+	TransferProofStatusDisetujui = "disetujui"
+	TransferProofStatusDitolak   = "ditolak"
+)
+
+var (
+	ErrInvalidTransferProof     = errors.New("bukti transfer tidak valid")
+	ErrTransferProofTooLarge    = errors.New("ukuran file bukti transfer melebihi batas")
+	ErrUnsupportedProofType     = errors.New("tipe file bukti transfer harus JPG, PNG, atau PDF")
+	ErrTransferProofNotWaiting  = errors.New("bukti transfer sudah diverifikasi")
+	ErrTransferProofRejectEmpty = errors.New("alasan penolakan wajib diisi")
+)
+
+var transferProofExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+type TransferProofService interface {
+	Upload(input dto.UploadTransferProofInput) (*model.BuktiTransfer, error)
+	FindAll(input dto.FindAllTransferProofsInput) ([]model.BuktiTransfer, int64, error)
+	FindByID(id uint) (*model.BuktiTransfer, error)
+	OpenFile(id uint) (*model.BuktiTransfer, io.ReadCloser, error)
+	Approve(id, verifierID uint) (*model.BuktiTransfer, error)
+	Reject(id, verifierID uint, reason string) (*model.BuktiTransfer, error)
+}
+
+type transferProofService struct {
+	repo        repository.TransferProofRepository
+	studentRepo repository.StudentRepository
+	storage     storage.Storage
+	maxFileSize int64
+	db          *gorm.DB
+}
+
+func NewTransferProofService(repo repository.TransferProofRepository, studentRepo repository.StudentRepository, storage storage.Storage, maxFileSize int64, db *gorm.DB) TransferProofService {
+	return &transferProofService{repo, studentRepo, storage, maxFileSize, db}
+}
+
+func (s *transferProofService) Upload(input dto.UploadTransferProofInput) (*model.BuktiTransfer, error) {
+	if input.Jumlah <= 0 {
+		return nil, fmt.Errorf("%w: jumlah transfer harus lebih dari 0", ErrInvalidTransferProof)
+	}
+	if input.Ukuran > s.maxFileSize {
+		return nil, fmt.Errorf("%w (maksimal %d KB)", ErrTransferProofTooLarge, s.maxFileSize/1024)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(input.File, s.maxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > s.maxFileSize {
+		return nil, fmt.Errorf("%w (maksimal %d KB)", ErrTransferProofTooLarge, s.maxFileSize/1024)
+	}
+	contentType := http.DetectContentType(content)
+	extension, ok := transferProofExtensions[contentType]
+	if !ok {
+		return nil, ErrUnsupportedProofType
+	}
+
+	transferredAt := time.Now()
+	if input.TanggalTransfer != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", input.TanggalTransfer, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%w: format tanggal transfer harus YYYY-MM-DD", ErrInvalidTransferProof)
+		}
+		if parsed.After(transferredAt) {
+			return nil, fmt.Errorf("%w: tanggal transfer tidak boleh di masa depan", ErrInvalidTransferProof)
+		}
+		transferredAt = parsed
+	}
+
+	student, err := s.studentRepo.FindByUserID(input.UserID)
+	if err != nil {
+		return nil, errors.New("profil siswa tidak ditemukan")
+	}
+
+	key, err := transferProofKey(extension)
+	if err != nil {
+		return nil, err
+	}
+
+	var proof *model.BuktiTransfer
+	var saved bool
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		billRepoTx := repository.NewBillRepository(tx)
+		paymentRepoTx := repository.NewPaymentRepository(tx)
+		repoTx := repository.NewTransferProofRepository(tx)
+
+		bills, err := billRepoTx.FindByIDsForUpdate([]uint{input.TagihanID})
+		if err != nil {
+			return err
+		}
+		if len(bills) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		bill := &bills[0]
+		if bill.SiswaID != student.ID {
+			return errors.New("tagihan ini bukan milik Anda")
+		}
+		if bill.StatusPembayaran == BillStatusLunas {
+			return ErrBillAlreadyPaid
+		}
+		if bill.StatusPembayaran == BillStatusDibebaskan {
+			return ErrBillWaived
+		}
+		if toCents(input.Jumlah) > toCents(billTotal(bill)-bill.JumlahTerbayar) {
+			return fmt.Errorf("%w: jumlah transfer melebihi sisa tagihan (%.0f)", ErrInvalidTransferProof, billTotal(bill)-bill.JumlahTerbayar)
+		}
+
+		pendings, err := paymentRepoTx.FindPendingByTagihanIDs([]uint{bill.ID})
+		if err != nil {
+			return err
+		}
+		if len(pendings) > 0 {
+			return ErrPendingPaymentExists
+		}
+
+		method := PaymentMethodManualTransfer
+		payment := &model.Pembayaran{
+			TagihanID:         bill.ID,
+			SiswaID:           student.ID,
+			OrderID:           fmt.Sprintf("BUKTI-%d-%d", bill.ID, time.Now().UnixNano()),
+			JumlahBayar:       input.Jumlah,
+			MetodePembayaran:  &method,
+			StatusPembayaran:  PaymentStatusPending,
+			TanggalPembayaran: &transferredAt,
+			DetailTagihan: []model.PembayaranTagihan{
+				{TagihanID: bill.ID, Jumlah: input.Jumlah},
+			},
+		}
+		if input.NomorReferensi != "" {
+			payment.NomorReferensi = &input.NomorReferensi
+		}
+		if input.Keterangan != "" {
+			payment.Keterangan = &input.Keterangan
+		}
+		if err := paymentRepoTx.Create(payment); err != nil {
+			return err
+		}
+
+		bill.StatusPembayaran = billStatusFor(billTotal(bill), bill.JumlahTerbayar, true)
+		if err := billRepoTx.Update(bill); err != nil {
+			return err
+		}
+
+		proof = &model.BuktiTransfer{
+			PembayaranID:     payment.ID,
+			TagihanID:        bill.ID,
+			SiswaID:          student.ID,
+			NamaFile:         path.Base(input.NamaFile),
+			LokasiFile:       key,
+			TipeKonten:       contentType,
+			UkuranFile:       int64(len(content)),
+			StatusVerifikasi: TransferProofStatusMenunggu,
+		}
+		if err := repoTx.Create(proof); err != nil {
+			return err
+		}
+
+		if err := s.storage.Save(key, bytes.NewReader(content)); err != nil {
+			return err
+		}
+		saved = true
+		return nil
+	})
+	if err != nil {
+		if saved {
+			if deleteErr := s.storage.Delete(key); deleteErr != nil {
+				return nil, errors.Join(err, deleteErr)
+			}
+		}
+		return nil, err
+	}
+
+	return s.repo.FindByID(proof.ID)
+}
+
+func (s *transferProofService) FindAll(input dto.FindAllTransferProofsInput) ([]model.BuktiTransfer, int64, error) {
+	if input.Page <= 0 {
+		input.Page = 1
+	}
+	if input.Limit <= 0 {
+		input.Limit = 10
+	}
+	params := utils.FindAllTransferProofsParams{
+		Page:             input.Page,
+		Limit:            input.Limit,
+		SiswaID:          input.SiswaID,
+		StatusVerifikasi: input.StatusVerifikasi,
+	}
+	if input.UserID != 0 {
+		student, err := s.studentRepo.FindByUserID(input.UserID)
+		if err != nil {
+			return nil, 0, errors.New("profil siswa tidak ditemukan")
+		}
+		params.SiswaID = student.ID
+	}
+	return s.repo.FindAll(params)
+}
+
+func (s *transferProofService) FindByID(id uint) (*model.BuktiTransfer, error) {
+	return s.repo.FindByID(id)
+}
+
+func (s *transferProofService) OpenFile(id uint) (*model.BuktiTransfer, io.ReadCloser, error) {
+	proof, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := s.storage.Open(proof.LokasiFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return proof, file, nil
+}
+
+func (s *transferProofService) Approve(id, verifierID uint) (*model.BuktiTransfer, error) {
+	return s.verify(id, verifierID, func(tx *gorm.DB, proof *model.BuktiTransfer, bills []model.TagihanSPP, payment *model.Pembayaran) error {
+		now := time.Now()
+		payment.TanggalSettlement = &now
+		payment.DiterimaOleh = &verifierID
+		update := dto.PaymentStatusUpdate{
+			OrderID:           payment.OrderID,
+			TransactionStatus: PaymentStatusSettlement,
+			PaymentType:       PaymentMethodManualTransfer,
+		}
+		if err := transitionPayment(tx, bills, payment, update); err != nil {
+			return err
+		}
+		proof.StatusVerifikasi = TransferProofStatusDisetujui
+		return nil
+	})
+}
+
+func (s *transferProofService) Reject(id, verifierID uint, reason string) (*model.BuktiTransfer, error) {
+	if reason == "" {
+		return nil, ErrTransferProofRejectEmpty
+	}
+	return s.verify(id, verifierID, func(tx *gorm.DB, proof *model.BuktiTransfer, bills []model.TagihanSPP, payment *model.Pembayaran) error {
+		payment.Keterangan = &reason
+		update := dto.PaymentStatusUpdate{
+			OrderID:           payment.OrderID,
+			TransactionStatus: PaymentStatusDeny,
+		}
+		if err := transitionPayment(tx, bills, payment, update); err != nil {
+			return err
+		}
+		proof.StatusVerifikasi = TransferProofStatusDitolak
+		proof.AlasanPenolakan = &reason
+		return nil
+	})
+}
+
+func (s *transferProofService) verify(id, verifierID uint, apply func(tx *gorm.DB, proof *model.BuktiTransfer, bills []model.TagihanSPP, payment *model.Pembayaran) error) (*model.BuktiTransfer, error) {
+	current, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		billRepoTx := repository.NewBillRepository(tx)
+		paymentRepoTx := repository.NewPaymentRepository(tx)
+		repoTx := repository.NewTransferProofRepository(tx)
+
+		bills, err := billRepoTx.FindByIDsForUpdate([]uint{current.TagihanID})
+		if err != nil {
+			return err
+		}
+		payment, err := paymentRepoTx.FindByOrderIDForUpdate(current.Pembayaran.OrderID)
+		if err != nil {
+			return err
+		}
+		proof, err := repoTx.FindByIDForUpdate(id)
+		if err != nil {
+			return err
+		}
+		if proof.StatusVerifikasi != TransferProofStatusMenunggu || payment.StatusPembayaran != PaymentStatusPending {
+			return ErrTransferProofNotWaiting
+		}
+
+		if err := apply(tx, proof, bills, payment); err != nil {
+			return err
+		}
+		now := time.Now()
+		proof.DiverifikasiOleh = &verifierID
+		proof.TanggalVerifikasi = &now
+		return repoTx.Update(proof)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindByID(id)
+}
+
+func transferProofKey(extension string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return path.Join("bukti-transfer", time.Now().Format("2006/01"), hex.EncodeToString(random)+extension), nil
+}
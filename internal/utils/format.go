@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+var namaBulan = []string{"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"}
+
+func FormatRupiah(amount float64) string {
+	digits := fmt.Sprintf("%d", int64(math.Round(math.Abs(amount))))
+	var groups []string
+	for len(digits) > 3 {
+		groups = append([]string{digits[len(digits)-3:]}, groups...)
+		digits = digits[:len(digits)-3]
+	}
+	groups = append([]string{digits}, groups...)
+
+	result := "Rp " + strings.Join(groups, ".")
+	if amount < 0 {
+		result = "-" + result
+	}
+	return result
+}
+
+func FormatTanggal(t time.Time) string {
+	return fmt.Sprintf("%d %s %d", t.Day(), namaBulan[t.Month()-1], t.Year())
+}
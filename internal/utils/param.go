@@ -6,6 +6,28 @@ type FindAllBillsParams struct {
 	PeriodeID        uint
 	SiswaID          uint
 	StatusPembayaran string
+	JenisBiayaID     uint
+}
+
+type GenerateBillsParams struct {
+	KelasIDs   []uint
+	TingkatIDs []uint
+	SiswaIDs   []uint
+}
+
+type WaiveBillsParams struct {
+	TagihanIDs   []uint
+	PeriodeID    uint
+	JenisBiayaID uint
+	KelasIDs     []uint
+	TingkatIDs   []uint
+	SiswaIDs     []uint
+}
+
+type FindAllFeeSchedulesParams struct {
+	TahunAjaranID uint
+	JenisBiayaID  uint
+	TingkatID     uint
 }
 
 type FindAllStudentsParams struct {
@@ -21,3 +43,24 @@ type FindAllUsersParams struct {
 	RoleID uint
 	Search string
 }
+
+type FindAllNotificationsParams struct {
+	Limit        int
+	Page         int
+	OrderID      string
+	StatusProses string
+}
+
+type FindAllTransferProofsParams struct {
+	Limit            int
+	Page             int
+	SiswaID          uint
+	StatusVerifikasi string
+}
+
+type FindAllRefundsParams struct {
+	Limit        int
+	Page         int
+	OrderID      string
+	StatusRefund string
+}
@@ -47,20 +47,32 @@ type LoginRequest struct {
 }
 
 type PeriodRequest struct {
-	TahunAjaran    string `json:"tahun_ajaran" binding:"required"`
-	Bulan          int    `json:"bulan" binding:"required,gte=1,lte=12"`
-	NamaBulan      string `json:"nama_bulan" binding:"required"`
-	TanggalMulai   string `json:"tanggal_mulai" binding:"required"`
-	TanggalSelesai string `json:"tanggal_selesai" binding:"required"`
+	TahunAjaranID     uint   `json:"tahun_ajaran_id" binding:"required"`
+	Bulan             int    `json:"bulan" binding:"required,gte=1,lte=12"`
+	TanggalMulai      string `json:"tanggal_mulai" binding:"required"`
+	TanggalSelesai    string `json:"tanggal_selesai" binding:"required"`
+	TanggalJatuhTempo string `json:"tanggal_jatuh_tempo"`
 }
 
 type UpdatePeriodRequest struct {
-	TahunAjaran    string `json:"tahun_ajaran" binding:"required"`
-	Bulan          int    `json:"bulan" binding:"required,gte=1,lte=12"`
-	NamaBulan      string `json:"nama_bulan" binding:"required"`
-	TanggalMulai   string `json:"tanggal_mulai" binding:"required"`
-	TanggalSelesai string `json:"tanggal_selesai" binding:"required"`
-	Status         string `json:"status" binding:"required,oneof=belum_aktif aktif selesai"`
+	TahunAjaranID     uint   `json:"tahun_ajaran_id" binding:"required"`
+	Bulan             int    `json:"bulan" binding:"required,gte=1,lte=12"`
+	TanggalMulai      string `json:"tanggal_mulai" binding:"required"`
+	TanggalSelesai    string `json:"tanggal_selesai" binding:"required"`
+	TanggalJatuhTempo string `json:"tanggal_jatuh_tempo"`
+	Status            string `json:"status" binding:"required,oneof=belum_aktif aktif selesai"`
+}
+
+type AcademicYearRequest struct {
+	NamaTahunAjaran string `json:"nama_tahun_ajaran" binding:"required"`
+	TanggalMulai    string `json:"tanggal_mulai" binding:"required"`
+	TanggalSelesai  string `json:"tanggal_selesai" binding:"required"`
+	Aktif           bool   `json:"aktif"`
+}
+
+type GeneratePeriodsRequest struct {
+	Bulan             []int `json:"bulan" binding:"omitempty,dive,gte=1,lte=12"`
+	TanggalJatuhTempo int   `json:"tanggal_jatuh_tempo" binding:"gte=0,lte=31"`
 }
 
 type CreateStudentRequest struct {
@@ -76,25 +88,160 @@ type CreateStudentRequest struct {
 	NamaOrangTua    string `json:"nama_orangtua"`
 	TeleponOrangTua string `json:"telepon_orangtua"`
 	TahunMasuk      int    `json:"tahun_masuk"`
+	TanggalMasuk    string `json:"tanggal_masuk" binding:"omitempty,datetime=2006-01-02"`
 }
 
 type UpdateStudentRequest struct {
-	NISN            string `json:"nisn" binding:"required"`
-	KelasID         uint   `json:"kelas_id" binding:"required"`
-	NamaLengkap     string `json:"nama_lengkap" binding:"required"`
-	JenisKelamin    string `json:"jenis_kelamin" binding:"required,oneof=L P"`
-	TempatLahir     string `json:"tempat_lahir"`
-	TanggalLahir    string `json:"tanggal_lahir"`
-	Alamat          string `json:"alamat"`
+	NISN            string  `json:"nisn" binding:"required"`
+	KelasID         uint    `json:"kelas_id" binding:"required"`
+	NamaLengkap     string  `json:"nama_lengkap" binding:"required"`
+	JenisKelamin    string  `json:"jenis_kelamin" binding:"required,oneof=L P"`
+	TempatLahir     string  `json:"tempat_lahir"`
+	TanggalLahir    string  `json:"tanggal_lahir"`
+	Alamat          string  `json:"alamat"`
+	NamaOrangTua    string  `json:"nama_orangtua"`
+	TeleponOrangTua string  `json:"telepon_orangtua"`
+	TahunMasuk      int     `json:"tahun_masuk"`
+	TanggalMasuk    *string `json:"tanggal_masuk" binding:"omitempty,len=0|datetime=2006-01-02"`
+	Status          string  `json:"status" binding:"required,oneof=aktif pindah lulus keluar"`
+	EmailUser       string  `json:"email" binding:"required,email"`
+	StatusUser      string  `json:"status_user" binding:"required,oneof=aktif nonaktif"`
+}
+
+type UpdateBillRequest struct {
+	JumlahTagihan float64 `json:"jumlah_tagihan" binding:"required,gt=0"`
+}
+
+type ManualPaymentRequest struct {
+	MetodePembayaran string  `json:"metode_pembayaran" binding:"required,oneof=tunai transfer_manual"`
+	Jumlah           float64 `json:"jumlah" binding:"required,gt=0"`
+	TanggalDiterima  string  `json:"tanggal_diterima"`
+	NomorReferensi   string  `json:"nomor_referensi"`
+	Keterangan       string  `json:"keterangan"`
+}
+
+type InitiatePaymentRequest struct {
+	Jumlah           float64 `json:"jumlah" binding:"omitempty,gt=0"`
+	MetodePembayaran string  `json:"metode_pembayaran"`
+}
+
+type InstallmentRequest struct {
+	Jumlah            float64 `json:"jumlah" binding:"required,gt=0"`
+	TanggalJatuhTempo string  `json:"tanggal_jatuh_tempo" binding:"required"`
+}
+
+type InstallmentPlanRequest struct {
+	Cicilan []InstallmentRequest `json:"cicilan" binding:"required,min=2,dive"`
+}
+
+type UploadTransferProofRequest struct {
+	Jumlah          float64 `form:"jumlah" binding:"required,gt=0"`
+	TanggalTransfer string  `form:"tanggal_transfer"`
+	NomorReferensi  string  `form:"nomor_referensi"`
+	Keterangan      string  `form:"keterangan"`
+}
+
+type RejectTransferProofRequest struct {
+	Alasan string `json:"alasan" binding:"required"`
+}
+
+type RefundRequest struct {
+	Jumlah float64 `json:"jumlah" binding:"omitempty,gt=0"`
+	Alasan string  `json:"alasan" binding:"required"`
+}
+
+type RejectRefundRequest struct {
+	Alasan string `json:"alasan" binding:"required"`
+}
+
+type WaiveLateFeeRequest struct {
+	Alasan string `json:"alasan" binding:"required"`
+}
+
+type WaiveBillsRequest struct {
+	TagihanIDs   []uint `json:"tagihan_ids"`
+	PeriodeID    uint   `json:"periode_id"`
+	JenisBiayaID uint   `json:"jenis_biaya_id"`
+	KelasIDs     []uint `json:"kelas_ids"`
+	TingkatIDs   []uint `json:"tingkat_ids"`
+	SiswaIDs     []uint `json:"siswa_ids"`
+	Alasan       string `json:"alasan" binding:"required"`
+}
+
+type ExemptionRequest struct {
+	PeriodeID    uint   `json:"periode_id" binding:"required"`
+	JenisBiayaID *uint  `json:"jenis_biaya_id"`
+	TingkatID    *uint  `json:"tingkat_id"`
+	KelasID      *uint  `json:"kelas_id"`
+	SiswaID      *uint  `json:"siswa_id"`
+	Alasan       string `json:"alasan" binding:"required"`
+}
+
+type CheckoutRequest struct {
+	TagihanIDs       []uint `json:"tagihan_ids" binding:"required,min=1"`
+	MetodePembayaran string `json:"metode_pembayaran"`
+}
+
+type DiscountTypeRequest struct {
+	NamaPotongan     string   `json:"nama_potongan" binding:"required"`
+	JenisBiayaID     uint     `json:"jenis_biaya_id"`
+	TipePotongan     string   `json:"tipe_potongan" binding:"required,oneof=persen nominal"`
+	Nilai            float64  `json:"nilai" binding:"required,gt=0"`
+	MaksimalPotongan *float64 `json:"maksimal_potongan" binding:"omitempty,gte=0"`
+	Keterangan       string   `json:"keterangan"`
+	Status           string   `json:"status" binding:"omitempty,oneof=aktif nonaktif"`
+}
+
+type StudentDiscountRequest struct {
+	JenisPotonganID  uint   `json:"jenis_potongan_id" binding:"required"`
+	PeriodeMulaiID   uint   `json:"periode_mulai_id" binding:"required"`
+	PeriodeSelesaiID *uint  `json:"periode_selesai_id"`
+	Keterangan       string `json:"keterangan"`
+}
+
+type FamilyRequest struct {
+	NamaKeluarga    string `json:"nama_keluarga"`
 	NamaOrangTua    string `json:"nama_orangtua"`
 	TeleponOrangTua string `json:"telepon_orangtua"`
-	TahunMasuk      int    `json:"tahun_masuk"`
-	Status          string `json:"status" binding:"required,oneof=aktif pindah lulus keluar"`
-	EmailUser       string `json:"email" binding:"required,email"`
-	StatusUser      string `json:"status_user" binding:"required,oneof=aktif nonaktif"`
+	Keterangan      string `json:"keterangan"`
+	SiswaIDs        []uint `json:"siswa_ids" binding:"required,min=1"`
 }
 
-type UpdateBillRequest struct {
-	JumlahTagihan    float64 `json:"jumlah_tagihan" binding:"required,gt=0"`
-	StatusPembayaran string  `json:"status_pembayaran" binding:"required,oneof=belum_bayar pending lunas"`
+type GenerateBillsRequest struct {
+	JenisBiayaID uint   `json:"jenis_biaya_id"`
+	KelasIDs     []uint `json:"kelas_ids"`
+	TingkatIDs   []uint `json:"tingkat_ids"`
+	SiswaIDs     []uint `json:"siswa_ids"`
+	DryRun       bool   `json:"dry_run"`
+}
+
+type FeeTypeRequest struct {
+	KodeBiaya    string           `json:"kode_biaya" binding:"required,max=30"`
+	NamaBiaya    string           `json:"nama_biaya" binding:"required"`
+	Periodisitas string           `json:"periodisitas" binding:"required,oneof=bulanan semester sekali"`
+	Wajib        *bool            `json:"wajib" binding:"required"`
+	Keterangan   string           `json:"keterangan"`
+	Status       string           `json:"status" binding:"omitempty,oneof=aktif nonaktif"`
+	Tarif        []FeeRateRequest `json:"tarif" binding:"dive"`
+}
+
+type FeeRateRequest struct {
+	TingkatID uint    `json:"tingkat_id" binding:"required"`
+	Jumlah    float64 `json:"jumlah" binding:"required,gt=0"`
+}
+
+type FeeScheduleRequest struct {
+	JenisBiayaID  uint    `json:"jenis_biaya_id" binding:"required"`
+	TingkatID     uint    `json:"tingkat_id" binding:"required"`
+	TahunAjaranID uint    `json:"tahun_ajaran_id" binding:"required"`
+	Jumlah        float64 `json:"jumlah" binding:"required,gt=0"`
+	BerlakuMulai  string  `json:"berlaku_mulai" binding:"omitempty,datetime=2006-01-02"`
+	Keterangan    string  `json:"keterangan" binding:"max=255"`
+}
+
+type FeeIncreasePreviewRequest struct {
+	TahunAjaranID  uint             `json:"tahun_ajaran_id" binding:"required"`
+	JenisBiayaID   uint             `json:"jenis_biaya_id"`
+	KenaikanPersen float64          `json:"kenaikan_persen" binding:"gte=-100"`
+	Tarif          []FeeRateRequest `json:"tarif" binding:"dive"`
 }
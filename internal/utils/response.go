@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -26,13 +29,34 @@ type UserResponse struct {
 }
 
 type PaymentHistoryResponse struct {
-	OrderID           string     `json:"order_id"`
-	NamaPeriode       string     `json:"nama_periode"`
-	TahunAjaran       string     `json:"tahun_ajaran"`
-	JumlahBayar       float64    `json:"jumlah_bayar"`
-	StatusPembayaran  string     `json:"status_pembayaran"`
-	MetodePembayaran  *string    `json:"metode_pembayaran,omitempty"`
-	TanggalPembayaran *time.Time `json:"tanggal_pembayaran,omitempty"`
+	OrderID           string                       `json:"order_id"`
+	NamaPeriode       string                       `json:"nama_periode"`
+	TahunAjaran       string                       `json:"tahun_ajaran"`
+	JumlahBayar       float64                      `json:"jumlah_bayar"`
+	JumlahRefund      float64                      `json:"jumlah_refund,omitempty"`
+	BiayaAdmin        float64                      `json:"biaya_admin,omitempty"`
+	StatusPembayaran  string                       `json:"status_pembayaran"`
+	MetodePembayaran  *string                      `json:"metode_pembayaran,omitempty"`
+	TanggalPembayaran *time.Time                   `json:"tanggal_pembayaran,omitempty"`
+	NomorKuitansi     *string                      `json:"nomor_kuitansi,omitempty"`
+	BankVA            *string                      `json:"bank_va,omitempty"`
+	NomorVA           *string                      `json:"nomor_va,omitempty"`
+	BatasPembayaran   *time.Time                   `json:"batas_pembayaran,omitempty"`
+	Tagihan           []PaymentHistoryBillResponse `json:"tagihan"`
+}
+
+type PaymentInstructionResponse struct {
+	SnapToken       string     `json:"snap_token,omitempty"`
+	Bank            string     `json:"bank,omitempty"`
+	NomorVA         string     `json:"nomor_va,omitempty"`
+	BatasPembayaran *time.Time `json:"batas_pembayaran,omitempty"`
+}
+
+type PaymentHistoryBillResponse struct {
+	TagihanID   uint    `json:"tagihan_id"`
+	NamaPeriode string  `json:"nama_periode"`
+	TahunAjaran string  `json:"tahun_ajaran"`
+	Jumlah      float64 `json:"jumlah"`
 }
 
 type StudentResponse struct {
@@ -43,6 +67,7 @@ type StudentResponse struct {
 	Status          string     `json:"status"`
 	KelasID         uint       `json:"kelas_id"`
 	NamaKelas       string     `json:"nama_kelas"`
+	KeluargaID      *uint      `json:"keluarga_id,omitempty"`
 	JenisKelamin    string     `json:"jenis_kelamin"`
 	TempatLahir     string     `json:"tempat_lahir,omitempty"`
 	TanggalLahir    *time.Time `json:"tanggal_lahir,omitempty"`
@@ -50,20 +75,260 @@ type StudentResponse struct {
 	NamaOrangTua    string     `json:"nama_orangtua,omitempty"`
 	TeleponOrangTua string     `json:"telepon_orangtua,omitempty"`
 	TahunMasuk      int        `json:"tahun_masuk,omitempty"`
+	TanggalMasuk    *time.Time `json:"tanggal_masuk,omitempty"`
+}
+
+type FamilyResponse struct {
+	ID              uint                   `json:"id"`
+	NamaKeluarga    string                 `json:"nama_keluarga"`
+	NamaOrangTua    string                 `json:"nama_orangtua"`
+	TeleponOrangTua string                 `json:"telepon_orangtua"`
+	Keterangan      *string                `json:"keterangan,omitempty"`
+	Anggota         []FamilyMemberResponse `json:"anggota"`
+}
+
+type FamilyMemberResponse struct {
+	ID           uint       `json:"id"`
+	NISN         string     `json:"nisn"`
+	NamaLengkap  string     `json:"nama_lengkap"`
+	NamaKelas    string     `json:"nama_kelas"`
+	TanggalLahir *time.Time `json:"tanggal_lahir,omitempty"`
+	Status       string     `json:"status"`
+	KeluargaID   *uint      `json:"keluarga_id,omitempty"`
+	UrutanAnak   int        `json:"urutan_anak,omitempty"`
+}
+
+type FamilySuggestionResponse struct {
+	NamaOrangTua    string                 `json:"nama_orangtua"`
+	TeleponOrangTua string                 `json:"telepon_orangtua"`
+	Siswa           []FamilyMemberResponse `json:"siswa"`
 }
 
 type BillResponse struct {
+	ID                uint                   `json:"id"`
+	SiswaID           uint                   `json:"siswa_id"`
+	NamaSiswa         string                 `json:"nama_siswa"`
+	PeriodeID         uint                   `json:"periode_id"`
+	NamaPeriode       string                 `json:"nama_periode"`
+	TahunAjaran       string                 `json:"tahun_ajaran"`
+	JenisBiayaID      uint                   `json:"jenis_biaya_id"`
+	KodeBiaya         string                 `json:"kode_biaya"`
+	NamaBiaya         string                 `json:"nama_biaya"`
+	JumlahAwal        float64                `json:"jumlah_awal"`
+	JumlahProrata     float64                `json:"jumlah_prorata"`
+	KeteranganProrata *string                `json:"keterangan_prorata,omitempty"`
+	JumlahPotongan    float64                `json:"jumlah_potongan"`
+	JumlahTagihan     float64                `json:"jumlah_tagihan"`
+	JumlahDenda       float64                `json:"jumlah_denda"`
+	JumlahTerbayar    float64                `json:"jumlah_terbayar"`
+	SisaTagihan       float64                `json:"sisa_tagihan"`
+	StatusPembayaran  string                 `json:"status_pembayaran"`
+	TanggalJatuhTempo time.Time              `json:"tanggal_jatuh_tempo"`
+	DibebaskanOleh    *uint                  `json:"dibebaskan_oleh,omitempty"`
+	AlasanPembebasan  *string                `json:"alasan_pembebasan,omitempty"`
+	TanggalDibebaskan *time.Time             `json:"tanggal_dibebaskan,omitempty"`
+	Cicilan           []InstallmentResponse  `json:"cicilan,omitempty"`
+	Denda             []LateFeeResponse      `json:"denda,omitempty"`
+	Potongan          []BillDiscountResponse `json:"potongan,omitempty"`
+}
+
+type BillGroupResponse struct {
+	JenisBiayaID uint           `json:"jenis_biaya_id"`
+	KodeBiaya    string         `json:"kode_biaya"`
+	NamaBiaya    string         `json:"nama_biaya"`
+	TotalTagihan float64        `json:"total_tagihan"`
+	SisaTagihan  float64        `json:"sisa_tagihan"`
+	Tagihan      []BillResponse `json:"tagihan"`
+}
+
+type BillDiscountResponse struct {
+	JenisPotonganID uint    `json:"jenis_potongan_id"`
+	NamaPotongan    string  `json:"nama_potongan"`
+	Jumlah          float64 `json:"jumlah"`
+}
+
+type DiscountTypeResponse struct {
+	ID               uint     `json:"id"`
+	NamaPotongan     string   `json:"nama_potongan"`
+	JenisBiayaID     uint     `json:"jenis_biaya_id"`
+	TipePotongan     string   `json:"tipe_potongan"`
+	Nilai            float64  `json:"nilai"`
+	MaksimalPotongan *float64 `json:"maksimal_potongan,omitempty"`
+	Keterangan       *string  `json:"keterangan,omitempty"`
+	Status           string   `json:"status"`
+}
+
+type FeeTypeResponse struct {
+	ID           uint              `json:"id"`
+	KodeBiaya    string            `json:"kode_biaya"`
+	NamaBiaya    string            `json:"nama_biaya"`
+	Periodisitas string            `json:"periodisitas"`
+	Wajib        bool              `json:"wajib"`
+	Keterangan   *string           `json:"keterangan,omitempty"`
+	Status       string            `json:"status"`
+	Tarif        []FeeRateResponse `json:"tarif"`
+}
+
+type FeeRateResponse struct {
+	TingkatID   uint    `json:"tingkat_id"`
+	NamaTingkat string  `json:"nama_tingkat"`
+	Jumlah      float64 `json:"jumlah"`
+}
+
+type FeeScheduleResponse struct {
+	ID              uint      `json:"id"`
+	JenisBiayaID    uint      `json:"jenis_biaya_id"`
+	NamaBiaya       string    `json:"nama_biaya"`
+	TingkatID       uint      `json:"tingkat_id"`
+	NamaTingkat     string    `json:"nama_tingkat"`
+	TahunAjaranID   uint      `json:"tahun_ajaran_id"`
+	NamaTahunAjaran string    `json:"nama_tahun_ajaran"`
+	Jumlah          float64   `json:"jumlah"`
+	BerlakuMulai    time.Time `json:"berlaku_mulai"`
+	Keterangan      *string   `json:"keterangan,omitempty"`
+}
+
+type AcademicYearResponse struct {
+	ID              uint      `json:"id"`
+	NamaTahunAjaran string    `json:"nama_tahun_ajaran"`
+	TanggalMulai    time.Time `json:"tanggal_mulai"`
+	TanggalSelesai  time.Time `json:"tanggal_selesai"`
+	Aktif           bool      `json:"aktif"`
+}
+
+type StudentDiscountResponse struct {
+	ID               uint                 `json:"id"`
+	SiswaID          uint                 `json:"siswa_id"`
+	JenisPotongan    DiscountTypeResponse `json:"jenis_potongan"`
+	PeriodeMulaiID   uint                 `json:"periode_mulai_id"`
+	PeriodeMulai     string               `json:"periode_mulai"`
+	PeriodeSelesaiID *uint                `json:"periode_selesai_id,omitempty"`
+	PeriodeSelesai   *string              `json:"periode_selesai,omitempty"`
+	Keterangan       *string              `json:"keterangan,omitempty"`
+}
+
+type ExemptionResponse struct {
+	ID           uint      `json:"id"`
+	PeriodeID    uint      `json:"periode_id"`
+	NamaPeriode  string    `json:"nama_periode"`
+	TahunAjaran  string    `json:"tahun_ajaran"`
+	JenisBiayaID *uint     `json:"jenis_biaya_id,omitempty"`
+	NamaBiaya    *string   `json:"nama_biaya,omitempty"`
+	TingkatID    *uint     `json:"tingkat_id,omitempty"`
+	NamaTingkat  *string   `json:"nama_tingkat,omitempty"`
+	KelasID      *uint     `json:"kelas_id,omitempty"`
+	NamaKelas    *string   `json:"nama_kelas,omitempty"`
+	SiswaID      *uint     `json:"siswa_id,omitempty"`
+	NamaSiswa    *string   `json:"nama_siswa,omitempty"`
+	Alasan       string    `json:"alasan"`
+	DibuatOleh   uint      `json:"dibuat_oleh"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type LateFeeResponse struct {
+	ID                 uint       `json:"id"`
+	Urutan             int        `json:"urutan"`
+	TanggalDenda       time.Time  `json:"tanggal_denda"`
+	Jumlah             float64    `json:"jumlah"`
+	Keterangan         string     `json:"keterangan"`
+	StatusDenda        string     `json:"status_denda"`
+	AlasanPenghapusan  *string    `json:"alasan_penghapusan,omitempty"`
+	DihapuskanOleh     *uint      `json:"dihapuskan_oleh,omitempty"`
+	TanggalPenghapusan *time.Time `json:"tanggal_penghapusan,omitempty"`
+}
+
+type InstallmentResponse struct {
 	ID                uint      `json:"id"`
-	SiswaID           uint      `json:"siswa_id"`
-	NamaSiswa         string    `json:"nama_siswa"`
-	PeriodeID         uint      `json:"periode_id"`
-	NamaPeriode       string    `json:"nama_periode"`
-	TahunAjaran       string    `json:"tahun_ajaran"`
-	JumlahTagihan     float64   `json:"jumlah_tagihan"`
+	Urutan            int       `json:"urutan"`
+	Jumlah            float64   `json:"jumlah"`
+	JumlahTerbayar    float64   `json:"jumlah_terbayar"`
 	StatusPembayaran  string    `json:"status_pembayaran"`
 	TanggalJatuhTempo time.Time `json:"tanggal_jatuh_tempo"`
 }
 
+type PaymentResponse struct {
+	ID                uint       `json:"id"`
+	OrderID           string     `json:"order_id"`
+	TagihanID         uint       `json:"tagihan_id"`
+	SiswaID           uint       `json:"siswa_id"`
+	JumlahBayar       float64    `json:"jumlah_bayar"`
+	JumlahRefund      float64    `json:"jumlah_refund,omitempty"`
+	BiayaAdmin        float64    `json:"biaya_admin,omitempty"`
+	MetodePembayaran  *string    `json:"metode_pembayaran,omitempty"`
+	StatusPembayaran  string     `json:"status_pembayaran"`
+	TanggalPembayaran *time.Time `json:"tanggal_pembayaran,omitempty"`
+	TanggalSettlement *time.Time `json:"tanggal_settlement,omitempty"`
+	NomorReferensi    *string    `json:"nomor_referensi,omitempty"`
+	NomorKuitansi     *string    `json:"nomor_kuitansi,omitempty"`
+	DiterimaOleh      *uint      `json:"diterima_oleh,omitempty"`
+	Keterangan        *string    `json:"keterangan,omitempty"`
+}
+
+type TransferProofResponse struct {
+	ID                uint       `json:"id"`
+	TagihanID         uint       `json:"tagihan_id"`
+	SiswaID           uint       `json:"siswa_id"`
+	NamaSiswa         string     `json:"nama_siswa"`
+	NamaPeriode       string     `json:"nama_periode"`
+	TahunAjaran       string     `json:"tahun_ajaran"`
+	OrderID           string     `json:"order_id"`
+	JumlahBayar       float64    `json:"jumlah_bayar"`
+	TanggalTransfer   *time.Time `json:"tanggal_transfer,omitempty"`
+	NomorReferensi    *string    `json:"nomor_referensi,omitempty"`
+	NamaFile          string     `json:"nama_file"`
+	TipeKonten        string     `json:"tipe_konten"`
+	UkuranFile        int64      `json:"ukuran_file"`
+	StatusVerifikasi  string     `json:"status_verifikasi"`
+	AlasanPenolakan   *string    `json:"alasan_penolakan,omitempty"`
+	DiverifikasiOleh  *uint      `json:"diverifikasi_oleh,omitempty"`
+	TanggalVerifikasi *time.Time `json:"tanggal_verifikasi,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+type RefundResponse struct {
+	ID               uint       `json:"id"`
+	OrderID          string     `json:"order_id"`
+	SiswaID          uint       `json:"siswa_id"`
+	NamaSiswa        string     `json:"nama_siswa"`
+	JumlahBayar      float64    `json:"jumlah_bayar"`
+	Jumlah           float64    `json:"jumlah"`
+	Alasan           string     `json:"alasan"`
+	MetodeRefund     string     `json:"metode_refund"`
+	StatusRefund     string     `json:"status_refund"`
+	DiajukanOleh     uint       `json:"diajukan_oleh"`
+	DisetujuiOleh    *uint      `json:"disetujui_oleh,omitempty"`
+	TanggalDisetujui *time.Time `json:"tanggal_disetujui,omitempty"`
+	AlasanPenolakan  *string    `json:"alasan_penolakan,omitempty"`
+	Keterangan       *string    `json:"keterangan,omitempty"`
+	TanggalRefund    *time.Time `json:"tanggal_refund,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+type NotificationResponse struct {
+	ID                uint       `json:"id"`
+	OrderID           string     `json:"order_id"`
+	TransactionID     *string    `json:"transaction_id,omitempty"`
+	TransactionStatus string     `json:"transaction_status"`
+	StatusProses      string     `json:"status_proses"`
+	Keterangan        *string    `json:"keterangan,omitempty"`
+	JumlahPercobaan   int        `json:"jumlah_percobaan"`
+	RawBody           string     `json:"raw_body"`
+	Headers           *string    `json:"headers,omitempty"`
+	TanggalDiterima   time.Time  `json:"tanggal_diterima"`
+	TanggalDiproses   *time.Time `json:"tanggal_diproses,omitempty"`
+}
+
+type ReconciliationResponse struct {
+	ID               uint            `json:"id"`
+	Pemicu           string          `json:"pemicu"`
+	JumlahDiperiksa  int             `json:"jumlah_diperiksa"`
+	JumlahDiperbarui int             `json:"jumlah_diperbarui"`
+	JumlahGagal      int             `json:"jumlah_gagal"`
+	Detail           json.RawMessage `json:"detail,omitempty"`
+	TanggalMulai     time.Time       `json:"tanggal_mulai"`
+	TanggalSelesai   *time.Time      `json:"tanggal_selesai,omitempty"`
+}
+
 func FormatClassResponse(class *model.Kelas) ClassResponse {
 	return ClassResponse{
 		ID:          class.ID,
@@ -86,6 +351,7 @@ func FormatStudentResponse(student *model.Siswa) StudentResponse {
 		Status:          student.Status,
 		KelasID:         student.KelasID,
 		NamaKelas:       student.Kelas.NamaKelas,
+		KeluargaID:      student.KeluargaID,
 		JenisKelamin:    student.JenisKelamin,
 		TempatLahir:     student.TempatLahir,
 		TanggalLahir:    student.TanggalLahir,
@@ -93,23 +359,333 @@ func FormatStudentResponse(student *model.Siswa) StudentResponse {
 		NamaOrangTua:    student.NamaOrangtua,
 		TeleponOrangTua: student.TeleponOrangtua,
 		TahunMasuk:      student.TahunMasuk,
+		TanggalMasuk:    student.TanggalMasuk,
+	}
+}
+
+func FormatFamilyResponse(family *model.Keluarga) FamilyResponse {
+	response := FamilyResponse{
+		ID:              family.ID,
+		NamaKeluarga:    family.NamaKeluarga,
+		NamaOrangTua:    family.NamaOrangtua,
+		TeleponOrangTua: family.TeleponOrangtua,
+		Keterangan:      family.Keterangan,
+		Anggota:         []FamilyMemberResponse{},
+	}
+	rank := 0
+	for _, student := range family.Anggota {
+		member := FormatFamilyMemberResponse(&student)
+		if student.Status == "aktif" {
+			rank++
+			member.UrutanAnak = rank
+		}
+		response.Anggota = append(response.Anggota, member)
+	}
+	return response
+}
+
+func FormatFamilyMemberResponse(student *model.Siswa) FamilyMemberResponse {
+	return FamilyMemberResponse{
+		ID:           student.ID,
+		NISN:         student.NISN,
+		NamaLengkap:  student.NamaLengkap,
+		NamaKelas:    student.Kelas.NamaKelas,
+		TanggalLahir: student.TanggalLahir,
+		Status:       student.Status,
+		KeluargaID:   student.KeluargaID,
 	}
 }
 
 func FormatBillResponse(bill *model.TagihanSPP) BillResponse {
-	return BillResponse{
+	response := BillResponse{
 		ID:                bill.ID,
 		SiswaID:           bill.SiswaID,
 		NamaSiswa:         bill.Siswa.NamaLengkap,
 		PeriodeID:         bill.PeriodeID,
 		NamaPeriode:       bill.PeriodeSPP.NamaBulan,
 		TahunAjaran:       bill.PeriodeSPP.TahunAjaran,
+		JenisBiayaID:      bill.JenisBiayaID,
+		KodeBiaya:         bill.JenisBiaya.KodeBiaya,
+		NamaBiaya:         bill.JenisBiaya.NamaBiaya,
+		JumlahAwal:        bill.JumlahAwal,
+		JumlahProrata:     bill.JumlahProrata,
+		KeteranganProrata: bill.KeteranganProrata,
+		JumlahPotongan:    bill.JumlahPotongan,
 		JumlahTagihan:     bill.JumlahTagihan,
+		JumlahDenda:       bill.JumlahDenda,
+		JumlahTerbayar:    bill.JumlahTerbayar,
+		SisaTagihan:       bill.JumlahTagihan + bill.JumlahDenda - bill.JumlahTerbayar,
 		StatusPembayaran:  bill.StatusPembayaran,
 		TanggalJatuhTempo: bill.TanggalJatuhTempo,
+		DibebaskanOleh:    bill.DibebaskanOleh,
+		AlasanPembebasan:  bill.AlasanPembebasan,
+		TanggalDibebaskan: bill.TanggalDibebaskan,
+	}
+	if bill.StatusPembayaran == "dibebaskan" {
+		response.SisaTagihan = 0
+	}
+	for _, installment := range bill.Cicilan {
+		response.Cicilan = append(response.Cicilan, InstallmentResponse{
+			ID:                installment.ID,
+			Urutan:            installment.Urutan,
+			Jumlah:            installment.Jumlah,
+			JumlahTerbayar:    installment.JumlahTerbayar,
+			StatusPembayaran:  installment.StatusPembayaran,
+			TanggalJatuhTempo: installment.TanggalJatuhTempo,
+		})
+	}
+	for _, fee := range bill.Denda {
+		response.Denda = append(response.Denda, FormatLateFeeResponse(&fee))
+	}
+	for _, discount := range bill.Potongan {
+		response.Potongan = append(response.Potongan, BillDiscountResponse{
+			JenisPotonganID: discount.JenisPotonganID,
+			NamaPotongan:    discount.NamaPotongan,
+			Jumlah:          discount.Jumlah,
+		})
+	}
+	return response
+}
+
+func FormatBillGroupResponses(bills []model.TagihanSPP) []BillGroupResponse {
+	groups := []BillGroupResponse{}
+	index := make(map[uint]int)
+	for _, bill := range bills {
+		i, ok := index[bill.JenisBiayaID]
+		if !ok {
+			i = len(groups)
+			index[bill.JenisBiayaID] = i
+			groups = append(groups, BillGroupResponse{
+				JenisBiayaID: bill.JenisBiayaID,
+				KodeBiaya:    bill.JenisBiaya.KodeBiaya,
+				NamaBiaya:    bill.JenisBiaya.NamaBiaya,
+			})
+		}
+		response := FormatBillResponse(&bill)
+		if bill.StatusPembayaran != "dibebaskan" {
+			groups[i].TotalTagihan += bill.JumlahTagihan + bill.JumlahDenda
+		}
+		groups[i].SisaTagihan += response.SisaTagihan
+		groups[i].Tagihan = append(groups[i].Tagihan, response)
+	}
+	return groups
+}
+
+func FormatDiscountTypeResponse(discountType *model.JenisPotongan) DiscountTypeResponse {
+	return DiscountTypeResponse{
+		ID:               discountType.ID,
+		NamaPotongan:     discountType.NamaPotongan,
+		JenisBiayaID:     discountType.JenisBiayaID,
+		TipePotongan:     discountType.TipePotongan,
+		Nilai:            discountType.Nilai,
+		MaksimalPotongan: discountType.MaksimalPotongan,
+		Keterangan:       discountType.Keterangan,
+		Status:           discountType.Status,
+	}
+}
+
+func FormatFeeTypeResponse(feeType *model.JenisBiaya) FeeTypeResponse {
+	response := FeeTypeResponse{
+		ID:           feeType.ID,
+		KodeBiaya:    feeType.KodeBiaya,
+		NamaBiaya:    feeType.NamaBiaya,
+		Periodisitas: feeType.Periodisitas,
+		Wajib:        feeType.Wajib,
+		Keterangan:   feeType.Keterangan,
+		Status:       feeType.Status,
+		Tarif:        []FeeRateResponse{},
+	}
+	for _, rate := range feeType.Tarif {
+		response.Tarif = append(response.Tarif, FeeRateResponse{
+			TingkatID:   rate.TingkatID,
+			NamaTingkat: rate.TingkatKelas.NamaTingkat,
+			Jumlah:      rate.Jumlah,
+		})
+	}
+	return response
+}
+
+func FormatFeeScheduleResponse(schedule *model.JadwalTarif) FeeScheduleResponse {
+	return FeeScheduleResponse{
+		ID:              schedule.ID,
+		JenisBiayaID:    schedule.JenisBiayaID,
+		NamaBiaya:       schedule.JenisBiaya.NamaBiaya,
+		TingkatID:       schedule.TingkatID,
+		NamaTingkat:     schedule.TingkatKelas.NamaTingkat,
+		TahunAjaranID:   schedule.TahunAjaranID,
+		NamaTahunAjaran: schedule.TahunAjaran.NamaTahunAjaran,
+		Jumlah:          schedule.Jumlah,
+		BerlakuMulai:    schedule.BerlakuMulai,
+		Keterangan:      schedule.Keterangan,
+	}
+}
+
+func FormatAcademicYearResponse(academicYear *model.TahunAjaran) AcademicYearResponse {
+	return AcademicYearResponse{
+		ID:              academicYear.ID,
+		NamaTahunAjaran: academicYear.NamaTahunAjaran,
+		TanggalMulai:    academicYear.TanggalMulai,
+		TanggalSelesai:  academicYear.TanggalSelesai,
+		Aktif:           academicYear.Aktif,
+	}
+}
+
+func FormatStudentDiscountResponse(assignment *model.PotonganSiswa) StudentDiscountResponse {
+	response := StudentDiscountResponse{
+		ID:               assignment.ID,
+		SiswaID:          assignment.SiswaID,
+		JenisPotongan:    FormatDiscountTypeResponse(&assignment.JenisPotongan),
+		PeriodeMulaiID:   assignment.PeriodeMulaiID,
+		PeriodeMulai:     fmt.Sprintf("%s %s", assignment.PeriodeMulai.NamaBulan, assignment.PeriodeMulai.TahunAjaran),
+		PeriodeSelesaiID: assignment.PeriodeSelesaiID,
+		Keterangan:       assignment.Keterangan,
+	}
+	if assignment.PeriodeSelesai != nil {
+		period := fmt.Sprintf("%s %s", assignment.PeriodeSelesai.NamaBulan, assignment.PeriodeSelesai.TahunAjaran)
+		response.PeriodeSelesai = &period
+	}
+	return response
+}
+
+func FormatExemptionResponse(exemption *model.PembebasanTagihan) ExemptionResponse {
+	response := ExemptionResponse{
+		ID:           exemption.ID,
+		PeriodeID:    exemption.PeriodeID,
+		NamaPeriode:  exemption.PeriodeSPP.NamaBulan,
+		TahunAjaran:  exemption.PeriodeSPP.TahunAjaran,
+		JenisBiayaID: exemption.JenisBiayaID,
+		TingkatID:    exemption.TingkatID,
+		KelasID:      exemption.KelasID,
+		SiswaID:      exemption.SiswaID,
+		Alasan:       exemption.Alasan,
+		DibuatOleh:   exemption.DibuatOleh,
+		CreatedAt:    exemption.CreatedAt,
+	}
+	if exemption.JenisBiaya != nil {
+		response.NamaBiaya = &exemption.JenisBiaya.NamaBiaya
+	}
+	if exemption.TingkatKelas != nil {
+		response.NamaTingkat = &exemption.TingkatKelas.NamaTingkat
+	}
+	if exemption.Kelas != nil {
+		response.NamaKelas = &exemption.Kelas.NamaKelas
+	}
+	if exemption.Siswa != nil {
+		response.NamaSiswa = &exemption.Siswa.NamaLengkap
+	}
+	return response
+}
+
+func FormatLateFeeResponse(fee *model.DendaTagihan) LateFeeResponse {
+	return LateFeeResponse{
+		ID:                 fee.ID,
+		Urutan:             fee.Urutan,
+		TanggalDenda:       fee.TanggalDenda,
+		Jumlah:             fee.Jumlah,
+		Keterangan:         fee.Keterangan,
+		StatusDenda:        fee.StatusDenda,
+		AlasanPenghapusan:  fee.AlasanPenghapusan,
+		DihapuskanOleh:     fee.DihapuskanOleh,
+		TanggalPenghapusan: fee.TanggalPenghapusan,
+	}
+}
+
+func FormatPaymentResponse(payment *model.Pembayaran) PaymentResponse {
+	return PaymentResponse{
+		ID:                payment.ID,
+		OrderID:           payment.OrderID,
+		TagihanID:         payment.TagihanID,
+		SiswaID:           payment.SiswaID,
+		JumlahBayar:       payment.JumlahBayar,
+		JumlahRefund:      payment.JumlahRefund,
+		BiayaAdmin:        payment.BiayaAdmin,
+		MetodePembayaran:  payment.MetodePembayaran,
+		StatusPembayaran:  payment.StatusPembayaran,
+		TanggalPembayaran: payment.TanggalPembayaran,
+		TanggalSettlement: payment.TanggalSettlement,
+		NomorReferensi:    payment.NomorReferensi,
+		NomorKuitansi:     payment.NomorKuitansi,
+		DiterimaOleh:      payment.DiterimaOleh,
+		Keterangan:        payment.Keterangan,
 	}
 }
 
+func FormatTransferProofResponse(proof *model.BuktiTransfer) TransferProofResponse {
+	return TransferProofResponse{
+		ID:                proof.ID,
+		TagihanID:         proof.TagihanID,
+		SiswaID:           proof.SiswaID,
+		NamaSiswa:         proof.Siswa.NamaLengkap,
+		NamaPeriode:       proof.TagihanSPP.PeriodeSPP.NamaBulan,
+		TahunAjaran:       proof.TagihanSPP.PeriodeSPP.TahunAjaran,
+		OrderID:           proof.Pembayaran.OrderID,
+		JumlahBayar:       proof.Pembayaran.JumlahBayar,
+		TanggalTransfer:   proof.Pembayaran.TanggalPembayaran,
+		NomorReferensi:    proof.Pembayaran.NomorReferensi,
+		NamaFile:          proof.NamaFile,
+		TipeKonten:        proof.TipeKonten,
+		UkuranFile:        proof.UkuranFile,
+		StatusVerifikasi:  proof.StatusVerifikasi,
+		AlasanPenolakan:   proof.AlasanPenolakan,
+		DiverifikasiOleh:  proof.DiverifikasiOleh,
+		TanggalVerifikasi: proof.TanggalVerifikasi,
+		CreatedAt:         proof.CreatedAt,
+	}
+}
+
+func FormatRefundResponse(refund *model.RefundPembayaran) RefundResponse {
+	return RefundResponse{
+		ID:               refund.ID,
+		OrderID:          refund.Pembayaran.OrderID,
+		SiswaID:          refund.Pembayaran.SiswaID,
+		NamaSiswa:        refund.Pembayaran.Siswa.NamaLengkap,
+		JumlahBayar:      refund.Pembayaran.JumlahBayar,
+		Jumlah:           refund.Jumlah,
+		Alasan:           refund.Alasan,
+		MetodeRefund:     refund.MetodeRefund,
+		StatusRefund:     refund.StatusRefund,
+		DiajukanOleh:     refund.DiajukanOleh,
+		DisetujuiOleh:    refund.DisetujuiOleh,
+		TanggalDisetujui: refund.TanggalDisetujui,
+		AlasanPenolakan:  refund.AlasanPenolakan,
+		Keterangan:       refund.Keterangan,
+		TanggalRefund:    refund.TanggalRefund,
+		CreatedAt:        refund.CreatedAt,
+	}
+}
+
+func FormatNotificationResponse(notification *model.NotifikasiMidtrans) NotificationResponse {
+	return NotificationResponse{
+		ID:                notification.ID,
+		OrderID:           notification.OrderID,
+		TransactionID:     notification.TransactionID,
+		TransactionStatus: notification.TransactionStatus,
+		StatusProses:      notification.StatusProses,
+		Keterangan:        notification.Keterangan,
+		JumlahPercobaan:   notification.JumlahPercobaan,
+		RawBody:           notification.RawBody,
+		Headers:           notification.Headers,
+		TanggalDiterima:   notification.TanggalDiterima,
+		TanggalDiproses:   notification.TanggalDiproses,
+	}
+}
+
+func FormatReconciliationResponse(run *model.RekonsiliasiPembayaran) ReconciliationResponse {
+	response := ReconciliationResponse{
+		ID:               run.ID,
+		Pemicu:           run.Pemicu,
+		JumlahDiperiksa:  run.JumlahDiperiksa,
+		JumlahDiperbarui: run.JumlahDiperbarui,
+		JumlahGagal:      run.JumlahGagal,
+		TanggalMulai:     run.TanggalMulai,
+		TanggalSelesai:   run.TanggalSelesai,
+	}
+	if run.Detail != nil {
+		response.Detail = json.RawMessage(*run.Detail)
+	}
+	return response
+}
+
 func SendSuccessResponse(c *gin.Context, code int, message string, data interface{}) {
 	c.JSON(code, gin.H{
 		"status":  "success",
@@ -118,6 +694,11 @@ func SendSuccessResponse(c *gin.Context, code int, message string, data interfac
 	})
 }
 
+func SendFileResponse(c *gin.Context, contentType, filename string, content []byte) {
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, contentType, content)
+}
+
 func SendErrorResponse(c *gin.Context, code int, message string) {
 	c.JSON(code, gin.H{
 		"status":  "error",
@@ -0,0 +1,39 @@
+package utils
+
+import "strings"
+
+var satuan = []string{"", "satu", "dua", "tiga", "empat", "lima", "enam", "tujuh", "delapan", "sembilan", "sepuluh", "sebelas"}
+
+func Terbilang(n int64) string {
+	if n == 0 {
+		return "nol"
+	}
+	if n < 0 {
+		return "minus " + Terbilang(-n)
+	}
+	return strings.Join(strings.Fields(terbilang(n)), " ")
+}
+
+func terbilang(n int64) string {
+	switch {
+	case n < 12:
+		return satuan[n]
+	case n < 20:
+		return terbilang(n-10) + " belas"
+	case n < 100:
+		return terbilang(n/10) + " puluh " + terbilang(n%10)
+	case n < 200:
+		return "seratus " + terbilang(n-100)
+	case n < 1000:
+		return terbilang(n/100) + " ratus " + terbilang(n%100)
+	case n < 2000:
+		return "seribu " + terbilang(n-1000)
+	case n < 1000000:
+		return terbilang(n/1000) + " ribu " + terbilang(n%1000)
+	case n < 1000000000:
+		return terbilang(n/1000000) + " juta " + terbilang(n%1000000)
+	case n < 1000000000000:
+		return terbilang(n/1000000000) + " miliar " + terbilang(n%1000000000)
+	}
+	return terbilang(n/1000000000000) + " triliun " + terbilang(n%1000000000000)
+}
@@ -0,0 +1,34 @@
+package utils
+
+import "testing"
+
+func TestTerbilang(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "nol"},
+		{7, "tujuh"},
+		{10, "sepuluh"},
+		{11, "sebelas"},
+		{12, "dua belas"},
+		{19, "sembilan belas"},
+		{25, "dua puluh lima"},
+		{100, "seratus"},
+		{111, "seratus sebelas"},
+		{250, "dua ratus lima puluh"},
+		{1000, "seribu"},
+		{1001, "seribu satu"},
+		{150000, "seratus lima puluh ribu"},
+		{1000000, "satu juta"},
+		{1500000, "satu juta lima ratus ribu"},
+		{2000000000, "dua miliar"},
+		{9999999999, "sembilan miliar sembilan ratus sembilan puluh sembilan juta sembilan ratus sembilan puluh sembilan ribu sembilan ratus sembilan puluh sembilan"},
+		{-5000, "minus lima ribu"},
+	}
+	for _, tt := range tests {
+		if got := Terbilang(tt.n); got != tt.want {
+			t.Errorf("Terbilang(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/hiuncy/spp-payment-api/internal/handler"
 	"github.com/hiuncy/spp-payment-api/internal/repository"
 	"github.com/hiuncy/spp-payment-api/internal/service"
+	"github.com/hiuncy/spp-payment-api/internal/storage"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/driver/mysql"
@@ -27,6 +29,7 @@ func main() {
 		NamingStrategy: schema.NamingStrategy{
 			SingularTable: true,
 		},
+		TranslateError: true,
 	})
 	if err != nil {
 		log.Fatalf("failed to connect to database: %v", err)
@@ -42,26 +45,59 @@ func main() {
 	billRepo := repository.NewBillRepository(db)
 	reportRepo := repository.NewReportRepository(db)
 	paymentRepo := repository.NewPaymentRepository(db)
+	logRepo := repository.NewLogRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	reconciliationRepo := repository.NewReconciliationRepository(db)
+	transferProofRepo := repository.NewTransferProofRepository(db)
+	refundRepo := repository.NewRefundRepository(db)
+	lateFeeRepo := repository.NewLateFeeRepository(db)
+	discountRepo := repository.NewDiscountRepository(db)
+	familyRepo := repository.NewFamilyRepository(db)
+	feeTypeRepo := repository.NewFeeTypeRepository(db)
+	academicYearRepo := repository.NewAcademicYearRepository(db)
+	exemptionRepo := repository.NewExemptionRepository(db)
+	feeScheduleRepo := repository.NewFeeScheduleRepository(db)
 
 	// Service
 	authService := service.NewAuthService(userRepo, cfg.JWTSecretKey)
 	userService := service.NewUserService(userRepo)
-	classLevelService := service.NewClassLevelService(classLevelRepo)
+	classLevelService := service.NewClassLevelService(classLevelRepo, db)
 	classService := service.NewClassService(classRepo)
 	settingService := service.NewSettingService(settingRepo, db)
 	studentService := service.NewStudentService(studentRepo, userRepo, db)
-	periodService := service.NewPeriodService(periodRepo)
-	billService := service.NewBillService(billRepo)
+	billService := service.NewBillService(billRepo, feeTypeRepo, settingService, db)
+	periodService := service.NewPeriodService(periodRepo, academicYearRepo, billService, settingService, cfg.PeriodInterval)
 	reportService := service.NewReportService(reportRepo)
-	midTransService := service.NewMidtransService(cfg)
-	paymentService := service.NewPaymentService(billRepo, studentRepo, paymentRepo, midTransService, db)
+	discountService := service.NewDiscountService(discountRepo, studentRepo, periodRepo, feeTypeRepo)
+	familyService := service.NewFamilyService(familyRepo, db)
+	feeTypeService := service.NewFeeTypeService(feeTypeRepo, db)
+	academicYearService := service.NewAcademicYearService(academicYearRepo, settingService, db)
+	exemptionService := service.NewExemptionService(exemptionRepo, periodRepo)
+	feeScheduleService := service.NewFeeScheduleService(feeScheduleRepo, feeTypeRepo, academicYearRepo, classLevelRepo, periodRepo, settingService)
+	gateways := []service.PaymentGateway{service.NewMidtransGateway(cfg)}
+	if cfg.FakeGatewayEnabled {
+		gateways = append(gateways, service.NewFakeGateway(cfg.FakeGatewaySecret))
+	}
+	gatewayRegistry := service.NewPaymentGatewayRegistry(settingService, gateways...)
+	paymentService := service.NewPaymentService(billRepo, studentRepo, paymentRepo, settingService, gatewayRegistry, db)
+	logService := service.NewLogService(logRepo)
+	notificationService := service.NewNotificationService(notificationRepo, paymentService, gatewayRegistry)
+	reconciliationService := service.NewReconciliationService(reconciliationRepo, paymentRepo, paymentService, gatewayRegistry, service.ReconciliationOptions{
+		Interval:   cfg.ReconcileInterval,
+		PendingAge: cfg.ReconcilePendingAge,
+		ExpireAge:  cfg.ReconcileExpireAge,
+	})
+	receiptService := service.NewReceiptService(paymentRepo, studentRepo, settingService, db)
+	refundService := service.NewRefundService(refundRepo, paymentRepo, settingService, gatewayRegistry, db)
+	lateFeeService := service.NewLateFeeService(lateFeeRepo, billRepo, settingService, cfg.LateFeeInterval, db)
+	transferProofService := service.NewTransferProofService(transferProofRepo, studentRepo, storage.NewLocalStorage(cfg.UploadDir), cfg.UploadMaxSize, db)
 
 	// Handler
 	authHandler := handler.NewAuthHandler(authService, userService)
-	adminHandler := handler.NewAdminHandler(userService, classLevelService, classService, settingService)
-	treasurerHandler := handler.NewTreasurerHandler(studentService, periodService, billService, reportService)
-	studentHandler := handler.NewStudentHandler(studentService, billService, paymentService)
-	midtransHandler := handler.NewMidtransHandler(paymentService)
+	adminHandler := handler.NewAdminHandler(userService, classLevelService, classService, settingService, refundService, discountService, feeTypeService, feeScheduleService)
+	treasurerHandler := handler.NewTreasurerHandler(studentService, periodService, billService, paymentService, reportService, notificationService, reconciliationService, transferProofService, receiptService, refundService, lateFeeService, discountService, familyService, academicYearService, exemptionService)
+	studentHandler := handler.NewStudentHandler(studentService, billService, paymentService, transferProofService, receiptService)
+	webhookHandler := handler.NewWebhookHandler(notificationService, logService)
 
 	router := gin.Default()
 	config := cors.Config{
@@ -72,9 +108,13 @@ func main() {
 		AllowCredentials: true,
 	}
 	router.Use(cors.New(config))
-	apiRouter := handler.NewRouter(router, authHandler, adminHandler, treasurerHandler, studentHandler, midtransHandler, cfg.JWTSecretKey)
+	apiRouter := handler.NewRouter(router, authHandler, adminHandler, treasurerHandler, studentHandler, webhookHandler, cfg.JWTSecretKey)
 	apiRouter.SetupRoutes()
 
+	go reconciliationService.Start(context.Background())
+	go lateFeeService.Start(context.Background())
+	go periodService.Start(context.Background())
+
 	log.Printf("Server starting on port %s", cfg.ServerPort)
 	if err := router.Run(":" + cfg.ServerPort); err != nil {
 		log.Fatalf("failed to run server: %v", err)
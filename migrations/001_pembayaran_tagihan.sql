@@ -0,0 +1,21 @@
+-- Migrasi untuk database yang dibuat dari spp.sql sebelum tabel pembayaran_tagihan tersedia.
+-- Database baru yang diimpor dari spp.sql terbaru tidak memerlukan migrasi ini.
+
+CREATE TABLE IF NOT EXISTS pembayaran_tagihan (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    pembayaran_id INT NOT NULL,
+    tagihan_id INT NOT NULL,
+    jumlah DECIMAL(12,2) NOT NULL,
+    jumlah_refund DECIMAL(12,2) DEFAULT 0 COMMENT 'Bagian dari jumlah yang sudah direfund',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (pembayaran_id) REFERENCES pembayaran(id) ON DELETE CASCADE,
+    FOREIGN KEY (tagihan_id) REFERENCES tagihan_spp(id) ON DELETE RESTRICT,
+    UNIQUE KEY unique_pembayaran_tagihan (pembayaran_id, tagihan_id),
+    INDEX idx_tagihan (tagihan_id)
+);
+
+-- Isi rincian untuk pembayaran lama yang hanya mencatat satu tagihan di pembayaran.tagihan_id
+INSERT INTO pembayaran_tagihan (pembayaran_id, tagihan_id, jumlah, jumlah_refund)
+SELECT p.id, p.tagihan_id, p.jumlah_bayar, COALESCE(p.jumlah_refund, 0)
+FROM pembayaran p
+WHERE NOT EXISTS (SELECT 1 FROM pembayaran_tagihan pt WHERE pt.pembayaran_id = p.id);
@@ -52,12 +52,24 @@ CREATE TABLE users (
     INDEX idx_role (role_id)
 );
 
+-- Tabel keluarga untuk mengelompokkan siswa bersaudara kandung
+CREATE TABLE keluarga (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    nama_keluarga VARCHAR(100) NOT NULL,
+    nama_orangtua VARCHAR(100),
+    telepon_orangtua VARCHAR(20),
+    keterangan TEXT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
 -- Tabel untuk menyimpan data siswa
 CREATE TABLE siswa (
     id INT PRIMARY KEY AUTO_INCREMENT,
     user_id INT NOT NULL,
     nisn VARCHAR(20) NOT NULL UNIQUE,
     kelas_id INT NOT NULL,
+    keluarga_id INT NULL COMMENT 'Keluarga yang dikonfirmasi bendahara untuk potongan saudara kandung',
     nama_lengkap VARCHAR(100) NOT NULL,
     jenis_kelamin ENUM('L', 'P') NOT NULL,
     tempat_lahir VARCHAR(50),
@@ -66,73 +78,366 @@ CREATE TABLE siswa (
     nama_orangtua VARCHAR(100),
     telepon_orangtua VARCHAR(20),
     tahun_masuk YEAR,
+    tanggal_masuk DATE NULL COMMENT 'Tanggal siswa mulai bersekolah, dasar prorata tagihan pertama',
     status ENUM('aktif', 'pindah', 'lulus', 'keluar') DEFAULT 'aktif',
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
     FOREIGN KEY (kelas_id) REFERENCES kelas(id) ON DELETE RESTRICT,
+    FOREIGN KEY (keluarga_id) REFERENCES keluarga(id) ON DELETE SET NULL,
     INDEX idx_nisn (nisn),
     INDEX idx_kelas (kelas_id),
     INDEX idx_status (status)
 );
 
+-- Tabel tahun ajaran
+CREATE TABLE tahun_ajaran (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    nama_tahun_ajaran VARCHAR(20) NOT NULL UNIQUE COMMENT 'Format: 2024/2025',
+    tanggal_mulai DATE NOT NULL,
+    tanggal_selesai DATE NOT NULL,
+    aktif BOOLEAN NOT NULL DEFAULT FALSE COMMENT 'Hanya satu tahun ajaran yang aktif',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_aktif (aktif)
+);
+
 -- Tabel untuk mengatur periode pembayaran SPP
 CREATE TABLE periode_spp (
     id INT PRIMARY KEY AUTO_INCREMENT,
-    tahun_ajaran VARCHAR(20) NOT NULL COMMENT 'Format: 2024/2025',
+    tahun_ajaran_id INT NOT NULL,
+    tahun_ajaran VARCHAR(20) NOT NULL COMMENT 'Salinan nama tahun ajaran, format: 2024/2025',
     bulan INT NOT NULL COMMENT 'Bulan SPP (1-12)',
     nama_bulan VARCHAR(20) NOT NULL COMMENT 'Nama bulan (Januari, Februari, dst)',
-    tanggal_mulai DATE NOT NULL COMMENT 'Tanggal mulai pembayaran',
-    tanggal_selesai DATE NOT NULL COMMENT 'Batas akhir pembayaran',
+    tanggal_mulai DATE NOT NULL COMMENT 'Tanggal mulai periode',
+    tanggal_selesai DATE NOT NULL COMMENT 'Tanggal akhir periode',
+    tanggal_jatuh_tempo DATE NOT NULL COMMENT 'Batas akhir pembayaran tagihan periode',
     status ENUM('belum_aktif', 'aktif', 'selesai') DEFAULT 'belum_aktif',
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (tahun_ajaran_id) REFERENCES tahun_ajaran(id) ON DELETE RESTRICT,
     UNIQUE KEY unique_periode (tahun_ajaran, bulan),
     INDEX idx_status (status),
     INDEX idx_tanggal (tanggal_mulai, tanggal_selesai)
 );
 
--- Tabel untuk menyimpan tagihan SPP per siswa per periode
+-- Tabel katalog jenis biaya (SPP, uang gedung, seragam, kegiatan, dll)
+CREATE TABLE jenis_biaya (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    kode_biaya VARCHAR(30) NOT NULL UNIQUE,
+    nama_biaya VARCHAR(100) NOT NULL,
+    periodisitas ENUM('bulanan', 'semester', 'sekali') DEFAULT 'bulanan' COMMENT 'Frekuensi penagihan',
+    wajib BOOLEAN NOT NULL DEFAULT TRUE COMMENT 'Biaya opsional tidak dikenakan denda keterlambatan',
+    keterangan TEXT NULL,
+    status ENUM('aktif', 'nonaktif') DEFAULT 'aktif',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+-- Tabel tarif jenis biaya per tingkat kelas
+CREATE TABLE tarif_biaya (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    jenis_biaya_id INT NOT NULL,
+    tingkat_id INT NOT NULL,
+    jumlah DECIMAL(12,2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (jenis_biaya_id) REFERENCES jenis_biaya(id) ON DELETE CASCADE,
+    FOREIGN KEY (tingkat_id) REFERENCES tingkat_kelas(id) ON DELETE CASCADE,
+    UNIQUE KEY unique_tarif (jenis_biaya_id, tingkat_id)
+);
+
+-- Tabel jadwal tarif per tahun ajaran (riwayat tarif, berlaku sejak tanggal tertentu)
+CREATE TABLE jadwal_tarif (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    jenis_biaya_id INT NOT NULL,
+    tingkat_id INT NOT NULL,
+    tahun_ajaran_id INT NOT NULL,
+    jumlah DECIMAL(12,2) NOT NULL,
+    berlaku_mulai DATE NOT NULL COMMENT 'Berlaku untuk periode yang dimulai pada atau setelah tanggal ini',
+    keterangan VARCHAR(255) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (jenis_biaya_id) REFERENCES jenis_biaya(id) ON DELETE CASCADE,
+    FOREIGN KEY (tingkat_id) REFERENCES tingkat_kelas(id) ON DELETE CASCADE,
+    FOREIGN KEY (tahun_ajaran_id) REFERENCES tahun_ajaran(id) ON DELETE CASCADE,
+    UNIQUE KEY unique_jadwal_tarif (jenis_biaya_id, tingkat_id, tahun_ajaran_id, berlaku_mulai)
+);
+
+-- Tabel untuk menyimpan tagihan per siswa per periode per jenis biaya
 CREATE TABLE tagihan_spp (
     id INT PRIMARY KEY AUTO_INCREMENT,
     siswa_id INT NOT NULL,
     periode_id INT NOT NULL,
-    jumlah_tagihan DECIMAL(12,2) NOT NULL,
-    status_pembayaran ENUM('belum_bayar', 'pending', 'lunas') DEFAULT 'belum_bayar',
+    jenis_biaya_id INT NOT NULL DEFAULT 1,
+    jumlah_awal DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT 'Biaya sebelum potongan',
+    jumlah_prorata DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT 'Pengurangan biaya karena siswa masuk di tengah periode',
+    keterangan_prorata VARCHAR(255) NULL,
+    jumlah_potongan DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT 'Total potongan/beasiswa yang diterapkan',
+    jumlah_tagihan DECIMAL(12,2) NOT NULL COMMENT 'Jumlah yang harus dibayar setelah potongan',
+    jumlah_terbayar DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT 'Akumulasi pembayaran yang sudah settlement',
+    jumlah_denda DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT 'Total denda keterlambatan yang masih aktif',
+    status_pembayaran ENUM('belum_bayar', 'pending', 'sebagian', 'lunas', 'dibebaskan') DEFAULT 'belum_bayar',
     tanggal_jatuh_tempo DATE NOT NULL,
+    dibebaskan_oleh INT NULL,
+    alasan_pembebasan VARCHAR(255) NULL,
+    tanggal_dibebaskan TIMESTAMP NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     FOREIGN KEY (siswa_id) REFERENCES siswa(id) ON DELETE CASCADE,
     FOREIGN KEY (periode_id) REFERENCES periode_spp(id) ON DELETE CASCADE,
-    UNIQUE KEY unique_tagihan (siswa_id, periode_id),
+    FOREIGN KEY (jenis_biaya_id) REFERENCES jenis_biaya(id) ON DELETE RESTRICT,
+    FOREIGN KEY (dibebaskan_oleh) REFERENCES users(id) ON DELETE SET NULL,
+    UNIQUE KEY unique_tagihan (siswa_id, periode_id, jenis_biaya_id),
     INDEX idx_status (status_pembayaran),
     INDEX idx_jatuh_tempo (tanggal_jatuh_tempo)
 );
 
+-- Tabel jenis potongan (beasiswa, anak guru/karyawan, saudara kandung, dll)
+CREATE TABLE jenis_potongan (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    nama_potongan VARCHAR(100) NOT NULL UNIQUE,
+    jenis_biaya_id INT NOT NULL DEFAULT 1 COMMENT 'Jenis biaya yang mendapat potongan',
+    tipe_potongan ENUM('persen', 'nominal') NOT NULL,
+    nilai DECIMAL(12,2) NOT NULL COMMENT 'Persentase (0-100) atau nominal rupiah',
+    maksimal_potongan DECIMAL(12,2) NULL COMMENT 'Batas potongan per tagihan (NULL/0 = tanpa batas)',
+    keterangan TEXT NULL,
+    status ENUM('aktif', 'nonaktif') DEFAULT 'aktif',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (jenis_biaya_id) REFERENCES jenis_biaya(id)
+);
+
+-- Tabel penetapan potongan ke siswa untuk rentang periode tertentu
+CREATE TABLE potongan_siswa (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    siswa_id INT NOT NULL,
+    jenis_potongan_id INT NOT NULL,
+    periode_mulai_id INT NOT NULL,
+    periode_selesai_id INT NULL COMMENT 'NULL = berlaku seterusnya',
+    keterangan TEXT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (siswa_id) REFERENCES siswa(id) ON DELETE CASCADE,
+    FOREIGN KEY (jenis_potongan_id) REFERENCES jenis_potongan(id),
+    FOREIGN KEY (periode_mulai_id) REFERENCES periode_spp(id),
+    FOREIGN KEY (periode_selesai_id) REFERENCES periode_spp(id),
+    INDEX idx_siswa (siswa_id)
+);
+
+-- Rincian potongan yang diterapkan pada setiap tagihan
+CREATE TABLE potongan_tagihan (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    tagihan_id INT NOT NULL,
+    jenis_potongan_id INT NOT NULL,
+    potongan_siswa_id INT NULL,
+    nama_potongan VARCHAR(100) NOT NULL,
+    jumlah DECIMAL(12,2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (tagihan_id) REFERENCES tagihan_spp(id) ON DELETE CASCADE,
+    FOREIGN KEY (jenis_potongan_id) REFERENCES jenis_potongan(id),
+    FOREIGN KEY (potongan_siswa_id) REFERENCES potongan_siswa(id) ON DELETE SET NULL,
+    INDEX idx_tagihan (tagihan_id)
+);
+
+-- Tabel jadwal cicilan untuk tagihan yang dibayar bertahap
+CREATE TABLE cicilan_tagihan (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    tagihan_id INT NOT NULL,
+    urutan INT NOT NULL,
+    jumlah DECIMAL(12,2) NOT NULL,
+    jumlah_terbayar DECIMAL(12,2) NOT NULL DEFAULT 0,
+    status_pembayaran ENUM('belum_bayar', 'sebagian', 'lunas') DEFAULT 'belum_bayar',
+    tanggal_jatuh_tempo DATE NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (tagihan_id) REFERENCES tagihan_spp(id) ON DELETE CASCADE,
+    UNIQUE KEY unique_cicilan (tagihan_id, urutan),
+    INDEX idx_jatuh_tempo (tanggal_jatuh_tempo)
+);
+
+-- Tabel denda keterlambatan per tagihan
+CREATE TABLE denda_tagihan (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    tagihan_id INT NOT NULL,
+    urutan INT NOT NULL,
+    tanggal_denda DATE NOT NULL,
+    jumlah DECIMAL(12,2) NOT NULL,
+    keterangan VARCHAR(255) NOT NULL,
+    status_denda ENUM('aktif', 'dihapuskan') DEFAULT 'aktif',
+    alasan_penghapusan TEXT NULL,
+    dihapuskan_oleh INT NULL,
+    tanggal_penghapusan TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (tagihan_id) REFERENCES tagihan_spp(id) ON DELETE CASCADE,
+    FOREIGN KEY (dihapuskan_oleh) REFERENCES users(id) ON DELETE SET NULL,
+    UNIQUE KEY unique_denda (tagihan_id, urutan),
+    INDEX idx_status_denda (status_denda)
+);
+
+-- Tabel aturan pembebasan tagihan per periode (libur, kelulusan, kebijakan khusus)
+CREATE TABLE pembebasan_tagihan (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    periode_id INT NOT NULL,
+    jenis_biaya_id INT NULL COMMENT 'NULL = semua jenis biaya',
+    tingkat_id INT NULL,
+    kelas_id INT NULL,
+    siswa_id INT NULL COMMENT 'Tingkat, kelas, dan siswa NULL = seluruh sekolah',
+    alasan VARCHAR(255) NOT NULL,
+    dibuat_oleh INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (periode_id) REFERENCES periode_spp(id) ON DELETE CASCADE,
+    FOREIGN KEY (jenis_biaya_id) REFERENCES jenis_biaya(id) ON DELETE CASCADE,
+    FOREIGN KEY (tingkat_id) REFERENCES tingkat_kelas(id) ON DELETE CASCADE,
+    FOREIGN KEY (kelas_id) REFERENCES kelas(id) ON DELETE CASCADE,
+    FOREIGN KEY (siswa_id) REFERENCES siswa(id) ON DELETE CASCADE,
+    FOREIGN KEY (dibuat_oleh) REFERENCES users(id),
+    INDEX idx_periode (periode_id)
+);
+
 -- Tabel untuk menyimpan data pembayaran dan integrasi dengan Midtrans
 CREATE TABLE pembayaran (
     id INT PRIMARY KEY AUTO_INCREMENT,
     tagihan_id INT NOT NULL,
     siswa_id INT NOT NULL,
-    order_id VARCHAR(100) NOT NULL UNIQUE COMMENT 'Order ID untuk Midtrans',
+    order_id VARCHAR(100) NOT NULL UNIQUE COMMENT 'Order ID untuk payment gateway',
+    gateway VARCHAR(30) NULL COMMENT 'Payment gateway yang memproses (midtrans, fake, ...); NULL untuk pembayaran manual',
     transaction_id VARCHAR(100) NULL COMMENT 'Transaction ID dari Midtrans',
     jumlah_bayar DECIMAL(12,2) NOT NULL,
-    metode_pembayaran VARCHAR(50) NULL COMMENT 'bank_transfer, e_wallet, credit_card, dll',
-    status_pembayaran ENUM('pending', 'settlement', 'cancel', 'expire', 'failure') DEFAULT 'pending',
+    jumlah_refund DECIMAL(12,2) DEFAULT 0 COMMENT 'Total nominal yang sudah direfund',
+    biaya_admin DECIMAL(12,2) DEFAULT 0 COMMENT 'Biaya admin channel pembayaran, di luar jumlah_bayar',
+    kanal_pembayaran VARCHAR(30) NULL COMMENT 'Channel yang dipilih siswa saat checkout (bca_va, qris, ...)',
+    metode_pembayaran VARCHAR(50) NULL COMMENT 'bank_transfer, e_wallet, credit_card, tunai, transfer_manual, dll',
+    status_pembayaran ENUM('pending', 'capture', 'settlement', 'deny', 'cancel', 'expire', 'failure', 'refund', 'partial_refund') DEFAULT 'pending',
     tanggal_pembayaran TIMESTAMP NULL,
     tanggal_settlement TIMESTAMP NULL,
+    snap_token VARCHAR(255) NULL COMMENT 'Snap token yang dapat dipakai ulang selama belum kedaluwarsa',
+    bank_va VARCHAR(20) NULL COMMENT 'Bank virtual account (bca, bni, bri, permata) untuk mode virtual_account',
+    nomor_va VARCHAR(50) NULL COMMENT 'Nomor virtual account dari Core API',
+    tanggal_kedaluwarsa TIMESTAMP NULL COMMENT 'Batas berlaku snap token atau virtual account',
     midtrans_response JSON NULL COMMENT 'Response lengkap dari Midtrans',
+    nomor_referensi VARCHAR(100) NULL COMMENT 'Nomor referensi transfer atau kuitansi untuk pembayaran manual',
+    nomor_kuitansi VARCHAR(30) NULL UNIQUE COMMENT 'Nomor kuitansi berurutan per tahun, diberikan saat pembayaran lunas',
+    diterima_oleh INT NULL COMMENT 'User bendahara yang mencatat pembayaran manual',
     keterangan TEXT NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-    FOREIGN KEY (tagihan_id) REFERENCES tagihan_spp(id) ON DELETE CASCADE,
+    FOREIGN KEY (tagihan_id) REFERENCES tagihan_spp(id) ON DELETE RESTRICT,
     FOREIGN KEY (siswa_id) REFERENCES siswa(id) ON DELETE CASCADE,
+    FOREIGN KEY (diterima_oleh) REFERENCES users(id) ON DELETE SET NULL,
     INDEX idx_order_id (order_id),
     INDEX idx_transaction_id (transaction_id),
     INDEX idx_status (status_pembayaran),
     INDEX idx_tanggal_bayar (tanggal_pembayaran)
 );
 
+-- Tabel rincian tagihan yang dibayar dalam satu transaksi pembayaran
+CREATE TABLE pembayaran_tagihan (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    pembayaran_id INT NOT NULL,
+    tagihan_id INT NOT NULL,
+    jumlah DECIMAL(12,2) NOT NULL,
+    jumlah_refund DECIMAL(12,2) DEFAULT 0 COMMENT 'Bagian dari jumlah yang sudah direfund',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (pembayaran_id) REFERENCES pembayaran(id) ON DELETE CASCADE,
+    FOREIGN KEY (tagihan_id) REFERENCES tagihan_spp(id) ON DELETE RESTRICT,
+    UNIQUE KEY unique_pembayaran_tagihan (pembayaran_id, tagihan_id),
+    INDEX idx_tagihan (tagihan_id)
+);
+
+-- Tabel refund atas pembayaran yang sudah lunas
+CREATE TABLE refund_pembayaran (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    pembayaran_id INT NOT NULL,
+    refund_key VARCHAR(100) NOT NULL UNIQUE COMMENT 'Refund key untuk Midtrans agar permintaan idempoten',
+    jumlah DECIMAL(12,2) NOT NULL,
+    alasan TEXT NOT NULL,
+    metode_refund VARCHAR(50) NOT NULL COMMENT 'Nama payment gateway atau tunai',
+    status_refund ENUM('menunggu_persetujuan', 'diproses', 'berhasil', 'ditolak', 'gagal') DEFAULT 'diproses',
+    diajukan_oleh INT NOT NULL,
+    disetujui_oleh INT NULL COMMENT 'Admin yang menyetujui atau menolak refund di atas batas',
+    tanggal_disetujui TIMESTAMP NULL,
+    alasan_penolakan TEXT NULL,
+    keterangan TEXT NULL COMMENT 'Pesan kesalahan bila refund gagal diproses',
+    midtrans_response JSON NULL,
+    tanggal_refund TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (pembayaran_id) REFERENCES pembayaran(id) ON DELETE CASCADE,
+    FOREIGN KEY (diajukan_oleh) REFERENCES users(id),
+    FOREIGN KEY (disetujui_oleh) REFERENCES users(id) ON DELETE SET NULL,
+    INDEX idx_status_refund (status_refund)
+);
+
+-- Tabel penghitung nomor kuitansi per tahun agar nomor berurutan tanpa celah
+CREATE TABLE urutan_kuitansi (
+    tahun INT PRIMARY KEY,
+    nomor_terakhir INT NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+-- Tabel bukti transfer yang diunggah siswa dan menunggu verifikasi bendahara
+CREATE TABLE bukti_transfer (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    pembayaran_id INT NOT NULL,
+    tagihan_id INT NOT NULL,
+    siswa_id INT NOT NULL,
+    nama_file VARCHAR(255) NOT NULL COMMENT 'Nama file asli dari pengunggah',
+    lokasi_file VARCHAR(255) NOT NULL COMMENT 'Kunci file pada storage',
+    tipe_konten VARCHAR(100) NOT NULL,
+    ukuran_file BIGINT NOT NULL,
+    status_verifikasi ENUM('menunggu', 'disetujui', 'ditolak') DEFAULT 'menunggu',
+    alasan_penolakan TEXT NULL,
+    diverifikasi_oleh INT NULL,
+    tanggal_verifikasi TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (pembayaran_id) REFERENCES pembayaran(id) ON DELETE CASCADE,
+    FOREIGN KEY (tagihan_id) REFERENCES tagihan_spp(id) ON DELETE RESTRICT,
+    FOREIGN KEY (siswa_id) REFERENCES siswa(id) ON DELETE CASCADE,
+    FOREIGN KEY (diverifikasi_oleh) REFERENCES users(id) ON DELETE SET NULL,
+    INDEX idx_status_verifikasi (status_verifikasi)
+);
+
+-- Tabel inbox untuk menyimpan setiap notifikasi Midtrans yang diterima
+CREATE TABLE notifikasi_midtrans (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    gateway VARCHAR(30) NOT NULL DEFAULT 'midtrans' COMMENT 'Payment gateway pengirim notifikasi',
+    order_id VARCHAR(100) NOT NULL,
+    transaction_id VARCHAR(100) NULL,
+    transaction_status VARCHAR(50) NULL,
+    kunci_deduplikasi VARCHAR(255) NULL COMMENT 'order_id|transaction_id|transaction_status, diisi setelah signature valid',
+    raw_body LONGTEXT NOT NULL COMMENT 'Body mentah notifikasi',
+    headers JSON NULL COMMENT 'Header HTTP notifikasi',
+    status_proses ENUM('diterima', 'berhasil', 'gagal', 'ditolak', 'duplikat') DEFAULT 'diterima',
+    keterangan TEXT NULL COMMENT 'Pesan kesalahan hasil pemrosesan',
+    jumlah_percobaan INT DEFAULT 0,
+    tanggal_diterima TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    tanggal_diproses TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY unique_kunci_deduplikasi (kunci_deduplikasi),
+    INDEX idx_order_id (order_id),
+    INDEX idx_status_proses (status_proses)
+);
+
+-- Tabel ringkasan setiap eksekusi rekonsiliasi pembayaran pending
+CREATE TABLE rekonsiliasi_pembayaran (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    pemicu ENUM('terjadwal', 'manual') DEFAULT 'terjadwal',
+    jumlah_diperiksa INT DEFAULT 0,
+    jumlah_diperbarui INT DEFAULT 0,
+    jumlah_gagal INT DEFAULT 0,
+    detail JSON NULL COMMENT 'Hasil rekonsiliasi per order',
+    tanggal_mulai TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    tanggal_selesai TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_tanggal_mulai (tanggal_mulai)
+);
+
 -- Tabel untuk log aktivitas sistem
 CREATE TABLE log_aktivitas (
     id INT PRIMARY KEY AUTO_INCREMENT,
@@ -176,6 +481,10 @@ INSERT INTO tingkat_kelas (tingkat, nama_tingkat, biaya_spp) VALUES
 (5, 'Kelas 5', 170000.00),
 (6, 'Kelas 6', 175000.00);
 
+-- Insert jenis biaya SPP (tarif mengikuti jadwal_tarif tahun ajaran, lalu tarif_biaya, lalu biaya_spp di tingkat_kelas)
+INSERT INTO jenis_biaya (id, kode_biaya, nama_biaya, periodisitas, wajib) VALUES
+(1, 'SPP', 'SPP', 'bulanan', TRUE);
+
 -- Insert kelas (2 kelas per tingkat: A dan B)
 INSERT INTO kelas (tingkat_id, nama_kelas, wali_kelas) VALUES
 (1, '1A', 'Bu Sari'),
@@ -203,10 +512,32 @@ INSERT INTO pengaturan (key_setting, value_setting, deskripsi) VALUES
 ('alamat_sekolah', 'Jl. Pendidikan No. 1, Kota', 'Alamat sekolah'),
 ('telepon_sekolah', '021-1234567', 'Nomor telepon sekolah'),
 ('email_sekolah', 'info@sekolah.sch.id', 'Email resmi sekolah'),
-('tahun_ajaran_aktif', '2024/2025', 'Tahun ajaran yang sedang aktif'),
 ('midtrans_server_key', '', 'Server Key Midtrans'),
 ('midtrans_client_key', '', 'Client Key Midtrans'),
-('midtrans_environment', 'sandbox', 'Environment Midtrans (sandbox/production)');
+('midtrans_environment', 'sandbox', 'Environment Midtrans (sandbox/production)'),
+('payment_gateway', 'midtrans', 'Payment gateway untuk pembayaran online baru (midtrans/fake)'),
+('batas_refund_tanpa_persetujuan', '500000', 'Nominal refund maksimal tanpa persetujuan admin (0 = tanpa batas)'),
+('metode_pembayaran_aktif', '', 'Daftar channel Snap yang ditampilkan, dipisah koma (kosong = semua channel)'),
+('batas_waktu_pembayaran_menit', '1440', 'Masa berlaku transaksi pembayaran online dalam menit'),
+('biaya_admin_pembayaran', '{}', 'Biaya admin per channel dalam format JSON, mis. {"bca_va": 4000, "default": 0}'),
+('mode_pembayaran_online', 'snap', 'Mode pembayaran online: snap (halaman pembayaran) atau virtual_account (nomor VA langsung)'),
+('va_nomor_tetap', 'false', 'Gunakan NISN sebagai nomor virtual account tetap per siswa (true/false)'),
+('denda_aktif', 'false', 'Aktifkan denda keterlambatan otomatis (true/false)'),
+('denda_jenis', 'nominal', 'Jenis denda: nominal (rupiah tetap) atau persen (dari jumlah tagihan)'),
+('denda_nilai', '0', 'Nilai denda per pengenaan, dalam rupiah atau persen sesuai denda_jenis'),
+('denda_masa_tenggang_hari', '0', 'Jumlah hari setelah jatuh tempo sebelum denda pertama dikenakan'),
+('denda_interval_hari', '0', 'Selang hari antar pengenaan denda berulang (0 = hanya sekali)'),
+('denda_maksimal', '0', 'Total denda aktif maksimal per tagihan (0 = tanpa batas)'),
+('potongan_saudara_aktif', 'false', 'Terapkan potongan saudara kandung otomatis saat generate tagihan (true/false)'),
+('potongan_saudara', '{}', 'Jenis potongan per urutan anak dalam format JSON, mis. {"2": 3, "3": 4} (urutan lebih tinggi memakai aturan terdekat)'),
+('periode_bulan', '', 'Daftar bulan (1-12) yang dibuatkan periode saat generate periode tahun ajaran, dipisah koma (kosong = semua bulan)'),
+('periode_tanggal_jatuh_tempo', '10', 'Tanggal jatuh tempo periode hasil generate (0 = akhir bulan)'),
+('periode_generate_tagihan_otomatis', 'false', 'Generate tagihan SPP otomatis saat periode diaktifkan oleh penjadwal (true/false)'),
+('prorata_tagihan', 'tidak', 'Prorata tagihan bulanan pertama siswa yang masuk di tengah periode: tidak, harian, atau setengah_bulan');
+
+-- Insert tahun ajaran awal
+INSERT INTO tahun_ajaran (nama_tahun_ajaran, tanggal_mulai, tanggal_selesai, aktif) VALUES
+('2024/2025', '2024-07-01', '2025-06-30', TRUE);
 
 -- ============================
 -- VIEW UNTUK LAPORAN
@@ -221,8 +552,14 @@ SELECT
     tk.nama_tingkat,
     ps.tahun_ajaran,
     ps.nama_bulan,
+    jb.kode_biaya,
+    jb.nama_biaya,
     ts.jumlah_tagihan,
+    ts.jumlah_denda,
+    ts.jumlah_terbayar,
+    CASE WHEN ts.status_pembayaran = 'dibebaskan' THEN 0 ELSE ts.jumlah_tagihan + ts.jumlah_denda - ts.jumlah_terbayar END as sisa_tagihan,
     ts.status_pembayaran,
+    ts.alasan_pembebasan,
     ts.tanggal_jatuh_tempo,
     p.tanggal_settlement,
     p.metode_pembayaran
@@ -231,7 +568,14 @@ JOIN kelas k ON s.kelas_id = k.id
 JOIN tingkat_kelas tk ON k.tingkat_id = tk.id
 JOIN tagihan_spp ts ON s.id = ts.siswa_id
 JOIN periode_spp ps ON ts.periode_id = ps.id
-LEFT JOIN pembayaran p ON ts.id = p.tagihan_id AND p.status_pembayaran = 'settlement'
+JOIN jenis_biaya jb ON ts.jenis_biaya_id = jb.id
+LEFT JOIN (
+    SELECT pt.tagihan_id, MAX(p.tanggal_settlement) AS tanggal_settlement, MAX(p.metode_pembayaran) AS metode_pembayaran
+    FROM pembayaran_tagihan pt
+    JOIN pembayaran p ON pt.pembayaran_id = p.id
+    WHERE p.status_pembayaran IN ('capture', 'settlement', 'partial_refund')
+    GROUP BY pt.tagihan_id
+) p ON ts.id = p.tagihan_id
 WHERE s.status = 'aktif';
 
 -- View untuk laporan pembayaran per kelas
@@ -241,130 +585,77 @@ SELECT
     tk.nama_tingkat,
     ps.tahun_ajaran,
     ps.nama_bulan,
+    jb.kode_biaya,
+    jb.nama_biaya,
     COUNT(ts.id) as total_siswa,
     SUM(CASE WHEN ts.status_pembayaran = 'lunas' THEN 1 ELSE 0 END) as siswa_lunas,
     SUM(CASE WHEN ts.status_pembayaran = 'belum_bayar' THEN 1 ELSE 0 END) as siswa_belum_bayar,
     SUM(CASE WHEN ts.status_pembayaran = 'pending' THEN 1 ELSE 0 END) as siswa_pending,
-    SUM(ts.jumlah_tagihan) as total_tagihan,
-    SUM(CASE WHEN ts.status_pembayaran = 'lunas' THEN ts.jumlah_tagihan ELSE 0 END) as total_terbayar
+    SUM(CASE WHEN ts.status_pembayaran = 'sebagian' THEN 1 ELSE 0 END) as siswa_sebagian,
+    SUM(CASE WHEN ts.status_pembayaran = 'dibebaskan' THEN 1 ELSE 0 END) as siswa_dibebaskan,
+    SUM(CASE WHEN ts.status_pembayaran = 'dibebaskan' THEN 0 ELSE ts.jumlah_tagihan END) as total_tagihan,
+    SUM(CASE WHEN ts.status_pembayaran = 'dibebaskan' THEN 0 ELSE ts.jumlah_denda END) as total_denda,
+    SUM(ts.jumlah_terbayar) as total_terbayar,
+    SUM(CASE WHEN ts.status_pembayaran = 'dibebaskan' THEN 0 ELSE ts.jumlah_tagihan + ts.jumlah_denda - ts.jumlah_terbayar END) as total_sisa,
+    SUM(CASE WHEN ts.status_pembayaran = 'dibebaskan' THEN ts.jumlah_tagihan + ts.jumlah_denda ELSE 0 END) as total_dibebaskan
 FROM kelas k
 JOIN tingkat_kelas tk ON k.tingkat_id = tk.id
 JOIN siswa s ON k.id = s.kelas_id AND s.status = 'aktif'
 JOIN tagihan_spp ts ON s.id = ts.siswa_id
 JOIN periode_spp ps ON ts.periode_id = ps.id
-GROUP BY k.id, ps.id;
+JOIN jenis_biaya jb ON ts.jenis_biaya_id = jb.id
+GROUP BY k.id, ps.id, jb.id;
 
 -- View untuk laporan keseluruhan
 CREATE VIEW v_laporan_keseluruhan AS
 SELECT
     ps.tahun_ajaran,
     ps.nama_bulan,
+    jb.kode_biaya,
+    jb.nama_biaya,
     COUNT(ts.id) as total_tagihan,
     SUM(CASE WHEN ts.status_pembayaran = 'lunas' THEN 1 ELSE 0 END) as total_lunas,
     SUM(CASE WHEN ts.status_pembayaran = 'belum_bayar' THEN 1 ELSE 0 END) as total_belum_bayar,
     SUM(CASE WHEN ts.status_pembayaran = 'pending' THEN 1 ELSE 0 END) as total_pending,
-    SUM(ts.jumlah_tagihan) as total_nominal_tagihan,
-    SUM(CASE WHEN ts.status_pembayaran = 'lunas' THEN ts.jumlah_tagihan ELSE 0 END) as total_nominal_terbayar,
-    ROUND((SUM(CASE WHEN ts.status_pembayaran = 'lunas' THEN ts.jumlah_tagihan ELSE 0 END) / SUM(ts.jumlah_tagihan)) * 100, 2) as persentase_pembayaran
+    SUM(CASE WHEN ts.status_pembayaran = 'sebagian' THEN 1 ELSE 0 END) as total_sebagian,
+    SUM(CASE WHEN ts.status_pembayaran = 'dibebaskan' THEN 1 ELSE 0 END) as total_dibebaskan,
+    SUM(CASE WHEN ts.status_pembayaran = 'dibebaskan' THEN 0 ELSE ts.jumlah_tagihan END) as total_nominal_tagihan,
+    SUM(CASE WHEN ts.status_pembayaran = 'dibebaskan' THEN 0 ELSE ts.jumlah_denda END) as total_nominal_denda,
+    SUM(ts.jumlah_terbayar) as total_nominal_terbayar,
+    SUM(CASE WHEN ts.status_pembayaran = 'dibebaskan' THEN 0 ELSE ts.jumlah_tagihan + ts.jumlah_denda - ts.jumlah_terbayar END) as total_nominal_sisa,
+    SUM(CASE WHEN ts.status_pembayaran = 'dibebaskan' THEN ts.jumlah_tagihan + ts.jumlah_denda ELSE 0 END) as total_nominal_dibebaskan,
+    IFNULL(ROUND((SUM(ts.jumlah_terbayar) / SUM(CASE WHEN ts.status_pembayaran = 'dibebaskan' THEN 0 ELSE ts.jumlah_tagihan + ts.jumlah_denda END)) * 100, 2), 100) as persentase_pembayaran
 FROM periode_spp ps
 JOIN tagihan_spp ts ON ps.id = ts.periode_id
 JOIN siswa s ON ts.siswa_id = s.id AND s.status = 'aktif'
-GROUP BY ps.id;
+JOIN jenis_biaya jb ON ts.jenis_biaya_id = jb.id
+GROUP BY ps.id, jb.id;
+
+-- View untuk laporan potongan per periode
+CREATE VIEW v_laporan_potongan AS
+SELECT
+    ps.tahun_ajaran,
+    ps.nama_bulan,
+    jb.kode_biaya,
+    jb.nama_biaya,
+    pt.nama_potongan,
+    COUNT(DISTINCT ts.siswa_id) as jumlah_siswa,
+    SUM(pt.jumlah) as total_potongan
+FROM potongan_tagihan pt
+JOIN tagihan_spp ts ON pt.tagihan_id = ts.id
+JOIN periode_spp ps ON ts.periode_id = ps.id
+JOIN jenis_biaya jb ON ts.jenis_biaya_id = jb.id
+GROUP BY ps.id, jb.id, pt.jenis_potongan_id, pt.nama_potongan;
 
 -- ============================
 -- STORED PROCEDURES
 -- ============================
 
-DELIMITER //
-
--- Procedure untuk generate tagihan SPP otomatis berdasarkan periode
-CREATE PROCEDURE GenerateTagihanSPP(
-    IN p_periode_id INT
-)
-BEGIN
-    DECLARE done INT DEFAULT FALSE;
-    DECLARE v_siswa_id INT;
-    DECLARE v_biaya_spp DECIMAL(12,2);
-    DECLARE v_tanggal_jatuh_tempo DATE;
-
-    -- Cursor untuk mengambil semua siswa aktif dengan biaya SPP
-    DECLARE siswa_cursor CURSOR FOR
-        SELECT s.id, tk.biaya_spp
-        FROM siswa s
-        JOIN kelas k ON s.kelas_id = k.id
-        JOIN tingkat_kelas tk ON k.tingkat_id = tk.id
-        WHERE s.status = 'aktif';
-
-    DECLARE CONTINUE HANDLER FOR NOT FOUND SET done = TRUE;
-
-    -- Ambil tanggal jatuh tempo dari periode
-    SELECT tanggal_selesai INTO v_tanggal_jatuh_tempo
-    FROM periode_spp
-    WHERE id = p_periode_id;
-
-    OPEN siswa_cursor;
-
-    read_loop: LOOP
-        FETCH siswa_cursor INTO v_siswa_id, v_biaya_spp;
-        IF done THEN
-            LEAVE read_loop;
-        END IF;
-
-        -- Insert tagihan jika belum ada
-        INSERT IGNORE INTO tagihan_spp (siswa_id, periode_id, jumlah_tagihan, tanggal_jatuh_tempo)
-        VALUES (v_siswa_id, p_periode_id, v_biaya_spp, v_tanggal_jatuh_tempo);
-
-    END LOOP;
-
-    CLOSE siswa_cursor;
-END //
-
--- Procedure untuk update status pembayaran dari callback Midtrans
-CREATE PROCEDURE UpdateStatusPembayaran(
-    IN p_order_id VARCHAR(100),
-    IN p_transaction_status VARCHAR(50),
-    IN p_transaction_id VARCHAR(100),
-    IN p_payment_type VARCHAR(50),
-    IN p_settlement_time TIMESTAMP,
-    IN p_midtrans_response JSON
-)
-BEGIN
-    DECLARE v_tagihan_id INT;
-
-    -- Update pembayaran
-    UPDATE pembayaran
-    SET
-        transaction_id = p_transaction_id,
-        status_pembayaran = p_transaction_status,
-        metode_pembayaran = p_payment_type,
-        tanggal_settlement = p_settlement_time,
-        midtrans_response = p_midtrans_response,
-        updated_at = CURRENT_TIMESTAMP
-    WHERE order_id = p_order_id;
-
-    -- Ambil tagihan_id
-    SELECT tagihan_id INTO v_tagihan_id
-    FROM pembayaran
-    WHERE order_id = p_order_id;
-
-    -- Update status tagihan
-    IF p_transaction_status = 'settlement' THEN
-        UPDATE tagihan_spp
-        SET status_pembayaran = 'lunas'
-        WHERE id = v_tagihan_id;
-    ELSEIF p_transaction_status IN ('pending', 'challenge') THEN
-        UPDATE tagihan_spp
-        SET status_pembayaran = 'pending'
-        WHERE id = v_tagihan_id;
-    ELSE
-        UPDATE tagihan_spp
-        SET status_pembayaran = 'belum_bayar'
-        WHERE id = v_tagihan_id;
-    END IF;
-
-END //
-
-DELIMITER ;
+-- Pembuatan tagihan per periode (termasuk tarif per jenis biaya, potongan,
+-- dan mode simulasi) diproses oleh aplikasi (internal/service/bill_service.go).
+
+-- Status pembayaran dan tagihan dari notifikasi Midtrans diproses oleh
+-- aplikasi (internal/service/payment_state.go), bukan oleh stored procedure.
 
 -- ============================
 -- INDEXES TAMBAHAN UNTUK PERFORMA
@@ -381,11 +672,14 @@ CREATE INDEX idx_pembayaran_tanggal ON pembayaran(tanggal_settlement);
 
 /*
 -- Contoh insert periode SPP untuk bulan Januari 2025
-INSERT INTO periode_spp (tahun_ajaran, bulan, nama_bulan, tanggal_mulai, tanggal_selesai, status)
-VALUES ('2024/2025', 1, 'Januari', '2025-01-01', '2025-01-31', 'aktif');
+INSERT INTO periode_spp (tahun_ajaran_id, tahun_ajaran, bulan, nama_bulan, tanggal_mulai, tanggal_selesai, tanggal_jatuh_tempo, status)
+VALUES (1, '2024/2025', 1, 'Januari', '2025-01-01', '2025-01-31', '2025-01-10', 'aktif');
+
+-- Atau buat seluruh periode tahun ajaran sekaligus melalui API:
+-- POST /api/v1/treasurer/academic-years/1/generate-periods
 
--- Generate tagihan untuk periode tersebut
-CALL GenerateTagihanSPP(1);
+-- Generate tagihan untuk periode tersebut melalui API:
+-- POST /api/v1/treasurer/periods/1/generate-bills
 
 -- Contoh query laporan per kelas
 SELECT * FROM v_laporan_kelas WHERE tahun_ajaran = '2024/2025' AND nama_bulan = 'Januari';